@@ -0,0 +1,82 @@
+package inspector
+
+import (
+	"strings"
+	"time"
+)
+
+// Analysis holds results of analyzers run over collected data.
+type Analysis struct {
+	Timeline EventTimeline    `json:"timeline"`
+	Backends NetworkAnalysis  `json:"backends"`
+	Rollouts []RolloutHistory `json:"rollouts"`
+	Findings []Finding        `json:"findings"`
+}
+
+// AnalyzeOptions configures analyzers.
+type AnalyzeOptions struct {
+	// Now is the time certificates are checked at, the current time when zero.
+	Now time.Time
+	// CertExpiryWindow is the window in which expiring certificates
+	// are reported, DefaultCertExpiryWindow when zero.
+	CertExpiryWindow time.Duration
+}
+
+// Analyze runs all analyzers over data collected in the report
+// with default options. It needs no access to the cluster,
+// so it can be run on saved reports.
+func Analyze(rep Report) Analysis {
+	return AnalyzeWithOptions(rep, AnalyzeOptions{})
+}
+
+// AnalyzeWithOptions runs all analyzers over data collected in the report.
+func AnalyzeWithOptions(rep Report, opts AnalyzeOptions) Analysis {
+	if opts.Now.IsZero() {
+		opts.Now = time.Now()
+	}
+	if opts.CertExpiryWindow == 0 {
+		opts.CertExpiryWindow = DefaultCertExpiryWindow
+	}
+	findings := AnalyzeStorage(rep.PersistentVolumeClaims, rep.PersistentVolumes, rep.VolumeAttachments, rep.Pods, rep.Events)
+	findings = append(findings, AnalyzeAutoscaling(rep.HorizontalPodAutoscalers, rep.PodDisruptionBudgets)...)
+	findings = append(findings, AnalyzeWorkloads(rep.Jobs, rep.CronJobs, rep.DaemonSets)...)
+	findings = append(findings, AnalyzeAccess(rep.Namespace, rep.AccessChecks)...)
+	complete := rep.Secrets != nil && !secretsFiltered(rep.Metadata.Options)
+	findings = append(findings, analyzeTLS(rep.Ingresses, rep.Secrets, complete, opts.Now, opts.CertExpiryWindow)...)
+	sortFindings(findings)
+
+	return Analysis{
+		Timeline: AnalyzeEvents(rep.Events, rep.EventsV1, rep.Pods, rep.ReplicaSets),
+		Backends: AnalyzeBackends(rep.Services, rep.Ingresses, rep.EndpointSlices, rep.Endpoints, rep.Pods, rep.NetworkPolicies),
+		Rollouts: AnalyzeRollouts(rep.Deployments, rep.StatefulSets, rep.ReplicaSets, rep.ControllerRevisions),
+		Findings: findings,
+	}
+}
+
+// secretsFiltered reports whether the collection options filtered
+// secrets by selectors or name patterns, so secrets missing from
+// the report may exist in the cluster.
+func secretsFiltered(opts map[string]string) bool {
+	for k := range opts {
+		switch {
+		case k == "label_selector", k == "field_selector", k == "names":
+			return true
+		case strings.HasPrefix(k, "selectors.secrets."):
+			return true
+		}
+	}
+	return false
+}
+
+// analyzeOptions returns options of analyzers run over collected reports.
+func (i *Inspector) analyzeOptions() AnalyzeOptions {
+	return AnalyzeOptions{CertExpiryWindow: i.CertExpiryWindow}
+}
+
+// SetAnalysis stores analysis results in the report.
+func (r *Report) SetAnalysis(a Analysis) {
+	r.Timeline = a.Timeline
+	r.Backends = a.Backends
+	r.Rollouts = a.Rollouts
+	r.Findings = a.Findings
+}
@@ -0,0 +1,107 @@
+package inspector
+
+import (
+	"context"
+	"fmt"
+
+	autoscalingv2 "k8s.io/api/autoscaling/v2"
+	corev1 "k8s.io/api/core/v1"
+	policyv1 "k8s.io/api/policy/v1"
+)
+
+// Autoscaling and disruption checks.
+const (
+	CheckHPAAtMaxReplicas  = "HPAAtMaxReplicas"
+	CheckHPAMetrics        = "HPAMetricsUnavailable"
+	CheckPDBZeroDisruption = "PDBZeroDisruptionsAllowed"
+)
+
+// HorizontalPodAutoscalers returns a list of [horizontal pod autoscalers] in a given namespace.
+//
+// [horizontal pod autoscalers]: https://kubernetes.io/docs/tasks/run-application/horizontal-pod-autoscale/
+func (i *Inspector) HorizontalPodAutoscalers(ctx context.Context, namespace string) (*autoscalingv2.HorizontalPodAutoscalerList, error) {
+	hpas, err := listPages(ctx, i, "horizontal_pod_autoscalers", i.K8sClient.AutoscalingV2().HorizontalPodAutoscalers(namespace).List)
+	if err != nil {
+		return nil, err
+	}
+	return hpas, nil
+}
+
+// PodDisruptionBudgets returns a list of [pod disruption budgets] in a given namespace.
+//
+// [pod disruption budgets]: https://kubernetes.io/docs/concepts/workloads/pods/disruptions/
+func (i *Inspector) PodDisruptionBudgets(ctx context.Context, namespace string) (*policyv1.PodDisruptionBudgetList, error) {
+	pdbs, err := listPages(ctx, i, "pod_disruption_budgets", i.K8sClient.PolicyV1().PodDisruptionBudgets(namespace).List)
+	if err != nil {
+		return nil, err
+	}
+	return pdbs, nil
+}
+
+// ResourceQuotas returns a list of [resource quotas] in a given namespace.
+//
+// [resource quotas]: https://kubernetes.io/docs/concepts/policy/resource-quotas/
+func (i *Inspector) ResourceQuotas(ctx context.Context, namespace string) (*corev1.ResourceQuotaList, error) {
+	quotas, err := listPages(ctx, i, "resource_quotas", i.K8sClient.CoreV1().ResourceQuotas(namespace).List)
+	if err != nil {
+		return nil, err
+	}
+	return quotas, nil
+}
+
+// LimitRanges returns a list of [limit ranges] in a given namespace.
+//
+// [limit ranges]: https://kubernetes.io/docs/concepts/policy/limit-range/
+func (i *Inspector) LimitRanges(ctx context.Context, namespace string) (*corev1.LimitRangeList, error) {
+	limitRanges, err := listPages(ctx, i, "limit_ranges", i.K8sClient.CoreV1().LimitRanges(namespace).List)
+	if err != nil {
+		return nil, err
+	}
+	return limitRanges, nil
+}
+
+// AnalyzeAutoscaling returns findings for horizontal pod autoscalers
+// running at their maximum replicas, autoscalers that cannot fetch
+// metrics and pod disruption budgets that currently allow no disruptions.
+func AnalyzeAutoscaling(hpas *autoscalingv2.HorizontalPodAutoscalerList, pdbs *policyv1.PodDisruptionBudgetList) []Finding {
+	findings := []Finding{}
+	if hpas != nil {
+		for _, hpa := range hpas.Items {
+			ref := ObjectRef{Kind: "HorizontalPodAutoscaler", Namespace: hpa.Namespace, Name: hpa.Name}
+			if hpa.Spec.MaxReplicas > 0 && hpa.Status.CurrentReplicas >= hpa.Spec.MaxReplicas {
+				findings = append(findings, Finding{
+					Check:    CheckHPAAtMaxReplicas,
+					Severity: SeverityWarning,
+					Object:   ref,
+					Message:  fmt.Sprintf("%s %s is running at max replicas (%d)", hpa.Spec.ScaleTargetRef.Kind, hpa.Spec.ScaleTargetRef.Name, hpa.Spec.MaxReplicas),
+				})
+			}
+			for _, c := range hpa.Status.Conditions {
+				if c.Type != autoscalingv2.ScalingActive || c.Status != corev1.ConditionFalse {
+					continue
+				}
+				findings = append(findings, Finding{
+					Check:    CheckHPAMetrics,
+					Severity: SeverityCritical,
+					Object:   ref,
+					Message:  fmt.Sprintf("%s: %s", c.Reason, c.Message),
+				})
+			}
+		}
+	}
+	if pdbs != nil {
+		for _, pdb := range pdbs.Items {
+			if pdb.Status.DisruptionsAllowed > 0 {
+				continue
+			}
+			findings = append(findings, Finding{
+				Check:    CheckPDBZeroDisruption,
+				Severity: SeverityWarning,
+				Object:   ObjectRef{Kind: "PodDisruptionBudget", Namespace: pdb.Namespace, Name: pdb.Name},
+				Message:  fmt.Sprintf("no disruptions allowed (%d of %d desired pods healthy)", pdb.Status.CurrentHealthy, pdb.Status.DesiredHealthy),
+			})
+		}
+	}
+	sortFindings(findings)
+	return findings
+}
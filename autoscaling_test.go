@@ -0,0 +1,150 @@
+package inspector_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/qba73/inspector"
+
+	autoscalingv2 "k8s.io/api/autoscaling/v2"
+	corev1 "k8s.io/api/core/v1"
+	policyv1 "k8s.io/api/policy/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestInspectorListsHorizontalPodAutoscalersInAGivenNamespace(t *testing.T) {
+	t.Parallel()
+
+	i := newTestInspector(hpaAtMax, hpaWithoutMetrics)
+	got, err := i.HorizontalPodAutoscalers(context.Background(), "web")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got.Items) != 2 {
+		t.Errorf("want 2 autoscalers, got %d", len(got.Items))
+	}
+}
+
+func TestInspectorListsPodDisruptionBudgetsInAGivenNamespace(t *testing.T) {
+	t.Parallel()
+
+	i := newTestInspector(pdbBlocking)
+	got, err := i.PodDisruptionBudgets(context.Background(), "web")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got.Items) != 1 {
+		t.Errorf("want 1 pod disruption budget, got %d", len(got.Items))
+	}
+}
+
+func TestInspectorListsResourceQuotasAndLimitRangesInAGivenNamespace(t *testing.T) {
+	t.Parallel()
+
+	i := newTestInspector(
+		&corev1.ResourceQuota{ObjectMeta: metav1.ObjectMeta{Name: "compute", Namespace: "web"}},
+		&corev1.LimitRange{ObjectMeta: metav1.ObjectMeta{Name: "defaults", Namespace: "web"}},
+	)
+	quotas, err := i.ResourceQuotas(context.Background(), "web")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(quotas.Items) != 1 {
+		t.Errorf("want 1 resource quota, got %d", len(quotas.Items))
+	}
+	limits, err := i.LimitRanges(context.Background(), "web")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(limits.Items) != 1 {
+		t.Errorf("want 1 limit range, got %d", len(limits.Items))
+	}
+}
+
+func TestAnalyzeAutoscalingFlagsHPAsAndPDBs(t *testing.T) {
+	t.Parallel()
+
+	got := inspector.AnalyzeAutoscaling(
+		&autoscalingv2.HorizontalPodAutoscalerList{Items: []autoscalingv2.HorizontalPodAutoscaler{*hpaAtMax, *hpaWithoutMetrics}},
+		&policyv1.PodDisruptionBudgetList{Items: []policyv1.PodDisruptionBudget{*pdbBlocking, *pdbHealthy}},
+	)
+	want := []inspector.Finding{
+		{
+			Check:    inspector.CheckHPAAtMaxReplicas,
+			Severity: inspector.SeverityWarning,
+			Object:   inspector.ObjectRef{Kind: "HorizontalPodAutoscaler", Namespace: "web", Name: "frontend"},
+			Message:  "Deployment frontend is running at max replicas (10)",
+		},
+		{
+			Check:    inspector.CheckHPAMetrics,
+			Severity: inspector.SeverityCritical,
+			Object:   inspector.ObjectRef{Kind: "HorizontalPodAutoscaler", Namespace: "web", Name: "backend"},
+			Message:  "FailedGetResourceMetric: the HPA was unable to compute the replica count: unable to get metrics for resource cpu",
+		},
+		{
+			Check:    inspector.CheckPDBZeroDisruption,
+			Severity: inspector.SeverityWarning,
+			Object:   inspector.ObjectRef{Kind: "PodDisruptionBudget", Namespace: "web", Name: "frontend"},
+			Message:  "no disruptions allowed (2 of 2 desired pods healthy)",
+		},
+	}
+	if !cmp.Equal(want, got) {
+		t.Error(cmp.Diff(want, got))
+	}
+}
+
+// Autoscaling and disruption objects used for testing.
+var (
+	hpaAtMax = &autoscalingv2.HorizontalPodAutoscaler{
+		ObjectMeta: metav1.ObjectMeta{Name: "frontend", Namespace: "web"},
+		Spec: autoscalingv2.HorizontalPodAutoscalerSpec{
+			ScaleTargetRef: autoscalingv2.CrossVersionObjectReference{Kind: "Deployment", Name: "frontend"},
+			MaxReplicas:    10,
+		},
+		Status: autoscalingv2.HorizontalPodAutoscalerStatus{
+			CurrentReplicas: 10,
+			DesiredReplicas: 14,
+			Conditions: []autoscalingv2.HorizontalPodAutoscalerCondition{
+				{Type: autoscalingv2.ScalingActive, Status: corev1.ConditionTrue, Reason: "ValidMetricFound"},
+			},
+		},
+	}
+
+	hpaWithoutMetrics = &autoscalingv2.HorizontalPodAutoscaler{
+		ObjectMeta: metav1.ObjectMeta{Name: "backend", Namespace: "web"},
+		Spec: autoscalingv2.HorizontalPodAutoscalerSpec{
+			ScaleTargetRef: autoscalingv2.CrossVersionObjectReference{Kind: "Deployment", Name: "backend"},
+			MaxReplicas:    5,
+		},
+		Status: autoscalingv2.HorizontalPodAutoscalerStatus{
+			CurrentReplicas: 2,
+			Conditions: []autoscalingv2.HorizontalPodAutoscalerCondition{
+				{
+					Type:    autoscalingv2.ScalingActive,
+					Status:  corev1.ConditionFalse,
+					Reason:  "FailedGetResourceMetric",
+					Message: "the HPA was unable to compute the replica count: unable to get metrics for resource cpu",
+				},
+			},
+		},
+	}
+
+	pdbBlocking = &policyv1.PodDisruptionBudget{
+		ObjectMeta: metav1.ObjectMeta{Name: "frontend", Namespace: "web"},
+		Status: policyv1.PodDisruptionBudgetStatus{
+			DisruptionsAllowed: 0,
+			CurrentHealthy:     2,
+			DesiredHealthy:     2,
+		},
+	}
+
+	pdbHealthy = &policyv1.PodDisruptionBudget{
+		ObjectMeta: metav1.ObjectMeta{Name: "backend", Namespace: "web"},
+		Status: policyv1.PodDisruptionBudgetStatus{
+			DisruptionsAllowed: 1,
+			CurrentHealthy:     3,
+			DesiredHealthy:     2,
+		},
+	}
+)
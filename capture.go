@@ -0,0 +1,443 @@
+package inspector
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"maps"
+	"path"
+	"slices"
+	"strings"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	coreinformers "k8s.io/client-go/informers/core/v1"
+	"k8s.io/client-go/tools/cache"
+)
+
+// Incident triggers.
+const (
+	// TriggerCrashLoop fires when a pod container enters CrashLoopBackOff.
+	TriggerCrashLoop = "CrashLoopBackOff"
+	// TriggerOOMKilled fires when a pod container is killed for running
+	// out of memory, or on events reporting it.
+	TriggerOOMKilled = "OOMKilled"
+	// TriggerControllerRestart fires when a container
+	// of an Ingress Controller pod restarts.
+	TriggerControllerRestart = "ControllerRestart"
+)
+
+// Triggers lists all incident triggers.
+var Triggers = []string{TriggerCrashLoop, TriggerOOMKilled, TriggerControllerRestart}
+
+const (
+	// DefaultCaptureCooldown is the period in which a trigger firing
+	// again for the same pod captures no new bundle.
+	DefaultCaptureCooldown = 10 * time.Minute
+	// DefaultCaptureLogLines is the number of log lines
+	// captured from each container.
+	DefaultCaptureLogLines = 1000
+)
+
+// Incident describes a fired trigger.
+type Incident struct {
+	Trigger   string    `json:"trigger"`
+	Time      time.Time `json:"time"`
+	Pod       ObjectRef `json:"pod"`
+	Container string    `json:"container,omitempty"`
+	Message   string    `json:"message"`
+}
+
+// ContainerLog holds logs of a container, or the error of reading them.
+// Previous is set for logs of the previous, terminated container.
+type ContainerLog struct {
+	Pod       string `json:"pod"`
+	Container string `json:"container"`
+	Previous  bool   `json:"previous,omitempty"`
+	Log       string `json:"log,omitempty"`
+	Error     string `json:"error,omitempty"`
+}
+
+// Bundle holds evidence captured for an incident: a report of the
+// namespace, logs of the pod containers, including logs of previous
+// containers, and kubectl describe style text of the pod and
+// of objects of the report.
+type Bundle struct {
+	Incident Incident       `json:"incident"`
+	Report   Report         `json:"report"`
+	Logs     []ContainerLog `json:"logs"`
+	// Describe maps file names to describe style text of objects.
+	Describe map[string]string `json:"describe"`
+}
+
+// Capturer watches pods and events of a namespace and
+// captures a bundle each time an incident trigger fires.
+type Capturer struct {
+	Inspector *Inspector
+	Namespace string
+	// Triggers are names of enabled triggers, all [Triggers] when empty.
+	Triggers []string
+	// ControllerSelector is the label selector of Ingress Controller pods.
+	// When empty, the pods are recognised by their container images.
+	ControllerSelector string
+	// Cooldown is the period in which a trigger firing again for the same
+	// pod captures no new bundle, DefaultCaptureCooldown when zero.
+	Cooldown time.Duration
+	// LogLines is the number of log lines captured
+	// from each container, DefaultCaptureLogLines when zero.
+	LogLines int64
+}
+
+// Run watches the namespace until ctx is canceled and calls handle with
+// the bundle captured for each incident. Incidents are captured one at
+// a time. A failed capture is logged and the watch goes on.
+func (c *Capturer) Run(ctx context.Context, handle func(Bundle) error) error {
+	i := c.Inspector
+	triggers := c.Triggers
+	if len(triggers) == 0 {
+		triggers = Triggers
+	}
+	for _, t := range triggers {
+		if !slices.Contains(Triggers, t) {
+			return &ConfigError{Err: fmt.Errorf("unknown trigger %q, want one of %s", t, strings.Join(Triggers, ", "))}
+		}
+	}
+	controller := labels.Nothing()
+	if c.ControllerSelector != "" {
+		s, err := labels.Parse(c.ControllerSelector)
+		if err != nil {
+			return &ConfigError{Err: fmt.Errorf("controller selector: %w", err)}
+		}
+		controller = s
+	}
+	cooldown := c.Cooldown
+	if cooldown == 0 {
+		cooldown = DefaultCaptureCooldown
+	}
+	ctx, cancel := context.WithCancelCause(ctx)
+	defer cancel(nil)
+
+	detector := &incidentDetector{
+		triggers:   triggers,
+		controller: controller,
+		bySelector: c.ControllerSelector != "",
+	}
+	incidents := make(chan Incident, 100)
+	fire := func(found []Incident) {
+		for _, inc := range found {
+			select {
+			case incidents <- inc:
+			default:
+				i.logger().Warn("incident dropped, captures are behind", "trigger", inc.Trigger, "pod", inc.Pod.String())
+			}
+		}
+	}
+
+	pods := coreinformers.NewPodInformer(i.K8sClient, c.Namespace, 0, cache.Indexers{})
+	events := coreinformers.NewEventInformer(i.K8sClient, c.Namespace, 0, cache.Indexers{})
+	onError := func(collector string) cache.WatchErrorHandler {
+		return func(_ *cache.Reflector, err error) {
+			if apierrors.IsForbidden(err) || apierrors.IsUnauthorized(err) {
+				cancel(newCollectorError(collector, err))
+				return
+			}
+			i.logger().Warn("watch failed", "collector", collector, "error", err)
+		}
+	}
+	if err := pods.SetWatchErrorHandler(onError("pods")); err != nil {
+		return err
+	}
+	if err := events.SetWatchErrorHandler(onError("events")); err != nil {
+		return err
+	}
+	if _, err := pods.AddEventHandler(cache.ResourceEventHandlerFuncs{
+		UpdateFunc: func(oldObj, newObj any) {
+			older, ok1 := oldObj.(*corev1.Pod)
+			newer, ok2 := newObj.(*corev1.Pod)
+			if ok1 && ok2 {
+				fire(detector.pod(older, newer, time.Now()))
+			}
+		},
+	}); err != nil {
+		return err
+	}
+	if _, err := events.AddEventHandler(cache.ResourceEventHandlerDetailedFuncs{
+		AddFunc: func(obj any, isInInitialList bool) {
+			// Events listed when the watch starts happened before it.
+			if e, ok := obj.(*corev1.Event); ok && !isInInitialList {
+				fire(detector.event(nil, e, time.Now()))
+			}
+		},
+		UpdateFunc: func(oldObj, newObj any) {
+			older, ok1 := oldObj.(*corev1.Event)
+			newer, ok2 := newObj.(*corev1.Event)
+			if ok1 && ok2 {
+				fire(detector.event(older, newer, time.Now()))
+			}
+		},
+	}); err != nil {
+		return err
+	}
+	go pods.Run(ctx.Done())
+	go events.Run(ctx.Done())
+	if !cache.WaitForCacheSync(ctx.Done(), pods.HasSynced, events.HasSynced) {
+		return watchStopped(ctx)
+	}
+	i.logger().Info("capture started", "namespace", c.Namespace, "triggers", strings.Join(triggers, ","))
+
+	captured := map[string]time.Time{}
+	for {
+		var inc Incident
+		select {
+		case <-ctx.Done():
+			return watchStopped(ctx)
+		case inc = <-incidents:
+		}
+		key := inc.Trigger + "/" + inc.Pod.String()
+		if last, ok := captured[key]; ok && inc.Time.Sub(last) < cooldown {
+			i.logger().Debug("incident skipped in cooldown", "trigger", inc.Trigger, "pod", inc.Pod.String())
+			continue
+		}
+		captured[key] = inc.Time
+		i.logger().Info("incident", "trigger", inc.Trigger, "pod", inc.Pod.String(), "message", inc.Message)
+		bundle, err := c.Capture(ctx, inc)
+		if err != nil {
+			if ctx.Err() != nil {
+				return watchStopped(ctx)
+			}
+			i.logger().Error("capture failed", "trigger", inc.Trigger, "pod", inc.Pod.String(), "kind", ErrorKind(err), "error", err.Error())
+			continue
+		}
+		if err := handle(bundle); err != nil {
+			return err
+		}
+	}
+}
+
+// Capture captures a bundle of the incident: a report of the namespace,
+// current and previous logs of the pod containers and kubectl describe
+// style text of the pod and of objects of the report, all redacted
+// with the inspector redaction rules. Reports of partial collections
+// are captured.
+func (c *Capturer) Capture(ctx context.Context, inc Incident) (Bundle, error) {
+	i := c.Inspector
+	redact, err := newRedactor(i.Redact)
+	if err != nil {
+		return Bundle{}, err
+	}
+	// The pod and its logs are read before the report is collected,
+	// so logs of the restarted container are not rotated away
+	// while the namespace is collected.
+	logs := []ContainerLog{}
+	pod, err := retry(ctx, i, func(ctx context.Context) (*corev1.Pod, error) {
+		return i.K8sClient.CoreV1().Pods(inc.Pod.Namespace).Get(ctx, inc.Pod.Name, metav1.GetOptions{})
+	})
+	if err != nil {
+		// The pod may be gone already, the report holds what is left.
+		i.logger().Warn("incident pod not found", "pod", inc.Pod.String(), "error", err)
+		pod = nil
+	} else {
+		lines := c.LogLines
+		if lines == 0 {
+			lines = DefaultCaptureLogLines
+		}
+		for _, container := range append(slices.Clone(pod.Spec.InitContainers), pod.Spec.Containers...) {
+			logs = append(logs, c.containerLog(ctx, pod, container.Name, false, lines))
+			if restarted(pod, container.Name) {
+				logs = append(logs, c.containerLog(ctx, pod, container.Name, true, lines))
+			}
+		}
+	}
+
+	report, err := i.Report(ctx, inc.Pod.Namespace)
+	var partial *PartialCollectionError
+	if err != nil && !errors.As(err, &partial) {
+		return Bundle{}, err
+	}
+	describe, err := DescribeReport(report, time.Now())
+	if err != nil {
+		return Bundle{}, err
+	}
+	bundle := Bundle{Incident: inc, Report: report, Logs: logs, Describe: describe}
+	if pod == nil {
+		return bundle, nil
+	}
+	// Logs and the pod are read outside the report,
+	// so they are redacted like data of the report.
+	if redact != nil {
+		for n := range bundle.Logs {
+			bundle.Logs[n].Log = redact(bundle.Logs[n].Log)
+		}
+		redactObject(pod, redact)
+	}
+	// The pod is described as it was read, with the restart
+	// that triggered the incident, rather than as collected.
+	text, err := DescribePod(*pod, reportEvents(report), time.Now())
+	if err != nil {
+		return Bundle{}, err
+	}
+	bundle.Describe["pods/"+pod.Name+".txt"] = text
+	return bundle, nil
+}
+
+// containerLog reads the last lines of logs of the container.
+func (c *Capturer) containerLog(ctx context.Context, pod *corev1.Pod, container string, previous bool, lines int64) ContainerLog {
+	i := c.Inspector
+	cl := ContainerLog{Pod: pod.Name, Container: container, Previous: previous}
+	log, err := retryWithTimeout(ctx, i, 0, func(ctx context.Context) ([]byte, error) {
+		res, err := i.K8sClient.CoreV1().Pods(pod.Namespace).GetLogs(pod.Name, &corev1.PodLogOptions{
+			Container: container,
+			Previous:  previous,
+			TailLines: &lines,
+		}).Stream(ctx)
+		if err != nil {
+			return nil, err
+		}
+		defer res.Close()
+		return io.ReadAll(res)
+	})
+	if err != nil {
+		cl.Error = err.Error()
+		return cl
+	}
+	cl.Log = string(log)
+	return cl
+}
+
+// restarted reports whether the named container of the pod restarted,
+// so logs of its previous container may be kept.
+func restarted(pod *corev1.Pod, container string) bool {
+	for _, s := range append(slices.Clone(pod.Status.InitContainerStatuses), pod.Status.ContainerStatuses...) {
+		if s.Name == container {
+			return s.RestartCount > 0
+		}
+	}
+	return false
+}
+
+// incidentDetector finds incidents in pod and event changes.
+type incidentDetector struct {
+	triggers   []string
+	controller labels.Selector
+	// bySelector is set when controller pods are found by the
+	// selector, otherwise by their container images.
+	bySelector bool
+}
+
+func (d *incidentDetector) enabled(trigger string) bool {
+	return slices.Contains(d.triggers, trigger)
+}
+
+// pod returns incidents of containers whose status changed in newer.
+func (d *incidentDetector) pod(older, newer *corev1.Pod, now time.Time) []Incident {
+	ref := ObjectRef{Kind: "Pod", Namespace: newer.Namespace, Name: newer.Name}
+	isController := runsIngressController(*newer)
+	if d.bySelector {
+		isController = d.controller.Matches(labels.Set(newer.Labels))
+	}
+	var incidents []Incident
+	for _, s := range newer.Status.ContainerStatuses {
+		var prev corev1.ContainerStatus
+		if i := slices.IndexFunc(older.Status.ContainerStatuses, func(o corev1.ContainerStatus) bool { return o.Name == s.Name }); i >= 0 {
+			prev = older.Status.ContainerStatuses[i]
+		}
+		newIncident := func(trigger, message string) Incident {
+			return Incident{Trigger: trigger, Time: now, Pod: ref, Container: s.Name, Message: message}
+		}
+		if d.enabled(TriggerCrashLoop) && waitingReason(s) == "CrashLoopBackOff" && waitingReason(prev) != "CrashLoopBackOff" {
+			incidents = append(incidents, newIncident(TriggerCrashLoop, fmt.Sprintf("container %s is in CrashLoopBackOff after %d restarts", s.Name, s.RestartCount)))
+		}
+		if s.RestartCount <= prev.RestartCount {
+			continue
+		}
+		if t := s.LastTerminationState.Terminated; d.enabled(TriggerOOMKilled) && t != nil && t.Reason == "OOMKilled" {
+			incidents = append(incidents, newIncident(TriggerOOMKilled, fmt.Sprintf("container %s was OOMKilled", s.Name)))
+		}
+		if d.enabled(TriggerControllerRestart) && isController {
+			incidents = append(incidents, newIncident(TriggerControllerRestart, fmt.Sprintf("Ingress Controller container %s restarted, %d restarts", s.Name, s.RestartCount)))
+		}
+	}
+	return incidents
+}
+
+// event returns an incident of an OOM event of a pod,
+// new or repeated since older.
+func (d *incidentDetector) event(older, newer *corev1.Event, now time.Time) []Incident {
+	if !d.enabled(TriggerOOMKilled) || newer.InvolvedObject.Kind != "Pod" {
+		return nil
+	}
+	if newer.Reason != "OOMKilled" && newer.Reason != "OOMKilling" {
+		return nil
+	}
+	if older != nil && newer.Count <= older.Count {
+		return nil
+	}
+	ref := ObjectRef{Kind: "Pod", Namespace: newer.InvolvedObject.Namespace, Name: newer.InvolvedObject.Name}
+	return []Incident{{Trigger: TriggerOOMKilled, Time: now, Pod: ref, Message: newer.Message}}
+}
+
+func waitingReason(s corev1.ContainerStatus) string {
+	if s.State.Waiting == nil {
+		return ""
+	}
+	return s.State.Waiting.Reason
+}
+
+// WriteArchive writes the bundle as a gzipped tar archive holding
+// incident.json, report.json, logs of each container in
+// logs/<pod>/<container>.log, or <container>.previous.log for
+// previous containers, and describe style text in describe/.
+func (b Bundle) WriteArchive(w io.Writer) error {
+	gz := gzip.NewWriter(w)
+	tw := tar.NewWriter(gz)
+	add := func(name string, data []byte) error {
+		if err := tw.WriteHeader(&tar.Header{Name: name, Mode: 0o644, Size: int64(len(data)), ModTime: b.Incident.Time}); err != nil {
+			return err
+		}
+		_, err := tw.Write(data)
+		return err
+	}
+	incident, err := json.MarshalIndent(b.Incident, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := add("incident.json", append(incident, '\n')); err != nil {
+		return err
+	}
+	report, err := ReportJSON(b.Report)
+	if err != nil {
+		return err
+	}
+	if err := add("report.json", []byte(report+"\n")); err != nil {
+		return err
+	}
+	for _, l := range b.Logs {
+		name := l.Container + ".log"
+		if l.Previous {
+			name = l.Container + ".previous.log"
+		}
+		data := l.Log
+		if l.Error != "" {
+			data = "error reading logs: " + l.Error + "\n"
+		}
+		if err := add(path.Join("logs", l.Pod, name), []byte(data)); err != nil {
+			return err
+		}
+	}
+	for _, name := range slices.Sorted(maps.Keys(b.Describe)) {
+		if err := add(path.Join("describe", name), []byte(b.Describe[name])); err != nil {
+			return err
+		}
+	}
+	if err := tw.Close(); err != nil {
+		return err
+	}
+	return gz.Close()
+}
@@ -0,0 +1,300 @@
+package inspector_test
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/qba73/inspector"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+)
+
+func TestCapturerCapturesBundlesWhenTriggersFire(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name       string
+		trigger    string
+		selector   string
+		pod        *corev1.Pod
+		update     func(ctx context.Context, client kubernetes.Interface) error
+		wantPodRef string
+	}{
+		{
+			name:    "crash loop",
+			trigger: inspector.TriggerCrashLoop,
+			pod:     incidentPod("web", "nginx:1.27", 0),
+			update: func(ctx context.Context, client kubernetes.Interface) error {
+				pod := incidentPod("web", "nginx:1.27", 3)
+				pod.Status.ContainerStatuses[0].State = corev1.ContainerState{Waiting: &corev1.ContainerStateWaiting{Reason: "CrashLoopBackOff"}}
+				_, err := client.CoreV1().Pods("shop").UpdateStatus(ctx, pod, metav1.UpdateOptions{})
+				return err
+			},
+			wantPodRef: "Pod/shop/web",
+		},
+		{
+			name:    "OOMKilled container",
+			trigger: inspector.TriggerOOMKilled,
+			pod:     incidentPod("web", "nginx:1.27", 0),
+			update: func(ctx context.Context, client kubernetes.Interface) error {
+				pod := incidentPod("web", "nginx:1.27", 1)
+				pod.Status.ContainerStatuses[0].LastTerminationState = corev1.ContainerState{Terminated: &corev1.ContainerStateTerminated{Reason: "OOMKilled", ExitCode: 137}}
+				_, err := client.CoreV1().Pods("shop").UpdateStatus(ctx, pod, metav1.UpdateOptions{})
+				return err
+			},
+			wantPodRef: "Pod/shop/web",
+		},
+		{
+			name:    "OOM event",
+			trigger: inspector.TriggerOOMKilled,
+			pod:     incidentPod("web", "nginx:1.27", 0),
+			update: func(ctx context.Context, client kubernetes.Interface) error {
+				_, err := client.CoreV1().Events("shop").Create(ctx, &corev1.Event{
+					ObjectMeta:     metav1.ObjectMeta{Name: "web.oom", Namespace: "shop"},
+					InvolvedObject: corev1.ObjectReference{Kind: "Pod", Namespace: "shop", Name: "web"},
+					Reason:         "OOMKilled",
+					Message:        "Memory cgroup out of memory",
+					Count:          1,
+				}, metav1.CreateOptions{})
+				return err
+			},
+			wantPodRef: "Pod/shop/web",
+		},
+		{
+			name:    "Ingress Controller restart",
+			trigger: inspector.TriggerControllerRestart,
+			pod:     incidentPod("controller", "nginx/nginx-ingress:5.0.0", 0),
+			update: func(ctx context.Context, client kubernetes.Interface) error {
+				_, err := client.CoreV1().Pods("shop").UpdateStatus(ctx, incidentPod("controller", "nginx/nginx-ingress:5.0.0", 1), metav1.UpdateOptions{})
+				return err
+			},
+			wantPodRef: "Pod/shop/controller",
+		},
+		{
+			name:     "Ingress Controller restart matched by selector",
+			trigger:  inspector.TriggerControllerRestart,
+			selector: "app=web",
+			pod:      incidentPod("web", "nginx:1.27", 0),
+			update: func(ctx context.Context, client kubernetes.Interface) error {
+				_, err := client.CoreV1().Pods("shop").UpdateStatus(ctx, incidentPod("web", "nginx:1.27", 1), metav1.UpdateOptions{})
+				return err
+			},
+			wantPodRef: "Pod/shop/web",
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			client := newTestClientset(tc.pod)
+			started := make(chan struct{}, 1)
+			c := &inspector.Capturer{
+				Inspector: &inspector.Inspector{
+					K8sClient:  client,
+					Collectors: []string{"pods", "events"},
+					Logger:     slog.New(&messageHandler{message: "capture started", seen: started}),
+				},
+				Namespace:          "shop",
+				Triggers:           []string{tc.trigger},
+				ControllerSelector: tc.selector,
+			}
+			bundles := make(chan inspector.Bundle, 1)
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+			go c.Run(ctx, func(b inspector.Bundle) error {
+				bundles <- b
+				return nil
+			})
+			<-started
+			if err := tc.update(ctx, client); err != nil {
+				t.Fatal(err)
+			}
+			select {
+			case b := <-bundles:
+				if b.Incident.Trigger != tc.trigger || b.Incident.Pod.String() != tc.wantPodRef {
+					t.Errorf("want %s incident of %s, got %+v", tc.trigger, tc.wantPodRef, b.Incident)
+				}
+			case <-time.After(5 * time.Second):
+				t.Fatal("want bundle captured")
+			}
+		})
+	}
+}
+
+func TestCapturerRejectsUnknownTriggers(t *testing.T) {
+	t.Parallel()
+
+	c := &inspector.Capturer{
+		Inspector: &inspector.Inspector{K8sClient: newTestClientset()},
+		Namespace: "shop",
+		Triggers:  []string{"Evicted"},
+	}
+	err := c.Run(context.Background(), func(inspector.Bundle) error { return nil })
+	if inspector.ExitCode(err) != inspector.ExitConfig {
+		t.Errorf("want configuration error, got %v", err)
+	}
+}
+
+func TestCapturerCaptureBundlesLogsOfPreviousContainers(t *testing.T) {
+	t.Parallel()
+
+	pod := incidentPod("web", "nginx:1.27", 2)
+	pod.Status.ContainerStatuses[0].State = corev1.ContainerState{Waiting: &corev1.ContainerStateWaiting{Reason: "CrashLoopBackOff"}}
+	c := &inspector.Capturer{
+		Inspector: &inspector.Inspector{K8sClient: newTestClientset(pod), Collectors: []string{"pods"}},
+		Namespace: "shop",
+	}
+	inc := inspector.Incident{
+		Trigger: inspector.TriggerCrashLoop,
+		Time:    time.Date(2026, 6, 1, 9, 0, 0, 0, time.UTC),
+		Pod:     inspector.ObjectRef{Kind: "Pod", Namespace: "shop", Name: "web"},
+	}
+	b, err := c.Capture(context.Background(), inc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	wantLogs := []inspector.ContainerLog{
+		{Pod: "web", Container: "web", Log: "fake logs"},
+		{Pod: "web", Container: "web", Previous: true, Log: "fake logs"},
+	}
+	if !cmp.Equal(wantLogs, b.Logs) {
+		t.Error(cmp.Diff(wantLogs, b.Logs))
+	}
+	if b.Report.Pods == nil || len(b.Report.Pods.Items) != 1 {
+		t.Errorf("want report of the namespace, got %+v", b.Report)
+	}
+	if !strings.Contains(b.Describe["pods/web.txt"], "CrashLoopBackOff") {
+		t.Errorf("want describe output of the pod, got %q", b.Describe["pods/web.txt"])
+	}
+
+	var archive bytes.Buffer
+	if err := b.WriteArchive(&archive); err != nil {
+		t.Fatal(err)
+	}
+	gz, err := gzip.NewReader(&archive)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tr := tar.NewReader(gz)
+	var names []string
+	for {
+		h, err := tr.Next()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		names = append(names, h.Name)
+	}
+	wantNames := []string{"incident.json", "report.json", "logs/web/web.log", "logs/web/web.previous.log", "describe/pods/web.txt"}
+	if !cmp.Equal(wantNames, names) {
+		t.Error(cmp.Diff(wantNames, names))
+	}
+}
+
+func TestCapturerCaptureRedactsLogsAndDescribedPod(t *testing.T) {
+	t.Parallel()
+
+	pod := incidentPod("web", "nginx:1.27", 1)
+	pod.Spec.Containers[0].Env = []corev1.EnvVar{{Name: "DB_PASSWORD", Value: "hunter2"}}
+	c := &inspector.Capturer{
+		Inspector: &inspector.Inspector{
+			K8sClient:  newTestClientset(pod),
+			Collectors: []string{"pods"},
+			Redact:     []inspector.RedactRule{{Name: "secrets", Pattern: "hunter2|^fake"}},
+		},
+		Namespace: "shop",
+	}
+	inc := inspector.Incident{Trigger: inspector.TriggerControllerRestart, Pod: inspector.ObjectRef{Kind: "Pod", Namespace: "shop", Name: "web"}}
+	b, err := c.Capture(context.Background(), inc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, l := range b.Logs {
+		if l.Log != "REDACTED logs" {
+			t.Errorf("want redacted logs of %s, got %q", l.Container, l.Log)
+		}
+	}
+	describe := b.Describe["pods/web.txt"]
+	if strings.Contains(describe, "hunter2") || !strings.Contains(describe, "DB_PASSWORD:  REDACTED") {
+		t.Errorf("want redacted environment in describe output, got\n%s", describe)
+	}
+}
+
+func TestCapturerCaptureReadsPodLogsBeforeCollectingTheReport(t *testing.T) {
+	t.Parallel()
+
+	client := newTestClientset(incidentPod("web", "nginx:1.27", 1))
+	c := &inspector.Capturer{
+		Inspector: &inspector.Inspector{K8sClient: client, Collectors: []string{"pods", "events"}},
+		Namespace: "shop",
+	}
+	inc := inspector.Incident{Trigger: inspector.TriggerControllerRestart, Pod: inspector.ObjectRef{Kind: "Pod", Namespace: "shop", Name: "web"}}
+	if _, err := c.Capture(context.Background(), inc); err != nil {
+		t.Fatal(err)
+	}
+	var got []string
+	for _, a := range client.Actions() {
+		resource := a.GetResource().Resource
+		if a.GetSubresource() != "" {
+			resource += "/" + a.GetSubresource()
+		}
+		got = append(got, a.GetVerb()+" "+resource)
+	}
+	want := []string{"get pods", "get pods/log", "get pods/log"}
+	if len(got) < len(want) || !cmp.Equal(want, got[:len(want)]) {
+		t.Errorf("want pod and its current and previous logs read first, got %v", got)
+	}
+}
+
+// incidentPod returns a running pod of the shop namespace
+// with a container of the image restarted the given times.
+func incidentPod(name, image string, restarts int32) *corev1.Pod {
+	return &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "shop", Labels: map[string]string{"app": name}},
+		Spec:       corev1.PodSpec{Containers: []corev1.Container{{Name: name, Image: image}}},
+		Status: corev1.PodStatus{
+			Phase: corev1.PodRunning,
+			ContainerStatuses: []corev1.ContainerStatus{{
+				Name:         name,
+				Ready:        true,
+				RestartCount: restarts,
+				State:        corev1.ContainerState{Running: &corev1.ContainerStateRunning{}},
+			}},
+		},
+	}
+}
+
+// messageHandler is a log handler signaling when the message is logged.
+type messageHandler struct {
+	message string
+	seen    chan struct{}
+}
+
+func (h *messageHandler) Enabled(context.Context, slog.Level) bool { return true }
+
+func (h *messageHandler) Handle(_ context.Context, r slog.Record) error {
+	if r.Message == h.message {
+		select {
+		case h.seen <- struct{}{}:
+		default:
+		}
+	}
+	return nil
+}
+
+func (h *messageHandler) WithAttrs([]slog.Attr) slog.Handler { return h }
+
+func (h *messageHandler) WithGroup(string) slog.Handler { return h }
@@ -0,0 +1,1001 @@
+package inspector
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log/slog"
+	"maps"
+	"os"
+	"os/signal"
+	"slices"
+	"strconv"
+	"strings"
+	"syscall"
+	"text/tabwriter"
+	"time"
+
+	"sigs.k8s.io/yaml"
+)
+
+var usage = `Usage:
+
+	inspector [command] [flags]
+	kubectl inspector [command] [flags]
+
+Collect K8s and Ingress Controller diagnostics.
+
+Commands:
+
+	collect     Collect a diagnostics report from a namespace (default command)
+	analyze     Analyze a namespace or a saved report
+	diff        Compare two saved reports
+	describe    Print kubectl describe style text of objects of a namespace or a saved report
+	profiles    List built-in collection profiles
+	preflight   Check permissions needed by collectors
+	deploy      Generate or apply manifests running inspector as a Job or CronJob
+	serve       Serve reports, findings and Prometheus metrics over HTTP
+	watch       Watch a namespace and print findings as they appear or resolve
+	capture     Capture incident bundles when pods crash, run out of memory or restart
+	schema      Print the JSON Schema of reports
+	version     Print the inspector version
+	completion  Print a shell completion script for bash, zsh, fish or kubectl
+
+Run 'inspector <command> -h' to list command flags.
+
+Collect a profile to choose collectors, namespaces, selectors,
+redaction rules and output with 'inspector collect -profile <name|file>'.
+Flags override profile settings.
+
+Flag defaults are read from the config file given in INSPECTOR_CONFIG
+or from inspector/config.yaml in the user config directory.
+Flags given on the command line and profile settings take precedence.
+
+Progress and errors are written to stderr in the format selected
+with -log-format, so stdout contains only the command output.
+In verbose mode (-v), progress of each collector is printed out.
+
+Exit codes:
+
+	0  success
+	1  internal error
+	2  invalid flags, config file or kubeconfig
+	3  authentication or authorization failure
+	4  cluster unreachable or unavailable
+	5  some collectors failed, partial report written to stdout
+	6  analyze found findings of the -fail-on severity or higher`
+
+// command is a CLI subcommand.
+type command struct {
+	name    string
+	args    string
+	summary string
+	// setup registers command flags and returns a function running
+	// the command with positional arguments left after parsing flags.
+	setup func(fs *flag.FlagSet, stdout, stderr io.Writer) func(args []string) int
+}
+
+// commandList returns all CLI subcommands.
+func commandList() []command {
+	return []command{
+		{name: "collect", summary: "Collect a diagnostics report from a namespace.", setup: setupCollect},
+		{name: "analyze", summary: "Analyze a namespace or a saved report.", setup: setupAnalyze},
+		{name: "diff", args: "old.json new.json", summary: "Compare two saved reports.", setup: setupDiff},
+		{name: "describe", args: "[kind[/name]...]", summary: "Print kubectl describe style text of pods, deployments, services, ingresses and nodes of a namespace or a saved report.", setup: setupDescribe},
+		{name: "profiles", args: "[name]", summary: "List built-in collection profiles or print one of them.", setup: setupProfiles},
+		{name: "preflight", summary: "Check permissions needed by collectors.", setup: setupPreflight},
+		{name: "deploy", summary: "Generate or apply manifests running inspector in the cluster as a Job or CronJob.", setup: setupDeploy},
+		{name: "serve", summary: "Serve reports, findings and Prometheus metrics over HTTP.", setup: setupServe},
+		{name: "watch", summary: "Watch a namespace and print findings as they appear or resolve.", setup: setupWatch},
+		{name: "capture", summary: "Capture incident bundles when pods crash loop, are OOMKilled or Ingress Controller pods restart.", setup: setupCapture},
+		{name: "schema", summary: "Print the JSON Schema of reports.", setup: setupSchema},
+		{name: "version", summary: "Print the inspector version.", setup: setupVersion},
+		{name: "completion", args: "bash|zsh|fish|kubectl", summary: "Print a shell completion script.", setup: setupCompletion},
+	}
+}
+
+// findCommand returns the named subcommand.
+func findCommand(name string) (command, bool) {
+	for _, c := range commandList() {
+		if c.name == name {
+			return c, true
+		}
+	}
+	return command{}, false
+}
+
+// Run runs the CLI with the given arguments and returns the exit code.
+// When the first argument is not a command, the collect command is run.
+func Run(args []string, stdout, stderr io.Writer) int {
+	name := "collect"
+	if len(args) > 0 && !strings.HasPrefix(args[0], "-") {
+		name, args = args[0], args[1:]
+	}
+	if len(args) == 1 && name == "collect" && isHelpFlag(args[0]) {
+		name = "help"
+	}
+	switch name {
+	case "help":
+		fmt.Fprintln(stdout, usage)
+		return ExitOK
+	case completeCommand:
+		return complete(args, stdout)
+	}
+
+	cmd, ok := findCommand(name)
+	if !ok {
+		fmt.Fprintln(stderr, usage)
+		return reportError(stderr, "text", &ConfigError{Err: fmt.Errorf("unknown command %q", name)})
+	}
+	fs := flag.NewFlagSet(name, flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+	help := fs.Bool("h", false, "show help")
+	runCommand := cmd.setup(fs, stdout, stderr)
+
+	cfg, err := LoadConfig(DefaultConfigPath())
+	if err != nil {
+		return reportError(stderr, "text", err)
+	}
+	if err := cfg.apply(name, fs); err != nil {
+		return reportError(stderr, "text", err)
+	}
+	if err := fs.Parse(args); err != nil {
+		printCommandUsage(stderr, cmd, fs)
+		return reportError(stderr, logFormat(fs), &ConfigError{Err: err})
+	}
+	if *help {
+		printCommandUsage(stdout, cmd, fs)
+		return ExitOK
+	}
+	return runCommand(fs.Args())
+}
+
+// isHelpFlag reports whether arg asks for help.
+func isHelpFlag(arg string) bool {
+	return arg == "-h" || arg == "--h" || arg == "-help" || arg == "--help"
+}
+
+// printCommandUsage writes command synopsis and flags to w.
+func printCommandUsage(w io.Writer, cmd command, fs *flag.FlagSet) {
+	fmt.Fprintf(w, "Usage:\n\n\tinspector %s [flags] %s\n\n%s\n\nFlags:\n\n", cmd.name, cmd.args, cmd.summary)
+	fs.SetOutput(w)
+	fs.PrintDefaults()
+	fs.SetOutput(io.Discard)
+}
+
+// logFormat returns the log format selected with the
+// -log-format flag, falling back to text.
+func logFormat(fs *flag.FlagSet) string {
+	if f := fs.Lookup("log-format"); f != nil && f.Value.String() == "json" {
+		return "json"
+	}
+	return "text"
+}
+
+// clusterFlags holds flags shared by commands talking to the cluster.
+type clusterFlags struct {
+	kubeconfig string
+	context    string
+	inCluster  bool
+	verbose    bool
+	logFormat  string
+	logLevel   string
+	pageSize   int64
+	qps        float64
+	burst      int
+	timeout    time.Duration
+	retries    int
+}
+
+// register registers cluster connection and logging flags.
+func (f *clusterFlags) register(fs *flag.FlagSet) {
+	fs.StringVar(&f.kubeconfig, "kubeconfig", "", "path to the kubeconfig file")
+	fs.StringVar(&f.context, "context", "", "kubeconfig context to use")
+	fs.BoolVar(&f.inCluster, "in-cluster", false, "connect with the service account of the pod inspector runs in, ignoring kubeconfig")
+	fs.BoolVar(&f.verbose, "v", false, "verbose output")
+	fs.StringVar(&f.logFormat, "log-format", "text", "log output format: text or json")
+	fs.StringVar(&f.logLevel, "log-level", "", "log level: debug, info, warn or error")
+	fs.Int64Var(&f.pageSize, "page-size", DefaultPageSize, "number of objects requested per list call")
+	fs.Float64Var(&f.qps, "qps", 5, "maximum API calls per second")
+	fs.IntVar(&f.burst, "burst", 10, "maximum burst of API calls above qps")
+	fs.DurationVar(&f.timeout, "timeout", 30*time.Second, "timeout of each API call except log reads and watches, 0 disables it")
+	fs.IntVar(&f.retries, "retries", DefaultRetryPolicy.MaxRetries, "retries of API calls failing with throttling, server or connection errors")
+}
+
+// format returns a valid log format for reporting errors.
+func (f *clusterFlags) format() string {
+	if f.logFormat == "json" {
+		return "json"
+	}
+	return "text"
+}
+
+// logger returns a logger writing progress to w at the selected level.
+// Verbose mode logs at the info level, otherwise only warnings are logged.
+func (f *clusterFlags) logger(w io.Writer) (*slog.Logger, error) {
+	if err := validateLogFormat(f.logFormat); err != nil {
+		return nil, err
+	}
+	level := slog.LevelWarn
+	if f.verbose {
+		level = slog.LevelInfo
+	}
+	if f.logLevel != "" {
+		l, err := parseLogLevel(f.logLevel)
+		if err != nil {
+			return nil, err
+		}
+		level = l
+	}
+	return newLogger(w, f.logFormat, level), nil
+}
+
+// inspector builds an inspector client logging to w.
+func (f *clusterFlags) inspector(w io.Writer) (*Inspector, error) {
+	logger, err := f.logger(w)
+	if err != nil {
+		return nil, err
+	}
+	i, err := BuildInspectorWithOptions(ClientOptions{
+		Kubeconfig: f.kubeconfig,
+		Context:    f.context,
+		QPS:        float32(f.qps),
+		Burst:      f.burst,
+		Timeout:    f.timeout,
+		InCluster:  f.inCluster,
+	})
+	if err != nil {
+		return nil, &ConfigError{Err: err}
+	}
+	i.Verbose = f.verbose
+	i.Logger = logger
+	i.PageSize = f.pageSize
+	i.Retry = DefaultRetryPolicy
+	i.Retry.MaxRetries = f.retries
+	i.Options = map[string]string{
+		"qps":     strconv.FormatFloat(f.qps, 'f', -1, 64),
+		"burst":   strconv.Itoa(f.burst),
+		"timeout": f.timeout.String(),
+	}
+	return i, nil
+}
+
+// namespaceFlag registers the -n flag and its -namespace alias.
+func namespaceFlag(fs *flag.FlagSet, usage string) *string {
+	namespace := fs.String("n", "default", usage)
+	fs.StringVar(namespace, "namespace", "default", usage)
+	return namespace
+}
+
+// setupCollect sets up the collect command.
+func setupCollect(fs *flag.FlagSet, stdout, stderr io.Writer) func([]string) int {
+	var cf clusterFlags
+	cf.register(fs)
+	namespace := namespaceFlag(fs, "K8s namespace, comma separated namespaces are collected into a report each")
+	profileName := fs.String("profile", "", "built-in profile ("+strings.Join(BuiltinProfiles(), ", ")+") or profile file")
+	format := fs.String("o", "json", "report format: json, yaml or jsonl, which streams objects as they are listed")
+	out := fs.String("out", "", "report destination: a file, s3://bucket/key or an http(s) upload URL, {namespace} is replaced with the namespace (default stdout)")
+	labelSelector := fs.String("l", "", "label selector filtering objects of namespaced collectors, except events")
+	fieldSelector := fs.String("field-selector", "", "field selector filtering objects of namespaced collectors, except events")
+	names := fs.String("name", "", "comma separated name globs, like web-*, filtering objects of namespaced collectors, except events")
+	trim := fs.String("trim", TrimOptions{}.String(), "comma separated fields removed from collected objects: managed-fields, last-applied, status, resource-version or none")
+	expiryWindow := fs.Duration("cert-expiry-window", DefaultCertExpiryWindow, "report certificates expiring within the window")
+	neat := fs.Bool("neat", false, "write collected objects as clean manifests, without status and fields set by the cluster")
+	owner := fs.String("owner", "", "collect only the deployment, statefulset, daemonset, service or ingress given as kind/name and objects it owns or references")
+	return func(args []string) int {
+		var profile Profile
+		if *profileName != "" {
+			p, err := LoadProfile(*profileName)
+			if err != nil {
+				return reportError(stderr, cf.format(), err)
+			}
+			profile = p
+		}
+		set := setFlags(fs)
+		namespaces := strings.Split(*namespace, ",")
+		if !set["n"] && !set["namespace"] && len(profile.Namespaces) > 0 {
+			namespaces = profile.Namespaces
+		}
+		if !set["log-level"] && profile.Log.Level != "" {
+			cf.logLevel = profile.Log.Level
+		}
+		if !set["log-format"] && profile.Log.Format != "" {
+			cf.logFormat = profile.Log.Format
+		}
+		if !set["o"] && profile.Output.Format != "" {
+			*format = profile.Output.Format
+		}
+		if !set["out"] && profile.Output.Destination != "" {
+			*out = profile.Output.Destination
+		}
+		if *format != "json" && *format != "yaml" && *format != "jsonl" {
+			return reportError(stderr, cf.format(), &ConfigError{Err: fmt.Errorf("unknown report format %q", *format)})
+		}
+
+		i, err := cf.inspector(stderr)
+		if err != nil {
+			return reportError(stderr, cf.format(), err)
+		}
+		profile.Apply(i)
+		i.CertExpiryWindow = *expiryWindow
+		i.Options["output"] = *format
+		if *profileName != "" {
+			i.Options["profile"] = *profileName
+		}
+		if *owner != "" {
+			i.Options["owner"] = *owner
+		}
+		if set["l"] {
+			i.LabelSelector = *labelSelector
+		}
+		if set["field-selector"] {
+			i.FieldSelector = *fieldSelector
+		}
+		if set["trim"] {
+			t, err := ParseTrimOptions(*trim)
+			if err != nil {
+				return reportError(stderr, cf.format(), &ConfigError{Err: err})
+			}
+			t.Neat = i.Trim.Neat
+			i.Trim = t
+		}
+		if set["neat"] {
+			i.Trim.Neat = *neat
+		}
+		if set["name"] {
+			i.Names = strings.Split(*names, ",")
+			if err := (Selector{Names: i.Names}).validate(); err != nil {
+				return reportError(stderr, cf.format(), &ConfigError{Err: err})
+			}
+		}
+		sink, err := NewSink(*out, stdout)
+		if err != nil {
+			return reportError(stderr, cf.format(), err)
+		}
+		w := newReportWriter(sink, *out, *format, len(namespaces) > 1)
+		// Reports are discarded when the command fails before closing them.
+		defer w.abort()
+		code := ExitOK
+		for _, ns := range namespaces {
+			if *format == "jsonl" {
+				i.PageHandler = w.pageHandler(ns)
+			}
+			report, collectErr := collectReport(i, ns, *owner)
+			var partial *PartialCollectionError
+			if collectErr != nil && !errors.As(collectErr, &partial) {
+				return reportError(stderr, cf.format(), collectErr)
+			}
+			if err := w.write(ns, report); err != nil {
+				return reportError(stderr, cf.format(), err)
+			}
+			if collectErr != nil {
+				code = reportError(stderr, cf.format(), collectErr)
+			}
+		}
+		if err := w.close(); err != nil {
+			return reportError(stderr, cf.format(), err)
+		}
+		return code
+	}
+}
+
+// collectReport collects a report from the namespace, limited
+// to the owner graph when owner is given in the kind/name form.
+func collectReport(i *Inspector, namespace, owner string) (Report, error) {
+	if owner == "" {
+		return i.Report(context.Background(), namespace)
+	}
+	root, err := ParseOwnerRoot(namespace, owner)
+	if err != nil {
+		return Report{}, err
+	}
+	return i.OwnerReport(context.Background(), namespace, root)
+}
+
+// setupProfiles sets up the profiles command.
+func setupProfiles(fs *flag.FlagSet, stdout, stderr io.Writer) func([]string) int {
+	return func(args []string) int {
+		if len(args) == 0 {
+			tw := tabwriter.NewWriter(stdout, 0, 8, 2, ' ', 0)
+			fmt.Fprintln(tw, "NAME\tDESCRIPTION")
+			for _, name := range BuiltinProfiles() {
+				p, err := LoadProfile(name)
+				if err != nil {
+					return reportError(stderr, "text", err)
+				}
+				fmt.Fprintf(tw, "%s\t%s\n", p.Name, p.Description)
+			}
+			if err := tw.Flush(); err != nil {
+				return reportError(stderr, "text", err)
+			}
+			return ExitOK
+		}
+		p, err := LoadProfile(args[0])
+		if err != nil {
+			return reportError(stderr, "text", err)
+		}
+		b, err := yaml.Marshal(p)
+		if err != nil {
+			return reportError(stderr, "text", err)
+		}
+		fmt.Fprint(stdout, string(b))
+		return ExitOK
+	}
+}
+
+// setFlags returns names of flags set on the command line.
+// Flags set in the config file are defaults and are not included.
+func setFlags(fs *flag.FlagSet) map[string]bool {
+	set := map[string]bool{}
+	fs.Visit(func(f *flag.Flag) {
+		set[f.Name] = true
+	})
+	return set
+}
+
+// setupAnalyze sets up the analyze command.
+func setupAnalyze(fs *flag.FlagSet, stdout, stderr io.Writer) func([]string) int {
+	var cf clusterFlags
+	cf.register(fs)
+	namespace := namespaceFlag(fs, "K8s namespace collected when no report file is given")
+	file := fs.String("f", "", "saved report to analyze, - reads the report from stdin")
+	expiryWindow := fs.Duration("cert-expiry-window", DefaultCertExpiryWindow, "report certificates expiring within the window")
+	failOn := fs.String("fail-on", "", "exit with code 6 when findings of the severity, info, warning or critical, or higher are found")
+	return func(args []string) int {
+		if *failOn != "" {
+			if err := FailOn(nil, *failOn); err != nil {
+				return reportError(stderr, cf.format(), err)
+			}
+		}
+		var (
+			report     Report
+			collectErr error
+		)
+		switch *file {
+		case "":
+			i, err := cf.inspector(stderr)
+			if err != nil {
+				return reportError(stderr, cf.format(), err)
+			}
+			var partial *PartialCollectionError
+			i.CertExpiryWindow = *expiryWindow
+			report, collectErr = i.Report(context.Background(), *namespace)
+			if collectErr != nil && !errors.As(collectErr, &partial) {
+				return reportError(stderr, cf.format(), collectErr)
+			}
+		default:
+			var err error
+			report, err = readReportFile(*file)
+			if err != nil {
+				return reportError(stderr, cf.format(), err)
+			}
+		}
+		analysis := AnalyzeWithOptions(report, AnalyzeOptions{CertExpiryWindow: *expiryWindow})
+		if err := writeJSON(stdout, analysis); err != nil {
+			return reportError(stderr, cf.format(), err)
+		}
+		code := ExitOK
+		if collectErr != nil {
+			code = reportError(stderr, cf.format(), collectErr)
+		}
+		if *failOn != "" {
+			if err := FailOn(analysis.Findings, *failOn); err != nil {
+				return reportError(stderr, cf.format(), err)
+			}
+		}
+		return code
+	}
+}
+
+// setupDiff sets up the diff command.
+func setupDiff(fs *flag.FlagSet, stdout, stderr io.Writer) func([]string) int {
+	format := fs.String("log-format", "text", "log output format: text or json")
+	return func(args []string) int {
+		if len(args) != 2 {
+			return reportError(stderr, *format, &ConfigError{Err: errors.New("diff needs exactly two report files")})
+		}
+		older, err := readReportFile(args[0])
+		if err != nil {
+			return reportError(stderr, *format, err)
+		}
+		newer, err := readReportFile(args[1])
+		if err != nil {
+			return reportError(stderr, *format, err)
+		}
+		d, err := DiffReports(older, newer)
+		if err != nil {
+			return reportError(stderr, *format, err)
+		}
+		if err := writeJSON(stdout, d); err != nil {
+			return reportError(stderr, *format, err)
+		}
+		return ExitOK
+	}
+}
+
+// describeAliases maps kind names accepted by the describe command,
+// in the forms kubectl accepts, to directories of DescribeReport files.
+var describeAliases = map[string]string{
+	"pod": "pods", "pods": "pods", "po": "pods",
+	"deployment": "deployments", "deployments": "deployments", "deploy": "deployments",
+	"service": "services", "services": "services", "svc": "services",
+	"ingress": "ingresses", "ingresses": "ingresses", "ing": "ingresses",
+	"node": "nodes", "nodes": "nodes", "no": "nodes",
+}
+
+// setupDescribe sets up the describe command.
+func setupDescribe(fs *flag.FlagSet, stdout, stderr io.Writer) func([]string) int {
+	var cf clusterFlags
+	cf.register(fs)
+	namespace := namespaceFlag(fs, "K8s namespace collected when no report file is given")
+	file := fs.String("f", "", "saved report to describe, - reads the report from stdin")
+	return func(args []string) int {
+		// Files of each described kind, or of the named objects.
+		want := map[string][]string{}
+		for _, arg := range args {
+			kind, name, _ := strings.Cut(arg, "/")
+			dir, ok := describeAliases[strings.ToLower(kind)]
+			if !ok {
+				return reportError(stderr, cf.format(), &ConfigError{Err: fmt.Errorf("unknown kind %q, want pods, deployments, services, ingresses or nodes", kind)})
+			}
+			if name == "" {
+				want[dir] = nil
+				continue
+			}
+			if names, ok := want[dir]; !ok || names != nil {
+				want[dir] = append(names, name)
+			}
+		}
+		var (
+			report     Report
+			collectErr error
+		)
+		switch *file {
+		case "":
+			i, err := cf.inspector(stderr)
+			if err != nil {
+				return reportError(stderr, cf.format(), err)
+			}
+			var partial *PartialCollectionError
+			report, collectErr = i.Report(context.Background(), *namespace)
+			if collectErr != nil && !errors.As(collectErr, &partial) {
+				return reportError(stderr, cf.format(), collectErr)
+			}
+		default:
+			var err error
+			report, err = readReportFile(*file)
+			if err != nil {
+				return reportError(stderr, cf.format(), err)
+			}
+		}
+		files, err := DescribeReport(report, time.Now())
+		if err != nil {
+			return reportError(stderr, cf.format(), err)
+		}
+		var texts []string
+		for _, dir := range describedDirs {
+			names, ok := want[dir]
+			if len(want) > 0 && !ok {
+				continue
+			}
+			if names == nil {
+				for _, f := range slices.Sorted(maps.Keys(files)) {
+					if strings.HasPrefix(f, dir+"/") {
+						texts = append(texts, files[f])
+					}
+				}
+				continue
+			}
+			for _, name := range names {
+				text, ok := files[dir+"/"+name+".txt"]
+				if !ok {
+					return reportError(stderr, cf.format(), &ConfigError{Err: fmt.Errorf("%s %q not found in the report", dir, name)})
+				}
+				texts = append(texts, text)
+			}
+		}
+		if _, err := io.WriteString(stdout, strings.Join(texts, "\n\n")); err != nil {
+			return reportError(stderr, cf.format(), err)
+		}
+		if collectErr != nil {
+			return reportError(stderr, cf.format(), collectErr)
+		}
+		return ExitOK
+	}
+}
+
+// setupPreflight sets up the preflight command.
+func setupPreflight(fs *flag.FlagSet, stdout, stderr io.Writer) func([]string) int {
+	var cf clusterFlags
+	cf.register(fs)
+	namespaces := namespaceFlag(fs, "comma separated K8s namespaces")
+	role := fs.String("role", "inspector", "name of the generated ClusterRole and Roles")
+	return func(args []string) int {
+		i, err := cf.inspector(stderr)
+		if err != nil {
+			return reportError(stderr, cf.format(), err)
+		}
+		p, err := i.Preflight(context.Background(), strings.Split(*namespaces, ","))
+		if err != nil {
+			return reportError(stderr, cf.format(), err)
+		}
+		if err := p.WriteMatrix(stdout); err != nil {
+			return reportError(stderr, cf.format(), err)
+		}
+		manifest, err := p.Manifest(*role)
+		if err != nil {
+			return reportError(stderr, cf.format(), err)
+		}
+		fmt.Fprintf(stdout, "\n%s", manifest)
+		if !p.Passed() {
+			return ExitAuth
+		}
+		return ExitOK
+	}
+}
+
+// setupDeploy sets up the deploy command.
+func setupDeploy(fs *flag.FlagSet, stdout, stderr io.Writer) func([]string) int {
+	var cf clusterFlags
+	cf.register(fs)
+	namespace := namespaceFlag(fs, "K8s namespace inspector runs in")
+	name := fs.String("name", "inspector", "name of the generated objects")
+	image := fs.String("image", "", "inspector container image")
+	profileName := fs.String("profile", "", "built-in profile ("+strings.Join(BuiltinProfiles(), ", ")+") or profile file")
+	collectNamespaces := fs.String("collect-namespaces", "", "comma separated namespaces collected (default the profile namespaces or the namespace inspector runs in)")
+	out := fs.String("out", "", "report destination: s3://bucket/key or an http(s) upload URL, {namespace} is replaced with the namespace")
+	schedule := fs.String("schedule", "", "cron schedule of a CronJob running inspector, a Job runs once when empty")
+	credentials := fs.String("credentials-secret", "", "Secret holding environment variables of inspector, like AWS_ACCESS_KEY_ID and AWS_SECRET_ACCESS_KEY")
+	apply := fs.Bool("apply", false, "create or update the objects in the cluster instead of printing them")
+	return func(args []string) int {
+		opts := DeployOptions{
+			Name:              *name,
+			Namespace:         *namespace,
+			Image:             *image,
+			Schedule:          *schedule,
+			CredentialsSecret: *credentials,
+		}
+		if *profileName != "" {
+			p, err := LoadProfile(*profileName)
+			if err != nil {
+				return reportError(stderr, cf.format(), err)
+			}
+			opts.Profile = p
+		}
+		if *collectNamespaces != "" {
+			opts.Profile.Namespaces = strings.Split(*collectNamespaces, ",")
+		}
+		if *out != "" {
+			opts.Profile.Output.Destination = *out
+		}
+		objects, err := DeployObjects(opts)
+		if err != nil {
+			return reportError(stderr, cf.format(), err)
+		}
+		if !*apply {
+			manifest, err := DeployManifest(objects)
+			if err != nil {
+				return reportError(stderr, cf.format(), err)
+			}
+			fmt.Fprint(stdout, manifest)
+			return ExitOK
+		}
+		i, err := cf.inspector(stderr)
+		if err != nil {
+			return reportError(stderr, cf.format(), err)
+		}
+		applied, err := i.Deploy(context.Background(), objects)
+		for _, ref := range applied {
+			fmt.Fprintf(stdout, "%s applied\n", ref)
+		}
+		if err != nil {
+			return reportError(stderr, cf.format(), err)
+		}
+		return ExitOK
+	}
+}
+
+// setupServe sets up the serve command.
+func setupServe(fs *flag.FlagSet, stdout, stderr io.Writer) func([]string) int {
+	var cf clusterFlags
+	cf.register(fs)
+	namespace := namespaceFlag(fs, "K8s namespace, comma separated namespaces are collected in the background")
+	profileName := fs.String("profile", "", "built-in profile ("+strings.Join(BuiltinProfiles(), ", ")+") or profile file")
+	addr := fs.String("addr", ":8080", "address the HTTP server listens on")
+	interval := fs.Duration("interval", DefaultRefreshInterval, "interval of background collections, 0 collects reports only on request")
+	anyNamespace := fs.Bool("any-namespace", false, "allow requests to collect namespaces other than the -n namespaces")
+	expiryWindow := fs.Duration("cert-expiry-window", DefaultCertExpiryWindow, "report certificates expiring within the window")
+	return func(args []string) int {
+		var profile Profile
+		if *profileName != "" {
+			p, err := LoadProfile(*profileName)
+			if err != nil {
+				return reportError(stderr, cf.format(), err)
+			}
+			profile = p
+		}
+		set := setFlags(fs)
+		namespaces := strings.Split(*namespace, ",")
+		if !set["n"] && !set["namespace"] && len(profile.Namespaces) > 0 {
+			namespaces = profile.Namespaces
+		}
+		if !set["log-level"] && profile.Log.Level != "" {
+			cf.logLevel = profile.Log.Level
+		}
+		if !set["log-format"] && profile.Log.Format != "" {
+			cf.logFormat = profile.Log.Format
+		}
+		i, err := cf.inspector(stderr)
+		if err != nil {
+			return reportError(stderr, cf.format(), err)
+		}
+		profile.Apply(i)
+		i.CertExpiryWindow = *expiryWindow
+		if *profileName != "" {
+			i.Options["profile"] = *profileName
+		}
+		ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+		defer stop()
+		s := &Server{Inspector: i, Namespaces: namespaces, AnyNamespace: *anyNamespace, Interval: *interval}
+		if err := s.Run(ctx, *addr); err != nil {
+			return reportError(stderr, cf.format(), err)
+		}
+		return ExitOK
+	}
+}
+
+// setupWatch sets up the watch command.
+func setupWatch(fs *flag.FlagSet, stdout, stderr io.Writer) func([]string) int {
+	var cf clusterFlags
+	cf.register(fs)
+	namespace := namespaceFlag(fs, "K8s namespace")
+	profileName := fs.String("profile", "", "built-in profile ("+strings.Join(BuiltinProfiles(), ", ")+") or profile file")
+	format := fs.String("o", "jsonl", "finding changes format: jsonl or text")
+	out := fs.String("out", "", "destination of the report rewritten on each change: a file, s3://bucket/key or an http(s) upload URL, {namespace} is replaced with the namespace")
+	reportFormat := fs.String("report-format", "json", "format of the report written to -out: json or yaml")
+	resync := fs.Duration("resync", DefaultWatchResync, "interval at which objects are resynced and analyzers re-run")
+	debounce := fs.Duration("debounce", DefaultWatchDebounce, "quiet period after a change before analyzers re-run")
+	expiryWindow := fs.Duration("cert-expiry-window", DefaultCertExpiryWindow, "report certificates expiring within the window")
+	return func(args []string) int {
+		if *format != "jsonl" && *format != "text" {
+			return reportError(stderr, cf.format(), &ConfigError{Err: fmt.Errorf("unknown finding changes format %q", *format)})
+		}
+		if *reportFormat != "json" && *reportFormat != "yaml" {
+			return reportError(stderr, cf.format(), &ConfigError{Err: fmt.Errorf("unknown report format %q", *reportFormat)})
+		}
+		var profile Profile
+		if *profileName != "" {
+			p, err := LoadProfile(*profileName)
+			if err != nil {
+				return reportError(stderr, cf.format(), err)
+			}
+			profile = p
+		}
+		set := setFlags(fs)
+		ns := *namespace
+		if !set["n"] && !set["namespace"] && len(profile.Namespaces) > 0 {
+			ns = profile.Namespaces[0]
+		}
+		if !set["log-level"] && profile.Log.Level != "" {
+			cf.logLevel = profile.Log.Level
+		}
+		if !set["log-format"] && profile.Log.Format != "" {
+			cf.logFormat = profile.Log.Format
+		}
+		i, err := cf.inspector(stderr)
+		if err != nil {
+			return reportError(stderr, cf.format(), err)
+		}
+		profile.Apply(i)
+		i.CertExpiryWindow = *expiryWindow
+		w := &Watcher{Inspector: i, Namespace: ns, Resync: *resync, Debounce: *debounce}
+		if *out != "" {
+			sink, err := NewSink(*out, stdout)
+			if err != nil {
+				return reportError(stderr, cf.format(), err)
+			}
+			w.OnUpdate = func(rep Report) error {
+				rw := newReportWriter(sink, *out, *reportFormat, false)
+				if err := rw.write(ns, rep); err != nil {
+					rw.abort()
+					return err
+				}
+				return rw.close()
+			}
+		}
+		ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+		defer stop()
+		err = w.Watch(ctx, func(change FindingChange) error {
+			if *format == "text" {
+				f := change.Finding
+				_, err := fmt.Fprintf(stdout, "%s %s %s %s %s: %s\n", change.Time.Format(time.RFC3339), change.Status, f.Severity, f.Check, f.Object, f.Message)
+				return err
+			}
+			b, err := json.Marshal(change)
+			if err != nil {
+				return err
+			}
+			_, err = stdout.Write(append(b, '\n'))
+			return err
+		})
+		if err != nil {
+			return reportError(stderr, cf.format(), err)
+		}
+		return ExitOK
+	}
+}
+
+// setupCapture sets up the capture command.
+func setupCapture(fs *flag.FlagSet, stdout, stderr io.Writer) func([]string) int {
+	var cf clusterFlags
+	cf.register(fs)
+	namespace := namespaceFlag(fs, "K8s namespace")
+	profileName := fs.String("profile", "", "built-in profile ("+strings.Join(BuiltinProfiles(), ", ")+") or profile file choosing what reports of incidents collect")
+	triggers := fs.String("triggers", strings.Join(Triggers, ","), "comma separated triggers capturing bundles: "+strings.Join(Triggers, ", "))
+	controllerSelector := fs.String("controller-selector", "", "label selector of Ingress Controller pods (default pods running an Ingress Controller image)")
+	cooldown := fs.Duration("cooldown", DefaultCaptureCooldown, "period in which a trigger firing again for the same pod captures no new bundle")
+	logLines := fs.Int64("log-lines", DefaultCaptureLogLines, "number of log lines captured from each container")
+	out := fs.String("out", "incident-{namespace}-{pod}-{trigger}-{time}.tar.gz", "bundle destination: a file, s3://bucket/key or an http(s) upload URL, {namespace}, {pod}, {trigger} and {time} are replaced")
+	expiryWindow := fs.Duration("cert-expiry-window", DefaultCertExpiryWindow, "report certificates expiring within the window")
+	return func(args []string) int {
+		if *out == "" || *out == "-" {
+			return reportError(stderr, cf.format(), &ConfigError{Err: errors.New("capture needs a bundle destination, not stdout")})
+		}
+		var profile Profile
+		if *profileName != "" {
+			p, err := LoadProfile(*profileName)
+			if err != nil {
+				return reportError(stderr, cf.format(), err)
+			}
+			profile = p
+		}
+		set := setFlags(fs)
+		ns := *namespace
+		if !set["n"] && !set["namespace"] && len(profile.Namespaces) > 0 {
+			ns = profile.Namespaces[0]
+		}
+		if !set["log-level"] && profile.Log.Level != "" {
+			cf.logLevel = profile.Log.Level
+		}
+		if !set["log-format"] && profile.Log.Format != "" {
+			cf.logFormat = profile.Log.Format
+		}
+		sink, err := NewSink(*out, stdout)
+		if err != nil {
+			return reportError(stderr, cf.format(), err)
+		}
+		i, err := cf.inspector(stderr)
+		if err != nil {
+			return reportError(stderr, cf.format(), err)
+		}
+		profile.Apply(i)
+		i.CertExpiryWindow = *expiryWindow
+		c := &Capturer{
+			Inspector:          i,
+			Namespace:          ns,
+			Triggers:           strings.Split(*triggers, ","),
+			ControllerSelector: *controllerSelector,
+			Cooldown:           *cooldown,
+			LogLines:           *logLines,
+		}
+		ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+		defer stop()
+		err = c.Run(ctx, func(b Bundle) error {
+			destination := strings.NewReplacer(
+				"{namespace}", b.Incident.Pod.Namespace,
+				"{pod}", b.Incident.Pod.Name,
+				"{trigger}", b.Incident.Trigger,
+				"{time}", b.Incident.Time.UTC().Format("20060102T150405Z"),
+			).Replace(*out)
+			if err := storeBundle(ctx, sink, destination, b); err != nil {
+				// Keep capturing, storage may be back for the next incident.
+				i.logger().Error("storing bundle failed", "destination", destination, "kind", ErrorKind(err), "error", err.Error())
+				return nil
+			}
+			_, err := fmt.Fprintf(stdout, "%s %s captured to %s\n", b.Incident.Trigger, b.Incident.Pod, destination)
+			return err
+		})
+		if err != nil {
+			return reportError(stderr, cf.format(), err)
+		}
+		return ExitOK
+	}
+}
+
+// storeBundle writes the bundle archive to the destination of the sink.
+func storeBundle(ctx context.Context, sink Sink, destination string, b Bundle) error {
+	w, err := sink.Create(ctx, destination)
+	if err != nil {
+		return err
+	}
+	if err := b.WriteArchive(w); err != nil {
+		abortWriter(w)
+		return err
+	}
+	return w.Close()
+}
+
+// setupVersion sets up the version command.
+func setupVersion(fs *flag.FlagSet, stdout, stderr io.Writer) func([]string) int {
+	return func(args []string) int {
+		v := BuildVersion()
+		fmt.Fprintf(stdout, "inspector %s (commit %s, %s)\n", v.Version, v.Commit, v.GoVersion)
+		return ExitOK
+	}
+}
+
+// setupSchema sets up the schema command.
+func setupSchema(fs *flag.FlagSet, stdout, stderr io.Writer) func([]string) int {
+	return func(args []string) int {
+		b, err := ReportSchema()
+		if err != nil {
+			return reportError(stderr, "text", err)
+		}
+		fmt.Fprintln(stdout, string(b))
+		return ExitOK
+	}
+}
+
+// setupCompletion sets up the completion command.
+func setupCompletion(fs *flag.FlagSet, stdout, stderr io.Writer) func([]string) int {
+	name := fs.String("name", "inspector", "name of the program the completion is registered for")
+	return func(args []string) int {
+		if len(args) != 1 {
+			return reportError(stderr, "text", &ConfigError{Err: errors.New("completion needs a shell name: bash, zsh, fish or kubectl")})
+		}
+		script, err := completionScript(args[0], *name)
+		if err != nil {
+			return reportError(stderr, "text", err)
+		}
+		fmt.Fprint(stdout, script)
+		return ExitOK
+	}
+}
+
+// readReportFile reads a saved report from a file or from stdin for "-".
+func readReportFile(path string) (Report, error) {
+	if path == "-" {
+		return ReadReport(os.Stdin)
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		return Report{}, &ConfigError{Err: err}
+	}
+	defer f.Close()
+	rep, err := ReadReport(f)
+	if err != nil {
+		return Report{}, fmt.Errorf("reading report %s: %w", path, err)
+	}
+	return rep, nil
+}
+
+// writeJSON writes v to w as indented JSON.
+func writeJSON(w io.Writer, v any) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(v)
+}
+
+// validateLogFormat returns a configuration error for unknown log formats.
+func validateLogFormat(format string) error {
+	if format != "text" && format != "json" {
+		return &ConfigError{Err: fmt.Errorf("unknown log format %q", format)}
+	}
+	return nil
+}
+
+// reportError writes err to w in the text or JSON format
+// and returns the exit code for err.
+func reportError(w io.Writer, format string, err error) int {
+	code := ExitCode(err)
+	attrs := []any{"kind", ErrorKind(err), "exit_code", code}
+	var collectorErr *CollectorError
+	if errors.As(err, &collectorErr) {
+		attrs = append(attrs, "collector", collectorErr.Collector, "resource", collectorErr.Resource)
+	}
+	newLogger(w, format, slog.LevelError).Error(err.Error(), attrs...)
+	return code
+}
@@ -0,0 +1,289 @@
+package inspector_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/qba73/inspector"
+
+	autoscalingv2 "k8s.io/api/autoscaling/v2"
+)
+
+func TestRunPrintsVersion(t *testing.T) {
+	t.Parallel()
+
+	var stdout, stderr bytes.Buffer
+	if code := inspector.Run([]string{"version"}, &stdout, &stderr); code != inspector.ExitOK {
+		t.Fatalf("want exit code %d, got %d: %s", inspector.ExitOK, code, stderr.String())
+	}
+	if !strings.HasPrefix(stdout.String(), "inspector ") {
+		t.Errorf("want version line, got %q", stdout.String())
+	}
+}
+
+func TestRunPrintsReportSchema(t *testing.T) {
+	t.Parallel()
+
+	var stdout, stderr bytes.Buffer
+	if code := inspector.Run([]string{"schema"}, &stdout, &stderr); code != inspector.ExitOK {
+		t.Fatalf("want exit code %d, got %d: %s", inspector.ExitOK, code, stderr.String())
+	}
+	if !strings.Contains(stdout.String(), inspector.SchemaID) {
+		t.Errorf("want schema with id %s, got %q", inspector.SchemaID, stdout.String())
+	}
+}
+
+func TestRunReportsUnknownCommand(t *testing.T) {
+	t.Parallel()
+
+	var stdout, stderr bytes.Buffer
+	if code := inspector.Run([]string{"bogus"}, &stdout, &stderr); code != inspector.ExitConfig {
+		t.Errorf("want exit code %d, got %d", inspector.ExitConfig, code)
+	}
+	if !strings.Contains(stderr.String(), `unknown command \"bogus\"`) {
+		t.Errorf("want unknown command error, got %q", stderr.String())
+	}
+}
+
+func TestRunAnalyzeReadsSavedReport(t *testing.T) {
+	t.Parallel()
+
+	path := writeReport(t, inspector.Report{
+		Namespace:                "web",
+		HorizontalPodAutoscalers: &autoscalingv2.HorizontalPodAutoscalerList{Items: []autoscalingv2.HorizontalPodAutoscaler{*hpaAtMax}},
+	})
+	var stdout, stderr bytes.Buffer
+	if code := inspector.Run([]string{"analyze", "-f", path}, &stdout, &stderr); code != inspector.ExitOK {
+		t.Fatalf("want exit code %d, got %d: %s", inspector.ExitOK, code, stderr.String())
+	}
+	var got inspector.Analysis
+	if err := json.Unmarshal(stdout.Bytes(), &got); err != nil {
+		t.Fatal(err)
+	}
+	if len(got.Findings) != 1 || got.Findings[0].Check != inspector.CheckHPAAtMaxReplicas {
+		t.Errorf("want %s finding, got %+v", inspector.CheckHPAAtMaxReplicas, got.Findings)
+	}
+}
+
+func TestRunAnalyzeFailsOnFindingsOfGivenSeverity(t *testing.T) {
+	t.Parallel()
+
+	path := writeReport(t, inspector.Report{
+		Namespace: "default",
+		Secrets: []inspector.SecretInfo{{
+			Namespace:    "default",
+			Name:         "shop-tls",
+			Type:         "kubernetes.io/tls",
+			Certificates: []inspector.CertificateInfo{{Subject: "CN=shop.example.com", NotAfter: time.Now().Add(-time.Hour)}},
+		}},
+	})
+	var stdout, stderr bytes.Buffer
+	if code := inspector.Run([]string{"analyze", "-f", path, "-fail-on", "critical"}, &stdout, &stderr); code != inspector.ExitFindings {
+		t.Fatalf("want exit code %d, got %d: %s", inspector.ExitFindings, code, stderr.String())
+	}
+	if !strings.Contains(stdout.String(), inspector.CheckCertificateExpired) {
+		t.Errorf("want expired certificate finding, got %s", stdout.String())
+	}
+	stdout.Reset()
+	if code := inspector.Run([]string{"analyze", "-f", path}, &stdout, &stderr); code != inspector.ExitOK {
+		t.Errorf("want exit code %d without -fail-on, got %d", inspector.ExitOK, code)
+	}
+}
+
+func TestRunDiffComparesSavedReports(t *testing.T) {
+	t.Parallel()
+
+	older := writeReport(t, inspector.Report{K8sVersion: "v1.29.4"})
+	newer := writeReport(t, inspector.Report{K8sVersion: "v1.30.1"})
+	var stdout, stderr bytes.Buffer
+	if code := inspector.Run([]string{"diff", older, newer}, &stdout, &stderr); code != inspector.ExitOK {
+		t.Fatalf("want exit code %d, got %d: %s", inspector.ExitOK, code, stderr.String())
+	}
+	var got inspector.ReportDiff
+	if err := json.Unmarshal(stdout.Bytes(), &got); err != nil {
+		t.Fatal(err)
+	}
+	want := []inspector.FieldChange{{Field: "k8s_version", Old: "v1.29.4", New: "v1.30.1"}}
+	if !cmp.Equal(want, got.Fields) {
+		t.Error(cmp.Diff(want, got.Fields))
+	}
+}
+
+func TestRunDiffNeedsTwoReports(t *testing.T) {
+	t.Parallel()
+
+	var stdout, stderr bytes.Buffer
+	if code := inspector.Run([]string{"diff", "old.json"}, &stdout, &stderr); code != inspector.ExitConfig {
+		t.Errorf("want exit code %d, got %d", inspector.ExitConfig, code)
+	}
+}
+
+func TestRunCompletesCommandsAndFlags(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name string
+		args []string
+		want string
+	}{
+		{name: "commands", args: []string{"__complete", "pre"}, want: "preflight\n:4\n"},
+		{name: "flags", args: []string{"__complete", "collect", "-log-"}, want: "-log-format\n-log-level\n:4\n"},
+		{name: "default command flags", args: []string{"__complete", "-kube"}, want: "-kubeconfig\n:4\n"},
+		{name: "shells", args: []string{"__complete", "completion", "z"}, want: "zsh\n:4\n"},
+		{name: "kubectl plugin script", args: []string{"__complete", "completion", "k"}, want: "kubectl\n:4\n"},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			var stdout, stderr bytes.Buffer
+			if code := inspector.Run(tc.args, &stdout, &stderr); code != inspector.ExitOK {
+				t.Fatalf("want exit code %d, got %d", inspector.ExitOK, code)
+			}
+			if !cmp.Equal(tc.want, stdout.String()) {
+				t.Error(cmp.Diff(tc.want, stdout.String()))
+			}
+		})
+	}
+}
+
+func TestRunCompletionPrintsShellScripts(t *testing.T) {
+	t.Parallel()
+
+	for _, shell := range []string{"bash", "zsh", "fish", "kubectl"} {
+		var stdout, stderr bytes.Buffer
+		if code := inspector.Run([]string{"completion", "-name", "kubectl-inspector", shell}, &stdout, &stderr); code != inspector.ExitOK {
+			t.Fatalf("%s: want exit code %d, got %d", shell, inspector.ExitOK, code)
+		}
+		if !strings.Contains(stdout.String(), "kubectl-inspector __complete") {
+			t.Errorf("%s: want script calling __complete, got %q", shell, stdout.String())
+		}
+	}
+}
+
+func TestRunAppliesConfigFileDefaults(t *testing.T) {
+	saved := writeReport(t, inspector.Report{
+		HorizontalPodAutoscalers: &autoscalingv2.HorizontalPodAutoscalerList{Items: []autoscalingv2.HorizontalPodAutoscaler{*hpaAtMax}},
+	})
+	config := filepath.Join(t.TempDir(), "config.yaml")
+	if err := os.WriteFile(config, []byte("commands:\n  analyze:\n    f: "+saved+"\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("INSPECTOR_CONFIG", config)
+
+	var stdout, stderr bytes.Buffer
+	if code := inspector.Run([]string{"analyze"}, &stdout, &stderr); code != inspector.ExitOK {
+		t.Fatalf("want exit code %d, got %d: %s", inspector.ExitOK, code, stderr.String())
+	}
+	if !strings.Contains(stdout.String(), inspector.CheckHPAAtMaxReplicas) {
+		t.Errorf("want analysis of report set in config, got %s", stdout.String())
+	}
+
+	empty := writeReport(t, inspector.Report{})
+	stdout.Reset()
+	if code := inspector.Run([]string{"analyze", "-f", empty}, &stdout, &stderr); code != inspector.ExitOK {
+		t.Fatalf("want exit code %d, got %d: %s", inspector.ExitOK, code, stderr.String())
+	}
+	if strings.Contains(stdout.String(), inspector.CheckHPAAtMaxReplicas) {
+		t.Errorf("want flag to override config, got %s", stdout.String())
+	}
+}
+
+func TestRunPrefersProfileSettingsToConfigFileDefaults(t *testing.T) {
+	dir := t.TempDir()
+	config := filepath.Join(dir, "config.yaml")
+	if err := os.WriteFile(config, []byte("defaults:\n  log-format: json\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	profile := filepath.Join(dir, "profile.yaml")
+	if err := os.WriteFile(profile, []byte("name: text-logs\ncollectors: [pods]\nlog:\n  format: text\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("INSPECTOR_CONFIG", config)
+
+	var stdout, stderr bytes.Buffer
+	inspector.Run([]string{"collect", "-profile", profile, "-kubeconfig", filepath.Join(dir, "missing")}, &stdout, &stderr)
+	if stderr.Len() == 0 || json.Valid(stderr.Bytes()) {
+		t.Errorf("want error logged in the profile text format, got %q", stderr.String())
+	}
+
+	stderr.Reset()
+	inspector.Run([]string{"collect", "-profile", profile, "-log-format", "json", "-kubeconfig", filepath.Join(dir, "missing")}, &stdout, &stderr)
+	if !json.Valid(stderr.Bytes()) {
+		t.Errorf("want flag to override profile, got %q", stderr.String())
+	}
+}
+
+func TestRunRejectsUnknownFlagInConfigFile(t *testing.T) {
+	config := filepath.Join(t.TempDir(), "config.yaml")
+	if err := os.WriteFile(config, []byte("commands:\n  diff:\n    namespace: web\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("INSPECTOR_CONFIG", config)
+
+	var stdout, stderr bytes.Buffer
+	if code := inspector.Run([]string{"diff", "a.json", "b.json"}, &stdout, &stderr); code != inspector.ExitConfig {
+		t.Errorf("want exit code %d, got %d", inspector.ExitConfig, code)
+	}
+}
+
+func writeReport(t *testing.T, rep inspector.Report) string {
+	t.Helper()
+	data, err := inspector.ReportJSON(rep)
+	if err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(t.TempDir(), "report.json")
+	if err := os.WriteFile(path, []byte(data), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestRunReportsPartialLiveCollections(t *testing.T) {
+	t.Parallel()
+
+	kubeconfig := forbiddingAPIServer(t)
+	for _, args := range [][]string{
+		{"analyze", "-kubeconfig", kubeconfig, "-retries", "0", "-qps", "1000", "-burst", "1000"},
+		{"describe", "-kubeconfig", kubeconfig, "-retries", "0", "-qps", "1000", "-burst", "1000"},
+	} {
+		var stdout, stderr bytes.Buffer
+		if code := inspector.Run(args, &stdout, &stderr); code != inspector.ExitPartial {
+			t.Errorf("%s: want exit code %d, got %d: %s", args[0], inspector.ExitPartial, code, stderr.String())
+		}
+		if !strings.Contains(stderr.String(), "forbidden") {
+			t.Errorf("%s: want partial collection error logged, got %q", args[0], stderr.String())
+		}
+	}
+}
+
+// forbiddingAPIServer starts an API server answering version requests
+// and forbidding all other calls, and returns the path of its kubeconfig.
+func forbiddingAPIServer(t *testing.T) string {
+	t.Helper()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		if r.URL.Path == "/version" {
+			fmt.Fprint(w, `{"major":"1","minor":"35","gitVersion":"v1.35.0"}`)
+			return
+		}
+		w.WriteHeader(http.StatusForbidden)
+		fmt.Fprintf(w, `{"kind":"Status","apiVersion":"v1","status":"Failure","reason":"Forbidden","code":403,"message":"%s is forbidden"}`, r.URL.Path)
+	}))
+	t.Cleanup(srv.Close)
+	kubeconfig := filepath.Join(t.TempDir(), "kubeconfig")
+	config := fmt.Sprintf("apiVersion: v1\nkind: Config\nclusters:\n- name: test\n  cluster:\n    server: %s\ncontexts:\n- name: test\n  context:\n    cluster: test\n    user: test\nusers:\n- name: test\n  user: {}\ncurrent-context: test\n", srv.URL)
+	if err := os.WriteFile(kubeconfig, []byte(config), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return kubeconfig
+}
@@ -3,16 +3,118 @@ inspector generates K8s cluster diagnostics reports.
 
 Usage:
 
-	inspector [flags]
+	inspector [command] [flags]
 
-The flags are:
+The commands are:
+
+	collect
+	    Collect a diagnostics report from a namespace. It is the default command.
+	analyze
+	    Analyze a namespace or a saved report given with -f. With -fail-on,
+	    it exits with code 6 when findings of the given severity or higher
+	    are found.
+	diff
+	    Compare two saved reports.
+	describe
+	    Print kubectl describe style text of pods, deployments, services,
+	    ingresses and nodes of a namespace, or of a saved report given with
+	    -f, with their events. Arguments like pods, svc/web or node/n1
+	    choose the objects.
+	profiles
+	    List built-in collection profiles or print one of them.
+	preflight
+	    Check permissions needed by collectors.
+	deploy
+	    Generate manifests running inspector in the cluster as a Job, or as
+	    a CronJob with -schedule: a ServiceAccount, RBAC granting access the
+	    profile collectors need, and a ConfigMap holding the profile. Reports
+	    go to the -out destination. With -apply, the objects are created in
+	    the cluster.
+	serve
+	    Run an HTTP server on -addr collecting reports of the -n namespaces
+	    every -interval. GET /report?namespace= collects a report on demand,
+	    /report/latest returns the latest report, /findings its findings
+	    and /metrics Prometheus gauges derived from the findings. Other
+	    namespaces than the -n ones are served only with -any-namespace.
+	watch
+	    Watch a namespace with informers, re-run analyzers when objects
+	    change and print findings as they appear or resolve, as JSON lines
+	    or, with -o text, as text. With -out, the report of the watched
+	    objects is rewritten on each change.
+	capture
+	    Watch pods and events of a namespace and capture an incident bundle
+	    when a pod container enters CrashLoopBackOff, is OOMKilled, or an
+	    Ingress Controller pod restarts. A bundle is a gzipped tar archive
+	    of a report, current and previous container logs and describe
+	    style text of the pod and of objects of the report, written to the
+	    -out destination.
+	schema
+	    Print the JSON Schema of reports.
+	version
+	    Print the inspector version.
+	completion
+	    Print a shell completion script for bash, zsh or fish, or with
+	    kubectl, the kubectl_complete-inspector script completing
+	    arguments of inspector run as a kubectl plugin.
+
+The flags shared by commands talking to the cluster are:
 
 	-h
 		Show help.
 	-v
-	    Print out to terminal all operations.
-	-n
+	    Print out progress of each collector to stderr.
+	-kubeconfig
+	    Path to the kubeconfig file.
+	-context
+	    Kubeconfig context to use.
+	-in-cluster
+	    Connect with the service account of the pod inspector runs in.
+	    Without a kubeconfig, inspector running in a pod connects this way.
+	-log-level
+	    Log level: debug, info, warn or error.
+	-log-format
+	    Log output format: text or json.
+	-page-size
+	    Number of objects requested per list call.
+	-qps, -burst
+	    Maximum API calls per second and their burst.
+	-timeout
+	    Timeout of each API call, except log reads and watches.
+	-retries
+	    Retries of API calls failing with throttling, server or connection errors.
+	-n, -namespace
 	    Kubernetes namespace. If not provided `default` is used.
+
+The collect command also accepts:
+
+	-profile
+	    Built-in profile name or profile file.
+	-o
+	    Report format: json, yaml or jsonl. The jsonl format streams
+	    objects as they are listed.
+	-out
+	    Report destination: a file, s3://bucket/key or an http(s) upload
+	    URL, like a pre-signed URL. Defaults to stdout.
+	-l
+	    Label selector filtering objects of namespaced collectors.
+	-field-selector
+	    Field selector filtering objects of namespaced collectors.
+	-name
+	    Comma separated name globs filtering objects of namespaced collectors.
+	-trim
+	    Comma separated fields removed from collected objects: managed-fields,
+	    last-applied, status and resource-version, or none.
+	-neat
+	    Write collected objects as clean manifests.
+	-cert-expiry-window
+	    Report certificates expiring within the window. Also accepted by
+	    analyze, serve, watch and capture.
+	-owner
+	    Deployment, StatefulSet, DaemonSet, Service or Ingress, given as kind/name,
+	    collected together with objects it owns or references.
+
+Flag defaults are read from the config file given in INSPECTOR_CONFIG
+or from inspector/config.yaml in the user config directory.
 */
 package main
 
@@ -0,0 +1,93 @@
+package inspector
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+// collection tracks collector runs and failures for a single report.
+type collection struct {
+	ctx    context.Context
+	logger *slog.Logger
+	total  int
+	errors []*CollectorError
+	// selected holds names of collectors to run. All collectors run when nil.
+	selected map[string]bool
+	// names returns object name patterns of a collector.
+	names func(collector string) []string
+	// retries counts retried API calls of each collector.
+	retries map[string]int
+	// durations holds run times of collectors in milliseconds.
+	durations map[string]int64
+}
+
+// collect runs the named collector, logs its progress and records
+// its failure. It returns the zero value of T when the collector fails
+// or is not selected.
+func collect[T any](c *collection, name string, f func(context.Context) (T, error)) T {
+	if c.selected != nil && !c.selected[name] {
+		c.logger.Debug("collector skipped", "collector", name)
+		var zero T
+		return zero
+	}
+	c.total++
+	c.logger.Debug("collector started", "collector", name)
+	start := time.Now()
+	ctx, scope := withRetryScope(c.ctx, name)
+	v, err := f(ctx)
+	if scope.retries > 0 {
+		if c.retries == nil {
+			c.retries = map[string]int{}
+		}
+		c.retries[name] = scope.retries
+	}
+	if err == nil {
+		err = c.filterNames(name, v)
+	}
+	duration := time.Since(start)
+	c.recordDuration(name, duration)
+	if err != nil {
+		collectorErr := newCollectorError(name, err)
+		c.logger.Warn("collector failed", "collector", name, "resource", collectorErr.Resource, "duration", duration, "error", err)
+		c.errors = append(c.errors, collectorErr)
+		var zero T
+		return zero
+	}
+	if c.logger.Enabled(c.ctx, slog.LevelInfo) {
+		c.logger.Info("collector finished", "collector", name, "duration", duration, "objects", objectCount(v), "bytes", jsonSize(v))
+	}
+	return v
+}
+
+// recordDuration records the run time of the collector.
+func (c *collection) recordDuration(name string, d time.Duration) {
+	if c.durations == nil {
+		c.durations = map[string]int64{}
+	}
+	c.durations[name] = d.Milliseconds()
+}
+
+// filterNames removes objects not matching name patterns
+// of the collector from collected K8s lists.
+func (c *collection) filterNames(collector string, v any) error {
+	if c.names == nil {
+		return nil
+	}
+	list, ok := v.(runtime.Object)
+	if !ok || !meta.IsListType(list) {
+		return nil
+	}
+	return filterNames(list, c.names(collector))
+}
+
+// err returns a partial collection error if any collector failed.
+func (c *collection) err() error {
+	if len(c.errors) == 0 {
+		return nil
+	}
+	return &PartialCollectionError{Total: c.total, Errors: c.errors}
+}
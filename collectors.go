@@ -0,0 +1,68 @@
+package inspector
+
+// Collector describes a data collector and the API access it needs.
+// Collector names match the report fields the collected data is stored in.
+type Collector struct {
+	Name   string           `json:"name"`
+	Access []ResourceAccess `json:"access"`
+}
+
+// list returns access needed to list a resource.
+func list(group, resource string, namespaced bool) []ResourceAccess {
+	return []ResourceAccess{{Group: group, Resource: resource, Verb: "list", Namespaced: namespaced}}
+}
+
+// Collectors lists all registered collectors in the order they run.
+var Collectors = []Collector{
+	{Name: "k8s_version"},
+	{Name: "cluster_id", Access: []ResourceAccess{{Resource: "namespaces", Verb: "get"}}},
+	{Name: "nodes", Access: list("", "nodes", false)},
+	{Name: "platform", Access: list("", "nodes", false)},
+	{Name: "pods", Access: list("", "pods", true)},
+	{Name: "pod_logs", Access: append(list("", "pods", true), ResourceAccess{Resource: "pods/log", Verb: "get", Namespaced: true})},
+	{Name: "events", Access: list("", "events", true)},
+	{Name: "events_v1", Access: list("events.k8s.io", "events", true)},
+	{Name: "config_maps", Access: list("", "configmaps", true)},
+	{Name: "secrets", Access: list("", "secrets", true)},
+	{Name: "services", Access: list("", "services", true)},
+	{Name: "deployments", Access: list("apps", "deployments", true)},
+	{Name: "stateful_sets", Access: list("apps", "statefulsets", true)},
+	{Name: "replica_sets", Access: list("apps", "replicasets", true)},
+	{Name: "controller_revisions", Access: list("apps", "controllerrevisions", true)},
+	{Name: "daemon_sets", Access: list("apps", "daemonsets", true)},
+	{Name: "jobs", Access: list("batch", "jobs", true)},
+	{Name: "cron_jobs", Access: list("batch", "cronjobs", true)},
+	{Name: "leases", Access: list("coordination.k8s.io", "leases", true)},
+	{Name: "ingress_classes", Access: list("networking.k8s.io", "ingressclasses", false)},
+	{Name: "ingresses", Access: list("networking.k8s.io", "ingresses", true)},
+	{Name: "endpoint_slices", Access: list("discovery.k8s.io", "endpointslices", true)},
+	{Name: "endpoints", Access: list("", "endpoints", true)},
+	{Name: "network_policies", Access: list("networking.k8s.io", "networkpolicies", true)},
+	{Name: "persistent_volume_claims", Access: list("", "persistentvolumeclaims", true)},
+	{Name: "persistent_volumes", Access: list("", "persistentvolumes", false)},
+	{Name: "storage_classes", Access: list("storage.k8s.io", "storageclasses", false)},
+	{Name: "volume_attachments", Access: list("storage.k8s.io", "volumeattachments", false)},
+	{Name: "csi_drivers", Access: list("storage.k8s.io", "csidrivers", false)},
+	{Name: "horizontal_pod_autoscalers", Access: list("autoscaling", "horizontalpodautoscalers", true)},
+	{Name: "pod_disruption_budgets", Access: list("policy", "poddisruptionbudgets", true)},
+	{Name: "resource_quotas", Access: list("", "resourcequotas", true)},
+	{Name: "limit_ranges", Access: list("", "limitranges", true)},
+	{Name: "service_accounts", Access: list("", "serviceaccounts", true)},
+	{Name: "roles", Access: list("rbac.authorization.k8s.io", "roles", true)},
+	{Name: "role_bindings", Access: list("rbac.authorization.k8s.io", "rolebindings", true)},
+	{Name: "cluster_role_bindings", Access: list("rbac.authorization.k8s.io", "clusterrolebindings", false)},
+	{Name: "cluster_roles", Access: list("rbac.authorization.k8s.io", "clusterroles", false)},
+	{Name: "access_checks", Access: []ResourceAccess{{Group: "authorization.k8s.io", Resource: "subjectaccessreviews", Verb: "create"}}},
+	{Name: "crds", Access: list("apiextensions.k8s.io", "customresourcedefinitions", false)},
+	{Name: "cluster_nodes", Access: list("", "nodes", false)},
+}
+
+// collectorResource returns the API resource a named collector reads.
+func collectorResource(name string) string {
+	for _, c := range Collectors {
+		if c.Name == name && len(c.Access) > 0 {
+			return qualifiedResource(c.Access[len(c.Access)-1])
+		}
+	}
+	return name
+}
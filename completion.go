@@ -0,0 +1,203 @@
+package inspector
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// completeCommand is the hidden command shells call to complete
+// arguments. It follows the protocol of Cobra's __complete command,
+// so kubectl completes plugin arguments via the kubectl_complete-inspector
+// script printed by 'inspector completion kubectl'.
+const completeCommand = "__complete"
+
+// completeNoFiles is the Cobra directive telling shells
+// not to fall back to file name completion.
+const completeNoFiles = ":4"
+
+// completionTimeout bounds cluster calls made while completing.
+const completionTimeout = 5 * time.Second
+
+// complete prints candidates for the last argument, one per line,
+// followed by the Cobra completion directive.
+func complete(args []string, w io.Writer) int {
+	if len(args) == 0 {
+		args = []string{""}
+	}
+	words, toComplete := args[:len(args)-1], args[len(args)-1]
+	for _, c := range completions(words, toComplete) {
+		if strings.HasPrefix(c, toComplete) {
+			fmt.Fprintln(w, c)
+		}
+	}
+	fmt.Fprintln(w, completeNoFiles)
+	return ExitOK
+}
+
+// completions returns candidates for toComplete following words:
+// command names, command flags, namespaces and kubeconfig contexts.
+func completions(words []string, toComplete string) []string {
+	if len(words) == 0 && !strings.HasPrefix(toComplete, "-") {
+		var names []string
+		for _, c := range commandList() {
+			names = append(names, c.name)
+		}
+		return names
+	}
+	name := "collect"
+	if len(words) > 0 && !strings.HasPrefix(words[0], "-") {
+		name, words = words[0], words[1:]
+	}
+	cmd, ok := findCommand(name)
+	if !ok {
+		return nil
+	}
+	fs := flag.NewFlagSet(name, flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+	fs.Bool("h", false, "show help")
+	cmd.setup(fs, io.Discard, io.Discard)
+
+	if len(words) > 0 {
+		prev := strings.TrimLeft(words[len(words)-1], "-")
+		switch prev {
+		case "n", "namespace":
+			return namespaceCompletions(flagValue(words, "kubeconfig"), flagValue(words, "context"), toComplete)
+		case "context":
+			return contextCompletions(flagValue(words, "kubeconfig"))
+		case "profile":
+			return BuiltinProfiles()
+		}
+	}
+	if !strings.HasPrefix(toComplete, "-") {
+		switch name {
+		case "completion":
+			return []string{"bash", "zsh", "fish", "kubectl"}
+		case "profiles":
+			return BuiltinProfiles()
+		}
+		return nil
+	}
+	dash := "-"
+	if strings.HasPrefix(toComplete, "--") {
+		dash = "--"
+	}
+	var flags []string
+	fs.VisitAll(func(f *flag.Flag) {
+		flags = append(flags, dash+f.Name)
+	})
+	return flags
+}
+
+// flagValue returns the value following the named flag in words.
+func flagValue(words []string, name string) string {
+	for i, w := range words {
+		flagName, value, hasValue := strings.Cut(strings.TrimLeft(w, "-"), "=")
+		if !strings.HasPrefix(w, "-") || flagName != name {
+			continue
+		}
+		if hasValue {
+			return value
+		}
+		if i+1 < len(words) {
+			return words[i+1]
+		}
+	}
+	return ""
+}
+
+// namespaceCompletions returns namespaces listed from the cluster.
+// Candidates extend the last item of a comma separated list.
+func namespaceCompletions(kubeconfig, kubeContext, toComplete string) []string {
+	i, err := BuildInspector(kubeconfig, kubeContext)
+	if err != nil {
+		return nil
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), completionTimeout)
+	defer cancel()
+	nsList, err := i.K8sClient.CoreV1().Namespaces().List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return nil
+	}
+	prefix := ""
+	if n := strings.LastIndex(toComplete, ","); n >= 0 {
+		prefix = toComplete[:n+1]
+	}
+	var names []string
+	for _, ns := range nsList.Items {
+		names = append(names, prefix+ns.Name)
+	}
+	return names
+}
+
+// contextCompletions returns context names defined in the kubeconfig.
+func contextCompletions(kubeconfig string) []string {
+	config, err := kubeClientConfig(kubeconfig, "").RawConfig()
+	if err != nil {
+		return nil
+	}
+	var names []string
+	for name := range config.Contexts {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+	return names
+}
+
+// completionScript returns the completion script for the shell
+// registering completion of the named program. For kubectl, it returns
+// the kubectl_complete-<plugin> script kubectl runs to complete
+// arguments of the named plugin program.
+func completionScript(shell, program string) (string, error) {
+	fn := "_" + strings.NewReplacer("-", "_", ".", "_").Replace(program)
+	switch shell {
+	case "bash":
+		return fmt.Sprintf(bashCompletion, program, fn), nil
+	case "zsh":
+		return fmt.Sprintf(zshCompletion, program, fn), nil
+	case "fish":
+		return fmt.Sprintf(fishCompletion, program, fn), nil
+	case "kubectl":
+		return fmt.Sprintf(kubectlCompletion, strings.TrimPrefix(program, "kubectl-"), program), nil
+	default:
+		return "", &ConfigError{Err: fmt.Errorf("unsupported shell %q", shell)}
+	}
+}
+
+const bashCompletion = `# bash completion for %[1]s
+%[2]s() {
+	local cur="${COMP_WORDS[COMP_CWORD]}"
+	local words=("${COMP_WORDS[@]:1:COMP_CWORD-1}")
+	COMPREPLY=($(compgen -W "$(%[1]s __complete "${words[@]}" "$cur" 2>/dev/null | grep -v '^:')" -- "$cur"))
+}
+complete -F %[2]s %[1]s
+`
+
+const zshCompletion = `#compdef %[1]s
+%[2]s() {
+	local -a candidates
+	candidates=("${(@f)$(%[1]s __complete "${(@)words[2,CURRENT]}" 2>/dev/null | grep -v '^:')}")
+	compadd -a candidates
+}
+compdef %[2]s %[1]s
+`
+
+const fishCompletion = `# fish completion for %[1]s
+function %[2]s
+	set -l args (commandline -opc)[2..-1] (commandline -ct)
+	%[1]s __complete $args 2>/dev/null | string match -v -r '^:'
+end
+complete -c %[1]s -f -a '(%[2]s)'
+`
+
+const kubectlCompletion = `#!/bin/sh
+# kubectl_complete-%[1]s completes arguments of 'kubectl %[1]s'.
+# Save it as an executable file named kubectl_complete-%[1]s on the PATH.
+exec %[2]s __complete "$@"
+`
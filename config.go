@@ -0,0 +1,100 @@
+package inspector
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+
+	"sigs.k8s.io/yaml"
+)
+
+// Config holds CLI flag defaults read from the config file.
+//
+// Example:
+//
+//	defaults:
+//	  context: staging
+//	  log-format: json
+//	commands:
+//	  collect:
+//	    namespace: nginx-ingress
+type Config struct {
+	// Defaults maps flag names to values used by every command
+	// defining the flag.
+	Defaults map[string]string `json:"defaults,omitempty"`
+	// Commands maps command names to their flag defaults.
+	Commands map[string]map[string]string `json:"commands,omitempty"`
+}
+
+// DefaultConfigPath returns the path of the config file given in
+// INSPECTOR_CONFIG or inspector/config.yaml in the user config directory.
+// It returns an empty path when neither is available.
+func DefaultConfigPath() string {
+	if path := os.Getenv("INSPECTOR_CONFIG"); path != "" {
+		return path
+	}
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(dir, "inspector", "config.yaml")
+}
+
+// LoadConfig reads the YAML config file at path.
+// A missing file or an empty path yields an empty config.
+func LoadConfig(path string) (Config, error) {
+	var cfg Config
+	if path == "" {
+		return cfg, nil
+	}
+	b, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return cfg, nil
+	}
+	if err != nil {
+		return cfg, &ConfigError{Err: err}
+	}
+	if err := yaml.UnmarshalStrict(b, &cfg); err != nil {
+		return cfg, &ConfigError{Err: fmt.Errorf("config file %s: %w", path, err)}
+	}
+	return cfg, nil
+}
+
+// apply sets flag defaults of the command. Global defaults of flags
+// the command does not define are skipped, unknown command flags
+// are reported as errors. Flags keep counting as unset, so profile
+// settings take precedence over config file defaults.
+func (c Config) apply(command string, fs *flag.FlagSet) error {
+	for name, value := range c.Defaults {
+		f := fs.Lookup(name)
+		if f == nil {
+			continue
+		}
+		if err := setDefault(f, value); err != nil {
+			return &ConfigError{Err: fmt.Errorf("config default %s: %w", name, err)}
+		}
+	}
+	for name, value := range c.Commands[command] {
+		f := fs.Lookup(name)
+		if f == nil {
+			return &ConfigError{Err: fmt.Errorf("config: command %s has no flag %s", command, name)}
+		}
+		if err := setDefault(f, value); err != nil {
+			return &ConfigError{Err: fmt.Errorf("config %s %s: %w", command, name, err)}
+		}
+	}
+	return nil
+}
+
+// setDefault sets the flag value and default without marking
+// the flag as set, unlike flag.FlagSet.Set.
+func setDefault(f *flag.Flag, value string) error {
+	if err := f.Value.Set(value); err != nil {
+		return err
+	}
+	f.DefValue = value
+	return nil
+}
@@ -0,0 +1,289 @@
+package inspector
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"path"
+	"slices"
+	"strings"
+
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/yaml"
+)
+
+// profileMountPath is the path the profile ConfigMap is mounted at
+// in inspector pods.
+const profileMountPath = "/etc/inspector"
+
+// DeployOptions configures objects running inspector in the cluster.
+type DeployOptions struct {
+	// Name names the generated objects, inspector when empty.
+	Name string
+	// Namespace is the namespace inspector runs in.
+	Namespace string
+	// Image is the inspector container image.
+	Image string
+	// Profile selects collectors, collected namespaces and the report
+	// destination. Namespaces default to the namespace inspector runs in.
+	Profile Profile
+	// Schedule runs inspector as a CronJob with the cron schedule.
+	// Inspector runs once as a Job when empty.
+	Schedule string
+	// CredentialsSecret names a Secret holding environment variables
+	// of the inspector container, like AWS_ACCESS_KEY_ID.
+	CredentialsSecret string
+}
+
+// DeployObjects returns objects running inspector in the cluster:
+// a ServiceAccount, a ClusterRole and Roles in collected namespaces
+// granting only access the profile collectors need, their bindings,
+// a ConfigMap holding the profile, and a Job or a CronJob. Reports
+// are written to the profile output destination, which must be set,
+// as the output of pods is not kept. Roles and bindings are named
+// <name>-<namespace> after the namespace inspector runs in, so
+// deployments to several namespaces do not rebind each other.
+func DeployObjects(opts DeployOptions) ([]runtime.Object, error) {
+	if opts.Name == "" {
+		opts.Name = "inspector"
+	}
+	if opts.Namespace == "" {
+		opts.Namespace = "default"
+	}
+	switch {
+	case opts.Image == "":
+		return nil, &ConfigError{Err: errors.New("deploy needs an inspector image")}
+	case opts.Profile.Output.Destination == "" || opts.Profile.Output.Destination == "-":
+		return nil, &ConfigError{Err: errors.New("deploy needs a report destination, like s3://bucket/key or an upload URL")}
+	case !strings.Contains(opts.Profile.Output.Destination, "://"):
+		return nil, &ConfigError{Err: fmt.Errorf("report destination %s is a file in the inspector pod, use s3://bucket/key or an upload URL", opts.Profile.Output.Destination)}
+	}
+	profile := opts.Profile
+	if profile.Name == "" {
+		profile.Name = opts.Name
+	}
+	if len(profile.Namespaces) == 0 {
+		profile.Namespaces = []string{opts.Namespace}
+	}
+	if err := profile.validate(); err != nil {
+		return nil, &ConfigError{Err: err}
+	}
+	profileYAML, err := yaml.Marshal(profile)
+	if err != nil {
+		return nil, err
+	}
+
+	labels := map[string]string{"app.kubernetes.io/name": opts.Name}
+	objectMeta := func(namespace string) metav1.ObjectMeta {
+		return metav1.ObjectMeta{Name: opts.Name, Namespace: namespace, Labels: labels}
+	}
+	rbacName := opts.Name + "-" + opts.Namespace
+	rbacMeta := func(namespace string) metav1.ObjectMeta {
+		return metav1.ObjectMeta{Name: rbacName, Namespace: namespace, Labels: labels}
+	}
+	subjects := []rbacv1.Subject{{Kind: rbacv1.ServiceAccountKind, Name: opts.Name, Namespace: opts.Namespace}}
+	clusterAccess, namespacedAccess := collectorAccess(profile.Collectors)
+
+	objects := []runtime.Object{
+		&corev1.ServiceAccount{
+			TypeMeta:   metav1.TypeMeta{APIVersion: "v1", Kind: "ServiceAccount"},
+			ObjectMeta: objectMeta(opts.Namespace),
+		},
+		&rbacv1.ClusterRole{
+			TypeMeta:   metav1.TypeMeta{APIVersion: "rbac.authorization.k8s.io/v1", Kind: "ClusterRole"},
+			ObjectMeta: rbacMeta(""),
+			Rules:      policyRules(clusterAccess),
+		},
+		&rbacv1.ClusterRoleBinding{
+			TypeMeta:   metav1.TypeMeta{APIVersion: "rbac.authorization.k8s.io/v1", Kind: "ClusterRoleBinding"},
+			ObjectMeta: rbacMeta(""),
+			RoleRef:    rbacv1.RoleRef{APIGroup: rbacv1.GroupName, Kind: "ClusterRole", Name: rbacName},
+			Subjects:   subjects,
+		},
+	}
+	for _, ns := range profile.Namespaces {
+		objects = append(objects,
+			&rbacv1.Role{
+				TypeMeta:   metav1.TypeMeta{APIVersion: "rbac.authorization.k8s.io/v1", Kind: "Role"},
+				ObjectMeta: rbacMeta(ns),
+				Rules:      policyRules(namespacedAccess),
+			},
+			&rbacv1.RoleBinding{
+				TypeMeta:   metav1.TypeMeta{APIVersion: "rbac.authorization.k8s.io/v1", Kind: "RoleBinding"},
+				ObjectMeta: rbacMeta(ns),
+				RoleRef:    rbacv1.RoleRef{APIGroup: rbacv1.GroupName, Kind: "Role", Name: rbacName},
+				Subjects:   subjects,
+			},
+		)
+	}
+	objects = append(objects, &corev1.ConfigMap{
+		TypeMeta:   metav1.TypeMeta{APIVersion: "v1", Kind: "ConfigMap"},
+		ObjectMeta: objectMeta(opts.Namespace),
+		Data:       map[string]string{"profile.yaml": string(profileYAML)},
+	})
+
+	template := podTemplate(opts, labels)
+	backoffLimit, ttl := int32(1), int32(24*60*60)
+	job := batchv1.JobSpec{BackoffLimit: &backoffLimit, TTLSecondsAfterFinished: &ttl, Template: template}
+	if opts.Schedule == "" {
+		return append(objects, &batchv1.Job{
+			TypeMeta:   metav1.TypeMeta{APIVersion: "batch/v1", Kind: "Job"},
+			ObjectMeta: objectMeta(opts.Namespace),
+			Spec:       job,
+		}), nil
+	}
+	return append(objects, &batchv1.CronJob{
+		TypeMeta:   metav1.TypeMeta{APIVersion: "batch/v1", Kind: "CronJob"},
+		ObjectMeta: objectMeta(opts.Namespace),
+		Spec: batchv1.CronJobSpec{
+			Schedule:          opts.Schedule,
+			ConcurrencyPolicy: batchv1.ForbidConcurrent,
+			JobTemplate:       batchv1.JobTemplateSpec{ObjectMeta: metav1.ObjectMeta{Labels: labels}, Spec: job},
+		},
+	}), nil
+}
+
+// collectorAccess returns cluster scoped and namespaced access
+// the named collectors need, of all collectors when names is empty.
+func collectorAccess(names []string) (clusterAccess, namespacedAccess []ResourceAccess) {
+	for _, c := range Collectors {
+		if len(names) > 0 && !slices.Contains(names, c.Name) {
+			continue
+		}
+		for _, a := range c.Access {
+			if a.Namespaced {
+				namespacedAccess = append(namespacedAccess, a)
+			} else {
+				clusterAccess = append(clusterAccess, a)
+			}
+		}
+	}
+	return clusterAccess, namespacedAccess
+}
+
+// podTemplate returns the template of pods running the profile collection
+// with the in-cluster configuration.
+func podTemplate(opts DeployOptions, labels map[string]string) corev1.PodTemplateSpec {
+	nonRoot, readOnly, escalation := true, true, false
+	container := corev1.Container{
+		Name:  "inspector",
+		Image: opts.Image,
+		Args:  []string{"collect", "-in-cluster", "-profile", path.Join(profileMountPath, "profile.yaml")},
+		VolumeMounts: []corev1.VolumeMount{
+			{Name: "profile", MountPath: profileMountPath, ReadOnly: true},
+			// Reports are spooled to a temporary file before upload.
+			{Name: "tmp", MountPath: "/tmp"},
+		},
+		SecurityContext: &corev1.SecurityContext{
+			RunAsNonRoot:             &nonRoot,
+			ReadOnlyRootFilesystem:   &readOnly,
+			AllowPrivilegeEscalation: &escalation,
+			Capabilities:             &corev1.Capabilities{Drop: []corev1.Capability{"ALL"}},
+			SeccompProfile:           &corev1.SeccompProfile{Type: corev1.SeccompProfileTypeRuntimeDefault},
+		},
+	}
+	if opts.CredentialsSecret != "" {
+		container.EnvFrom = []corev1.EnvFromSource{{
+			SecretRef: &corev1.SecretEnvSource{LocalObjectReference: corev1.LocalObjectReference{Name: opts.CredentialsSecret}},
+		}}
+	}
+	return corev1.PodTemplateSpec{
+		ObjectMeta: metav1.ObjectMeta{Labels: labels},
+		Spec: corev1.PodSpec{
+			ServiceAccountName: opts.Name,
+			RestartPolicy:      corev1.RestartPolicyNever,
+			Containers:         []corev1.Container{container},
+			Volumes: []corev1.Volume{
+				{Name: "profile", VolumeSource: corev1.VolumeSource{ConfigMap: &corev1.ConfigMapVolumeSource{
+					LocalObjectReference: corev1.LocalObjectReference{Name: opts.Name},
+				}}},
+				{Name: "tmp", VolumeSource: corev1.VolumeSource{EmptyDir: &corev1.EmptyDirVolumeSource{}}},
+			},
+		},
+	}
+}
+
+// DeployManifest encodes the objects as a multi-document YAML manifest.
+func DeployManifest(objects []runtime.Object) (string, error) {
+	docs := make([]string, 0, len(objects))
+	for _, o := range objects {
+		b, err := yaml.Marshal(o)
+		if err != nil {
+			return "", err
+		}
+		docs = append(docs, string(b))
+	}
+	return strings.Join(docs, "---\n"), nil
+}
+
+// Deploy creates the objects in the cluster, updating objects that exist.
+// Jobs are never updated: a Job left from a previous run must be deleted
+// before inspector runs again. It returns the applied objects in the
+// kind/name form.
+func (i *Inspector) Deploy(ctx context.Context, objects []runtime.Object) ([]string, error) {
+	applied := make([]string, 0, len(objects))
+	for _, obj := range objects {
+		ctx, cancel := withCallTimeout(ctx, i.Timeout)
+		var err error
+		switch o := obj.(type) {
+		case *corev1.ServiceAccount:
+			err = createOrUpdate(ctx, i.K8sClient.CoreV1().ServiceAccounts(o.Namespace), o)
+		case *corev1.ConfigMap:
+			err = createOrUpdate(ctx, i.K8sClient.CoreV1().ConfigMaps(o.Namespace), o)
+		case *rbacv1.ClusterRole:
+			err = createOrUpdate(ctx, i.K8sClient.RbacV1().ClusterRoles(), o)
+		case *rbacv1.ClusterRoleBinding:
+			err = createOrUpdate(ctx, i.K8sClient.RbacV1().ClusterRoleBindings(), o)
+		case *rbacv1.Role:
+			err = createOrUpdate(ctx, i.K8sClient.RbacV1().Roles(o.Namespace), o)
+		case *rbacv1.RoleBinding:
+			err = createOrUpdate(ctx, i.K8sClient.RbacV1().RoleBindings(o.Namespace), o)
+		case *batchv1.CronJob:
+			err = createOrUpdate(ctx, i.K8sClient.BatchV1().CronJobs(o.Namespace), o)
+		case *batchv1.Job:
+			_, err = i.K8sClient.BatchV1().Jobs(o.Namespace).Create(ctx, o, metav1.CreateOptions{FieldManager: "inspector"})
+			if apierrors.IsAlreadyExists(err) {
+				err = fmt.Errorf("%w: delete the job to run inspector again", err)
+			}
+		default:
+			err = fmt.Errorf("cannot deploy %T", obj)
+		}
+		cancel()
+		ref := objectName(obj)
+		if err != nil {
+			return applied, fmt.Errorf("deploying %s: %w", ref, err)
+		}
+		applied = append(applied, ref)
+	}
+	return applied, nil
+}
+
+// objectClient creates and updates objects of a kind.
+type objectClient[T runtime.Object] interface {
+	Create(ctx context.Context, obj T, opts metav1.CreateOptions) (T, error)
+	Update(ctx context.Context, obj T, opts metav1.UpdateOptions) (T, error)
+}
+
+// createOrUpdate creates the object or updates it if it exists.
+func createOrUpdate[T runtime.Object](ctx context.Context, c objectClient[T], obj T) error {
+	_, err := c.Create(ctx, obj, metav1.CreateOptions{FieldManager: "inspector"})
+	if apierrors.IsAlreadyExists(err) {
+		_, err = c.Update(ctx, obj, metav1.UpdateOptions{FieldManager: "inspector"})
+	}
+	return err
+}
+
+// objectName returns the object kind and name in the kind/name form.
+func objectName(obj runtime.Object) string {
+	name := ""
+	if m, err := meta.Accessor(obj); err == nil {
+		name = m.GetName()
+	}
+	return strings.ToLower(obj.GetObjectKind().GroupVersionKind().Kind) + "/" + name
+}
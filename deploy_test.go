@@ -0,0 +1,223 @@
+package inspector_test
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/qba73/inspector"
+
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+func TestDeployObjectsRunInspectorJobWithProfileAccess(t *testing.T) {
+	t.Parallel()
+
+	objects, err := inspector.DeployObjects(deployOptions)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got []string
+	for _, o := range objects {
+		m, _ := o.(metav1.Object)
+		got = append(got, o.GetObjectKind().GroupVersionKind().Kind+"/"+m.GetNamespace()+"/"+m.GetName())
+	}
+	want := []string{
+		"ServiceAccount/tools/inspector",
+		"ClusterRole//inspector-tools",
+		"ClusterRoleBinding//inspector-tools",
+		"Role/nginx-ingress/inspector-tools",
+		"RoleBinding/nginx-ingress/inspector-tools",
+		"ConfigMap/tools/inspector",
+		"Job/tools/inspector",
+	}
+	if !cmp.Equal(want, got) {
+		t.Error(cmp.Diff(want, got))
+	}
+
+	role := objects[3].(*rbacv1.Role)
+	wantRules := []rbacv1.PolicyRule{
+		{APIGroups: []string{""}, Resources: []string{"pods"}, Verbs: []string{"list"}},
+		{APIGroups: []string{"apps"}, Resources: []string{"deployments"}, Verbs: []string{"list"}},
+	}
+	if !cmp.Equal(wantRules, role.Rules) {
+		t.Error(cmp.Diff(wantRules, role.Rules))
+	}
+	clusterRole := objects[1].(*rbacv1.ClusterRole)
+	wantClusterRules := []rbacv1.PolicyRule{
+		{APIGroups: []string{""}, Resources: []string{"nodes"}, Verbs: []string{"list"}},
+	}
+	if !cmp.Equal(wantClusterRules, clusterRole.Rules) {
+		t.Error(cmp.Diff(wantClusterRules, clusterRole.Rules))
+	}
+
+	job := objects[6].(*batchv1.Job)
+	pod := job.Spec.Template.Spec
+	if pod.ServiceAccountName != "inspector" || pod.Containers[0].Image != "registry.example.com/inspector:v1" {
+		t.Errorf("want inspector image run with the inspector service account, got %+v", pod)
+	}
+	wantArgs := []string{"collect", "-in-cluster", "-profile", "/etc/inspector/profile.yaml"}
+	if !cmp.Equal(wantArgs, pod.Containers[0].Args) {
+		t.Error(cmp.Diff(wantArgs, pod.Containers[0].Args))
+	}
+	if pod.Containers[0].EnvFrom[0].SecretRef.Name != "inspector-s3" {
+		t.Errorf("want environment read from the credentials secret, got %+v", pod.Containers[0].EnvFrom)
+	}
+}
+
+func TestDeployObjectsNameRBACAfterTheNamespaceInspectorRunsIn(t *testing.T) {
+	t.Parallel()
+
+	bindings := map[string]bool{}
+	for _, ns := range []string{"tools", "ops"} {
+		opts := deployOptions
+		opts.Namespace = ns
+		objects, err := inspector.DeployObjects(opts)
+		if err != nil {
+			t.Fatal(err)
+		}
+		binding := objects[2].(*rbacv1.ClusterRoleBinding)
+		if binding.Name != "inspector-"+ns || binding.RoleRef.Name != objects[1].(*rbacv1.ClusterRole).Name {
+			t.Errorf("want cluster role binding inspector-%s of its cluster role, got %s of %s", ns, binding.Name, binding.RoleRef.Name)
+		}
+		if binding.Subjects[0].Namespace != ns {
+			t.Errorf("want service account of %s bound, got %+v", ns, binding.Subjects)
+		}
+		roleBinding := objects[4].(*rbacv1.RoleBinding)
+		if roleBinding.RoleRef.Name != objects[3].(*rbacv1.Role).Name {
+			t.Errorf("want role binding of role %s, got %s", objects[3].(*rbacv1.Role).Name, roleBinding.RoleRef.Name)
+		}
+		bindings[binding.Name] = true
+		bindings[roleBinding.Namespace+"/"+roleBinding.Name] = true
+	}
+	if len(bindings) != 4 {
+		t.Errorf("want distinct bindings of each deployment, got %v", bindings)
+	}
+}
+
+func TestDeployObjectsStoreProfileWithDestinationInConfigMap(t *testing.T) {
+	t.Parallel()
+
+	objects, err := inspector.DeployObjects(deployOptions)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cm := objects[5].(*corev1.ConfigMap)
+	got, err := inspector.ParseProfile([]byte(cm.Data["profile.yaml"]))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := deployOptions.Profile
+	want.Name = "inspector"
+	if !cmp.Equal(want, got) {
+		t.Error(cmp.Diff(want, got))
+	}
+}
+
+func TestDeployObjectsRunCronJobOnSchedule(t *testing.T) {
+	t.Parallel()
+
+	opts := deployOptions
+	opts.Schedule = "0 * * * *"
+	objects, err := inspector.DeployObjects(opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cronJob, ok := objects[len(objects)-1].(*batchv1.CronJob)
+	if !ok {
+		t.Fatalf("want a CronJob, got %T", objects[len(objects)-1])
+	}
+	if cronJob.Spec.Schedule != "0 * * * *" || cronJob.Spec.ConcurrencyPolicy != batchv1.ForbidConcurrent {
+		t.Errorf("want hourly CronJob without concurrent runs, got %+v", cronJob.Spec)
+	}
+}
+
+func TestDeployObjectsRejectMissingImageAndLocalDestinations(t *testing.T) {
+	t.Parallel()
+
+	noImage := deployOptions
+	noImage.Image = ""
+	stdout := deployOptions
+	stdout.Profile.Output.Destination = ""
+	file := deployOptions
+	file.Profile.Output.Destination = "report.json"
+	for _, opts := range []inspector.DeployOptions{noImage, stdout, file} {
+		if _, err := inspector.DeployObjects(opts); inspector.ExitCode(err) != inspector.ExitConfig {
+			t.Errorf("want configuration error, got %v", err)
+		}
+	}
+}
+
+func TestInspectorDeployCreatesAndUpdatesObjects(t *testing.T) {
+	t.Parallel()
+
+	existing := &corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Name: "inspector", Namespace: "tools"}}
+	client := newTestClientset(existing)
+	i := &inspector.Inspector{K8sClient: client}
+	objects, err := inspector.DeployObjects(deployOptions)
+	if err != nil {
+		t.Fatal(err)
+	}
+	applied, err := i.Deploy(context.Background(), objects)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(applied) != len(objects) || applied[0] != "serviceaccount/inspector" {
+		t.Errorf("want all objects applied, got %v", applied)
+	}
+	cm, err := client.CoreV1().ConfigMaps("tools").Get(context.Background(), "inspector", metav1.GetOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(cm.Data["profile.yaml"], "s3://diagnostics/") {
+		t.Errorf("want existing ConfigMap updated with the profile, got %v", cm.Data)
+	}
+
+	_, err = i.Deploy(context.Background(), objects)
+	if !apierrors.IsAlreadyExists(err) {
+		t.Errorf("want error for the Job left from the previous run, got %v", err)
+	}
+}
+
+func TestInspectorDeployRejectsUnknownObjects(t *testing.T) {
+	t.Parallel()
+
+	i := &inspector.Inspector{K8sClient: newTestClientset()}
+	_, err := i.Deploy(context.Background(), []runtime.Object{&corev1.Pod{}})
+	if err == nil {
+		t.Errorf("want error deploying a Pod, got %v", err)
+	}
+}
+
+func TestRunDeployPrintsManifests(t *testing.T) {
+	t.Parallel()
+
+	var stdout, stderr bytes.Buffer
+	args := []string{"deploy", "-n", "tools", "-image", "registry.example.com/inspector:v1", "-profile", "ingress", "-out", "https://storage.example.com/upload"}
+	if code := inspector.Run(args, &stdout, &stderr); code != inspector.ExitOK {
+		t.Fatalf("want exit code %d, got %d: %s", inspector.ExitOK, code, stderr.String())
+	}
+	for _, want := range []string{"kind: ServiceAccount", "kind: ClusterRole", "kind: Role\n", "namespace: nginx-ingress", "kind: ConfigMap", "kind: Job"} {
+		if !strings.Contains(stdout.String(), want) {
+			t.Errorf("want %q in the manifest, got:\n%s", want, stdout.String())
+		}
+	}
+}
+
+var deployOptions = inspector.DeployOptions{
+	Namespace:         "tools",
+	Image:             "registry.example.com/inspector:v1",
+	CredentialsSecret: "inspector-s3",
+	Profile: inspector.Profile{
+		Collectors: []string{"nodes", "pods", "deployments"},
+		Namespaces: []string{"nginx-ingress"},
+		Output:     inspector.OutputOptions{Format: "json", Destination: "s3://diagnostics/{namespace}/report.json"},
+	},
+}
@@ -0,0 +1,816 @@
+package inspector
+
+import (
+	"fmt"
+	"io"
+	"maps"
+	"slices"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	discoveryv1 "k8s.io/api/discovery/v1"
+	eventsv1 "k8s.io/api/events/v1"
+	netv1 "k8s.io/api/networking/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/duration"
+)
+
+// describer writes kubectl describe style text:
+// indented fields with values aligned in columns.
+type describer struct {
+	tw  *tabwriter.Writer
+	now time.Time
+}
+
+func newDescriber(w io.Writer, now time.Time) *describer {
+	return &describer{tw: tabwriter.NewWriter(w, 0, 8, 2, ' ', 0), now: now}
+}
+
+// line writes a line indented by level.
+func (d *describer) line(level int, format string, args ...any) {
+	fmt.Fprintf(d.tw, strings.Repeat("  ", level)+format+"\n", args...)
+}
+
+// field writes a name: value line indented by level.
+func (d *describer) field(level int, name string, value any) {
+	d.line(level, "%s:\t%v", name, value)
+}
+
+// stringMap writes a map field with a key=value pair per line.
+func (d *describer) stringMap(level int, name string, m map[string]string) {
+	d.pairs(level, name, m, "=")
+}
+
+// annotations writes annotations with a key: value pair per line.
+func (d *describer) annotations(level int, m map[string]string) {
+	d.pairs(level, "Annotations", m, ": ")
+}
+
+// pairs writes a map field with a key and value joined by sep per line.
+func (d *describer) pairs(level int, name string, m map[string]string, sep string) {
+	if len(m) == 0 {
+		d.field(level, name, "<none>")
+		return
+	}
+	for n, k := range slices.Sorted(maps.Keys(m)) {
+		label := ""
+		if n == 0 {
+			label = name + ":"
+		}
+		d.line(level, "%s\t%s%s%s", label, k, sep, m[k])
+	}
+}
+
+// age returns the time elapsed since t in the kubectl form, like 5m.
+func (d *describer) age(t time.Time) string {
+	if t.IsZero() {
+		return "<unknown>"
+	}
+	return duration.HumanDuration(d.now.Sub(t))
+}
+
+// timestamp formats t the way kubectl describe does.
+func timestamp(t metav1.Time) string {
+	if t.IsZero() {
+		return "<unset>"
+	}
+	return t.UTC().Format(time.RFC1123Z)
+}
+
+// events writes the events table of the object.
+func (d *describer) events(events []corev1.Event) {
+	if len(events) == 0 {
+		d.field(0, "Events", "<none>")
+		return
+	}
+	d.line(0, "Events:")
+	d.line(1, "Type\tReason\tAge\tFrom\tMessage")
+	d.line(1, "----\t------\t----\t----\t-------")
+	for _, e := range events {
+		age := d.age(eventTime(e))
+		if e.Count > 1 && !e.FirstTimestamp.IsZero() {
+			age = fmt.Sprintf("%s (x%d over %s)", age, e.Count, d.age(e.FirstTimestamp.Time))
+		}
+		from := e.Source.Component
+		if from == "" {
+			from = e.ReportingController
+		}
+		d.line(1, "%s\t%s\t%s\t%s\t%s", e.Type, e.Reason, age, from, strings.TrimSpace(e.Message))
+	}
+}
+
+func (d *describer) flush() error {
+	return d.tw.Flush()
+}
+
+// eventTime returns the time the event last occurred.
+func eventTime(e corev1.Event) time.Time {
+	switch {
+	case !e.LastTimestamp.IsZero():
+		return e.LastTimestamp.Time
+	case !e.EventTime.IsZero():
+		return e.EventTime.Time
+	default:
+		return e.FirstTimestamp.Time
+	}
+}
+
+// objectEvents returns events of the object of the kind, oldest first.
+func objectEvents(events *corev1.EventList, kind string, obj metav1.Object) []corev1.Event {
+	if events == nil {
+		return nil
+	}
+	var related []corev1.Event
+	for _, e := range events.Items {
+		ref := e.InvolvedObject
+		if ref.Kind != kind || ref.Name != obj.GetName() || ref.Namespace != obj.GetNamespace() {
+			continue
+		}
+		if ref.UID != "" && obj.GetUID() != "" && ref.UID != obj.GetUID() {
+			continue
+		}
+		related = append(related, e)
+	}
+	slices.SortStableFunc(related, func(a, b corev1.Event) int {
+		return eventTime(a).Compare(eventTime(b))
+	})
+	return related
+}
+
+// DescribePod returns kubectl describe style text of the pod
+// with its events found in the event list, with ages relative to now.
+func DescribePod(pod corev1.Pod, events *corev1.EventList, now time.Time) (string, error) {
+	var b strings.Builder
+	d := newDescriber(&b, now)
+	d.field(0, "Name", pod.Name)
+	d.field(0, "Namespace", pod.Namespace)
+	d.field(0, "Priority", priority(pod.Spec.Priority))
+	d.field(0, "Service Account", pod.Spec.ServiceAccountName)
+	node := pod.Spec.NodeName
+	if node != "" && pod.Status.HostIP != "" {
+		node += "/" + pod.Status.HostIP
+	}
+	d.field(0, "Node", orNone(node))
+	if pod.Status.StartTime != nil {
+		d.field(0, "Start Time", timestamp(*pod.Status.StartTime))
+	}
+	d.stringMap(0, "Labels", pod.Labels)
+	d.annotations(0, pod.Annotations)
+	status := string(pod.Status.Phase)
+	if pod.DeletionTimestamp != nil {
+		status = "Terminating (lasts " + d.age(pod.DeletionTimestamp.Time) + ")"
+	}
+	d.field(0, "Status", status)
+	if pod.Status.Reason != "" {
+		d.field(0, "Reason", pod.Status.Reason)
+	}
+	if pod.Status.Message != "" {
+		d.field(0, "Message", pod.Status.Message)
+	}
+	d.field(0, "IP", orNone(pod.Status.PodIP))
+	if ref := metav1.GetControllerOfNoCopy(&pod); ref != nil {
+		d.field(0, "Controlled By", ref.Kind+"/"+ref.Name)
+	}
+	if len(pod.Spec.InitContainers) > 0 {
+		d.line(0, "Init Containers:")
+		d.containers(1, pod.Spec.InitContainers, pod.Status.InitContainerStatuses)
+	}
+	d.line(0, "Containers:")
+	d.containers(1, pod.Spec.Containers, pod.Status.ContainerStatuses)
+	if len(pod.Status.Conditions) > 0 {
+		d.line(0, "Conditions:")
+		d.line(1, "Type\tStatus")
+		for _, c := range pod.Status.Conditions {
+			d.line(1, "%s\t%s", c.Type, c.Status)
+		}
+	}
+	d.field(0, "QoS Class", pod.Status.QOSClass)
+	d.stringMap(0, "Node-Selectors", pod.Spec.NodeSelector)
+	d.events(objectEvents(events, "Pod", &pod))
+	if err := d.flush(); err != nil {
+		return "", err
+	}
+	return b.String(), nil
+}
+
+// containers writes containers of a pod with their statuses,
+// container names indented by level.
+func (d *describer) containers(level int, containers []corev1.Container, statuses []corev1.ContainerStatus) {
+	for _, c := range containers {
+		d.line(level, "%s:", c.Name)
+		d.field(level+1, "Image", c.Image)
+		for _, p := range c.Ports {
+			d.field(level+1, "Port", fmt.Sprintf("%d/%s", p.ContainerPort, p.Protocol))
+		}
+		if len(c.Command) > 0 {
+			d.field(level+1, "Command", strings.Join(c.Command, " "))
+		}
+		if len(c.Args) > 0 {
+			d.field(level+1, "Args", strings.Join(c.Args, " "))
+		}
+		i := slices.IndexFunc(statuses, func(s corev1.ContainerStatus) bool { return s.Name == c.Name })
+		if i >= 0 {
+			s := statuses[i]
+			d.containerState(level+1, "State", s.State)
+			if s.LastTerminationState != (corev1.ContainerState{}) {
+				d.containerState(level+1, "Last State", s.LastTerminationState)
+			}
+			d.field(level+1, "Ready", titleBool(s.Ready))
+			d.field(level+1, "Restart Count", s.RestartCount)
+		}
+		d.resources(level+1, "Limits", c.Resources.Limits)
+		d.resources(level+1, "Requests", c.Resources.Requests)
+		if len(c.Env) > 0 {
+			d.line(level+1, "Environment:")
+			for _, e := range c.Env {
+				value := e.Value
+				if e.ValueFrom != nil {
+					value = "<set from a reference>"
+				}
+				d.line(level+2, "%s:\t%s", e.Name, value)
+			}
+		}
+	}
+}
+
+// containerState writes the state of a container.
+func (d *describer) containerState(level int, name string, s corev1.ContainerState) {
+	switch {
+	case s.Running != nil:
+		d.field(level, name, "Running")
+		d.field(level+1, "Started", timestamp(s.Running.StartedAt))
+	case s.Waiting != nil:
+		d.field(level, name, "Waiting")
+		if s.Waiting.Reason != "" {
+			d.field(level+1, "Reason", s.Waiting.Reason)
+		}
+		if s.Waiting.Message != "" {
+			d.field(level+1, "Message", s.Waiting.Message)
+		}
+	case s.Terminated != nil:
+		d.field(level, name, "Terminated")
+		if s.Terminated.Reason != "" {
+			d.field(level+1, "Reason", s.Terminated.Reason)
+		}
+		if s.Terminated.Message != "" {
+			d.field(level+1, "Message", s.Terminated.Message)
+		}
+		d.field(level+1, "Exit Code", s.Terminated.ExitCode)
+		d.field(level+1, "Started", timestamp(s.Terminated.StartedAt))
+		d.field(level+1, "Finished", timestamp(s.Terminated.FinishedAt))
+	default:
+		d.field(level, name, "Waiting")
+	}
+}
+
+// resources writes resource quantities of a container.
+func (d *describer) resources(level int, name string, list corev1.ResourceList) {
+	if len(list) == 0 {
+		return
+	}
+	d.line(level, "%s:", name)
+	for _, r := range slices.Sorted(maps.Keys(list)) {
+		q := list[r]
+		d.line(level+1, "%s:\t%s", r, q.String())
+	}
+}
+
+// describedDirs lists directories of DescribeReport files
+// in the order kinds are described.
+var describedDirs = []string{"pods", "deployments", "services", "ingresses", "nodes"}
+
+// DescribeReport returns kubectl describe style text of pods,
+// deployments, services, ingresses and nodes of the report, with
+// their events inlined and ages relative to now. Keys name files,
+// like pods/web.txt. Objects not collected are left out, so saved
+// reports are described offline the same way.
+func DescribeReport(rep Report, now time.Time) (map[string]string, error) {
+	events := reportEvents(rep)
+	files := map[string]string{}
+	add := func(dir, name string, text string, err error) error {
+		if err != nil {
+			return fmt.Errorf("describing %s/%s: %w", dir, name, err)
+		}
+		files[dir+"/"+name+".txt"] = text
+		return nil
+	}
+	if rep.Pods != nil {
+		for _, pod := range rep.Pods.Items {
+			text, err := DescribePod(pod, events, now)
+			if err := add("pods", pod.Name, text, err); err != nil {
+				return nil, err
+			}
+		}
+	}
+	if rep.Deployments != nil {
+		for _, dep := range rep.Deployments.Items {
+			text, err := describeDeployment(dep, rep.ReplicaSets, events, now)
+			if err := add("deployments", dep.Name, text, err); err != nil {
+				return nil, err
+			}
+		}
+	}
+	if rep.Services != nil {
+		for _, svc := range rep.Services.Items {
+			text, err := describeService(svc, rep, events, now)
+			if err := add("services", svc.Name, text, err); err != nil {
+				return nil, err
+			}
+		}
+	}
+	if rep.Ingresses != nil {
+		for _, ing := range rep.Ingresses.Items {
+			text, err := describeIngress(ing, rep, events, now)
+			if err := add("ingresses", ing.Name, text, err); err != nil {
+				return nil, err
+			}
+		}
+	}
+	if rep.ClusterNodes != nil {
+		for _, node := range rep.ClusterNodes.Items {
+			text, err := describeNode(node, rep.Pods, events, now)
+			if err := add("nodes", node.Name, text, err); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return files, nil
+}
+
+// reportEvents returns core events of the report. Reports holding
+// only events.k8s.io/v1 events have them converted.
+func reportEvents(rep Report) *corev1.EventList {
+	if rep.Events != nil || rep.EventsV1 == nil {
+		return rep.Events
+	}
+	events := &corev1.EventList{}
+	for _, e := range rep.EventsV1.Items {
+		events.Items = append(events.Items, coreEvent(e))
+	}
+	return events
+}
+
+// coreEvent converts an events.k8s.io/v1 event to a core event.
+func coreEvent(e eventsv1.Event) corev1.Event {
+	ce := corev1.Event{
+		ObjectMeta:          e.ObjectMeta,
+		InvolvedObject:      e.Regarding,
+		Reason:              e.Reason,
+		Message:             e.Note,
+		Type:                e.Type,
+		EventTime:           e.EventTime,
+		FirstTimestamp:      e.DeprecatedFirstTimestamp,
+		LastTimestamp:       e.DeprecatedLastTimestamp,
+		Count:               e.DeprecatedCount,
+		Source:              e.DeprecatedSource,
+		ReportingController: e.ReportingController,
+	}
+	if ce.InvolvedObject.Namespace == "" && ce.InvolvedObject.Kind != "Node" {
+		ce.InvolvedObject.Namespace = e.Namespace
+	}
+	if e.Series != nil {
+		ce.Count = e.Series.Count
+		ce.LastTimestamp = metav1.NewTime(e.Series.LastObservedTime.Time)
+		if ce.FirstTimestamp.IsZero() {
+			ce.FirstTimestamp = metav1.NewTime(e.EventTime.Time)
+		}
+	}
+	return ce
+}
+
+// describeDeployment returns kubectl describe style text
+// of the deployment with its replica sets and events.
+func describeDeployment(dep appsv1.Deployment, replicaSets *appsv1.ReplicaSetList, events *corev1.EventList, now time.Time) (string, error) {
+	var b strings.Builder
+	d := newDescriber(&b, now)
+	d.field(0, "Name", dep.Name)
+	d.field(0, "Namespace", dep.Namespace)
+	d.field(0, "CreationTimestamp", timestamp(dep.CreationTimestamp))
+	d.stringMap(0, "Labels", dep.Labels)
+	d.annotations(0, dep.Annotations)
+	selector := "<none>"
+	if dep.Spec.Selector != nil {
+		selector = metav1.FormatLabelSelector(dep.Spec.Selector)
+	}
+	d.field(0, "Selector", selector)
+	replicas := int32(1)
+	if dep.Spec.Replicas != nil {
+		replicas = *dep.Spec.Replicas
+	}
+	st := dep.Status
+	d.field(0, "Replicas", fmt.Sprintf("%d desired | %d updated | %d total | %d available | %d unavailable",
+		replicas, st.UpdatedReplicas, st.Replicas, st.AvailableReplicas, st.UnavailableReplicas))
+	d.field(0, "StrategyType", dep.Spec.Strategy.Type)
+	d.field(0, "MinReadySeconds", dep.Spec.MinReadySeconds)
+	if ru := dep.Spec.Strategy.RollingUpdate; ru != nil && ru.MaxUnavailable != nil && ru.MaxSurge != nil {
+		d.field(0, "RollingUpdateStrategy", fmt.Sprintf("%s max unavailable, %s max surge", ru.MaxUnavailable, ru.MaxSurge))
+	}
+	d.line(0, "Pod Template:")
+	d.stringMap(1, "Labels", dep.Spec.Template.Labels)
+	if len(dep.Spec.Template.Annotations) > 0 {
+		d.annotations(1, dep.Spec.Template.Annotations)
+	}
+	if sa := dep.Spec.Template.Spec.ServiceAccountName; sa != "" {
+		d.field(1, "Service Account", sa)
+	}
+	if len(dep.Spec.Template.Spec.InitContainers) > 0 {
+		d.line(1, "Init Containers:")
+		d.containers(2, dep.Spec.Template.Spec.InitContainers, nil)
+	}
+	d.line(1, "Containers:")
+	d.containers(2, dep.Spec.Template.Spec.Containers, nil)
+	d.stringMap(1, "Node-Selectors", dep.Spec.Template.Spec.NodeSelector)
+	if len(st.Conditions) > 0 {
+		d.line(0, "Conditions:")
+		d.line(1, "Type\tStatus\tReason")
+		d.line(1, "----\t------\t------")
+		for _, c := range st.Conditions {
+			d.line(1, "%s\t%s\t%s", c.Type, c.Status, c.Reason)
+		}
+	}
+	var newRS string
+	var oldRS []string
+	if replicaSets != nil {
+		revision := dep.Annotations[deploymentRevisionAnnotation]
+		for _, rs := range replicaSets.Items {
+			if rs.Namespace != dep.Namespace || !ownedBy(rs.OwnerReferences, "Deployment", dep.Name) {
+				continue
+			}
+			desired := int32(0)
+			if rs.Spec.Replicas != nil {
+				desired = *rs.Spec.Replicas
+			}
+			text := fmt.Sprintf("%s (%d/%d replicas created)", rs.Name, rs.Status.Replicas, desired)
+			if revision != "" && rs.Annotations[deploymentRevisionAnnotation] == revision {
+				newRS = text
+				continue
+			}
+			if desired > 0 || rs.Status.Replicas > 0 {
+				oldRS = append(oldRS, text)
+			}
+		}
+	}
+	d.field(0, "OldReplicaSets", orNone(strings.Join(oldRS, ", ")))
+	d.field(0, "NewReplicaSet", orNone(newRS))
+	d.events(objectEvents(events, "Deployment", &dep))
+	if err := d.flush(); err != nil {
+		return "", err
+	}
+	return b.String(), nil
+}
+
+// describeService returns kubectl describe style text
+// of the service with its endpoints and events.
+func describeService(svc corev1.Service, rep Report, events *corev1.EventList, now time.Time) (string, error) {
+	var b strings.Builder
+	d := newDescriber(&b, now)
+	d.field(0, "Name", svc.Name)
+	d.field(0, "Namespace", svc.Namespace)
+	d.stringMap(0, "Labels", svc.Labels)
+	d.annotations(0, svc.Annotations)
+	var selector []string
+	for _, k := range slices.Sorted(maps.Keys(svc.Spec.Selector)) {
+		selector = append(selector, k+"="+svc.Spec.Selector[k])
+	}
+	d.field(0, "Selector", orNone(strings.Join(selector, ",")))
+	d.field(0, "Type", svc.Spec.Type)
+	if svc.Spec.Type == corev1.ServiceTypeExternalName {
+		d.field(0, "External Name", svc.Spec.ExternalName)
+	}
+	if svc.Spec.IPFamilyPolicy != nil {
+		d.field(0, "IP Family Policy", *svc.Spec.IPFamilyPolicy)
+	}
+	if len(svc.Spec.IPFamilies) > 0 {
+		var families []string
+		for _, f := range svc.Spec.IPFamilies {
+			families = append(families, string(f))
+		}
+		d.field(0, "IP Families", strings.Join(families, ","))
+	}
+	d.field(0, "IP", orNone(svc.Spec.ClusterIP))
+	if len(svc.Spec.ClusterIPs) > 0 {
+		d.field(0, "IPs", strings.Join(svc.Spec.ClusterIPs, ","))
+	}
+	if len(svc.Spec.ExternalIPs) > 0 {
+		d.field(0, "External IPs", strings.Join(svc.Spec.ExternalIPs, ","))
+	}
+	if ingress := loadBalancerIngress(svc.Status.LoadBalancer.Ingress); ingress != "" {
+		d.field(0, "LoadBalancer Ingress", ingress)
+	}
+	for _, p := range svc.Spec.Ports {
+		name := p.Name
+		if name == "" {
+			name = "<unset>"
+		}
+		d.field(0, "Port", fmt.Sprintf("%s  %d/%s", name, p.Port, p.Protocol))
+		if svc.Spec.Type != corev1.ServiceTypeExternalName {
+			d.field(0, "TargetPort", fmt.Sprintf("%s/%s", p.TargetPort.String(), p.Protocol))
+		}
+		if p.NodePort != 0 {
+			d.field(0, "NodePort", fmt.Sprintf("%s  %d/%s", name, p.NodePort, p.Protocol))
+		}
+		d.field(0, "Endpoints", serviceEndpoints(rep, svc, p.Name))
+	}
+	d.field(0, "Session Affinity", svc.Spec.SessionAffinity)
+	if svc.Spec.ExternalTrafficPolicy != "" {
+		d.field(0, "External Traffic Policy", svc.Spec.ExternalTrafficPolicy)
+	}
+	d.events(objectEvents(events, "Service", &svc))
+	if err := d.flush(); err != nil {
+		return "", err
+	}
+	return b.String(), nil
+}
+
+// loadBalancerIngress returns comma separated load balancer addresses.
+func loadBalancerIngress(ingress []corev1.LoadBalancerIngress) string {
+	var addrs []string
+	for _, in := range ingress {
+		switch {
+		case in.IP != "":
+			addrs = append(addrs, in.IP)
+		case in.Hostname != "":
+			addrs = append(addrs, in.Hostname)
+		}
+	}
+	return strings.Join(addrs, ",")
+}
+
+// serviceEndpoints returns ready endpoints of the named service port
+// found in endpoint slices of the report or, when slices were not
+// collected, in endpoints. Up to three endpoints are listed.
+func serviceEndpoints(rep Report, svc corev1.Service, port string) string {
+	var addrs []string
+	switch {
+	case rep.EndpointSlices != nil:
+		for _, slice := range rep.EndpointSlices.Items {
+			if slice.Namespace != svc.Namespace || slice.Labels[discoveryv1.LabelServiceName] != svc.Name {
+				continue
+			}
+			for _, p := range slice.Ports {
+				if p.Port == nil || (p.Name == nil && port != "") || (p.Name != nil && *p.Name != port) {
+					continue
+				}
+				for _, e := range slice.Endpoints {
+					if e.Conditions.Ready != nil && !*e.Conditions.Ready {
+						continue
+					}
+					for _, addr := range e.Addresses {
+						addrs = append(addrs, fmt.Sprintf("%s:%d", addr, *p.Port))
+					}
+				}
+			}
+		}
+	case rep.Endpoints != nil:
+		for _, ep := range rep.Endpoints.Items {
+			if ep.Namespace != svc.Namespace || ep.Name != svc.Name {
+				continue
+			}
+			for _, subset := range ep.Subsets {
+				for _, p := range subset.Ports {
+					if p.Name != port {
+						continue
+					}
+					for _, addr := range subset.Addresses {
+						addrs = append(addrs, fmt.Sprintf("%s:%d", addr.IP, p.Port))
+					}
+				}
+			}
+		}
+	default:
+		return "<unknown>"
+	}
+	if len(addrs) == 0 {
+		return "<none>"
+	}
+	slices.Sort(addrs)
+	if len(addrs) > 3 {
+		return fmt.Sprintf("%s + %d more...", strings.Join(addrs[:3], ","), len(addrs)-3)
+	}
+	return strings.Join(addrs, ",")
+}
+
+// describeIngress returns kubectl describe style text of the ingress
+// with its rules resolved to service endpoints and its events.
+func describeIngress(ing netv1.Ingress, rep Report, events *corev1.EventList, now time.Time) (string, error) {
+	var b strings.Builder
+	d := newDescriber(&b, now)
+	d.field(0, "Name", ing.Name)
+	d.stringMap(0, "Labels", ing.Labels)
+	d.field(0, "Namespace", ing.Namespace)
+	var addrs []string
+	for _, in := range ing.Status.LoadBalancer.Ingress {
+		switch {
+		case in.IP != "":
+			addrs = append(addrs, in.IP)
+		case in.Hostname != "":
+			addrs = append(addrs, in.Hostname)
+		}
+	}
+	d.field(0, "Address", strings.Join(addrs, ","))
+	class := "<none>"
+	if ing.Spec.IngressClassName != nil {
+		class = *ing.Spec.IngressClassName
+	}
+	d.field(0, "Ingress Class", class)
+	backend := "<default>"
+	if ing.Spec.DefaultBackend != nil {
+		backend = describeBackend(*ing.Spec.DefaultBackend, ing.Namespace, rep)
+	}
+	d.field(0, "Default backend", backend)
+	if len(ing.Spec.TLS) > 0 {
+		d.line(0, "TLS:")
+		for _, tls := range ing.Spec.TLS {
+			secret := tls.SecretName
+			if secret == "" {
+				secret = "SNI routes"
+			}
+			d.line(1, "%s terminates %s", secret, strings.Join(tls.Hosts, ","))
+		}
+	}
+	d.line(0, "Rules:")
+	d.line(1, "Host\tPath\tBackends")
+	d.line(1, "----\t----\t--------")
+	if len(ing.Spec.Rules) == 0 {
+		d.line(1, "*\t*\t%s", backend)
+	}
+	for _, rule := range ing.Spec.Rules {
+		host := rule.Host
+		if host == "" {
+			host = "*"
+		}
+		if rule.HTTP == nil {
+			d.line(1, "%s\t*\t%s", host, backend)
+			continue
+		}
+		d.line(1, "%s\t\t", host)
+		for _, path := range rule.HTTP.Paths {
+			p := path.Path
+			if p == "" {
+				p = "/"
+			}
+			d.line(2, "\t%s\t%s", p, describeBackend(path.Backend, ing.Namespace, rep))
+		}
+	}
+	d.annotations(0, ing.Annotations)
+	d.events(objectEvents(events, "Ingress", &ing))
+	if err := d.flush(); err != nil {
+		return "", err
+	}
+	return b.String(), nil
+}
+
+// describeBackend returns the backend with endpoints of its service,
+// like web:80 (10.0.0.1:8080). Errors finding the service are shown
+// in place of endpoints the way kubectl shows them.
+func describeBackend(backend netv1.IngressBackend, namespace string, rep Report) string {
+	if backend.Resource != nil {
+		group := ""
+		if backend.Resource.APIGroup != nil {
+			group = *backend.Resource.APIGroup
+		}
+		return fmt.Sprintf("APIGroup: %s, Kind: %s, Name: %s", group, backend.Resource.Kind, backend.Resource.Name)
+	}
+	if backend.Service == nil {
+		return "<none>"
+	}
+	sb := backend.Service
+	port := sb.Port.Name
+	if port == "" {
+		port = fmt.Sprint(sb.Port.Number)
+	}
+	name := sb.Name + ":" + port
+	if rep.Services == nil {
+		return name + " (<unknown>)"
+	}
+	i := slices.IndexFunc(rep.Services.Items, func(svc corev1.Service) bool {
+		return svc.Namespace == namespace && svc.Name == sb.Name
+	})
+	if i < 0 {
+		return fmt.Sprintf("%s (<error: services %q not found>)", name, sb.Name)
+	}
+	svc := rep.Services.Items[i]
+	j := slices.IndexFunc(svc.Spec.Ports, func(p corev1.ServicePort) bool {
+		if sb.Port.Name != "" {
+			return p.Name == sb.Port.Name
+		}
+		return p.Port == sb.Port.Number
+	})
+	if j < 0 {
+		return fmt.Sprintf("%s (<error: port %s not found in service %q>)", name, port, sb.Name)
+	}
+	return fmt.Sprintf("%s (%s)", name, serviceEndpoints(rep, svc, svc.Spec.Ports[j].Name))
+}
+
+// describeNode returns kubectl describe style text of the node
+// with pods of the report running on it and its events.
+func describeNode(node corev1.Node, pods *corev1.PodList, events *corev1.EventList, now time.Time) (string, error) {
+	var b strings.Builder
+	d := newDescriber(&b, now)
+	d.field(0, "Name", node.Name)
+	d.field(0, "Roles", orNone(strings.Join(nodeRoles(node), ",")))
+	d.stringMap(0, "Labels", node.Labels)
+	d.annotations(0, node.Annotations)
+	d.field(0, "CreationTimestamp", timestamp(node.CreationTimestamp))
+	if len(node.Spec.Taints) == 0 {
+		d.field(0, "Taints", "<none>")
+	}
+	for n, t := range node.Spec.Taints {
+		label := ""
+		if n == 0 {
+			label = "Taints:"
+		}
+		d.line(0, "%s\t%s", label, t.ToString())
+	}
+	d.field(0, "Unschedulable", node.Spec.Unschedulable)
+	if len(node.Status.Conditions) > 0 {
+		d.line(0, "Conditions:")
+		d.line(1, "Type\tStatus\tLastHeartbeatTime\tReason\tMessage")
+		d.line(1, "----\t------\t-----------------\t------\t-------")
+		for _, c := range node.Status.Conditions {
+			d.line(1, "%s\t%s\t%s\t%s\t%s", c.Type, c.Status, timestamp(c.LastHeartbeatTime), c.Reason, c.Message)
+		}
+	}
+	d.line(0, "Addresses:")
+	for _, a := range node.Status.Addresses {
+		d.field(1, string(a.Type), a.Address)
+	}
+	d.resources(0, "Capacity", node.Status.Capacity)
+	d.resources(0, "Allocatable", node.Status.Allocatable)
+	info := node.Status.NodeInfo
+	d.line(0, "System Info:")
+	d.field(1, "Machine ID", info.MachineID)
+	d.field(1, "System UUID", info.SystemUUID)
+	d.field(1, "Boot ID", info.BootID)
+	d.field(1, "Kernel Version", info.KernelVersion)
+	d.field(1, "OS Image", info.OSImage)
+	d.field(1, "Operating System", info.OperatingSystem)
+	d.field(1, "Architecture", info.Architecture)
+	d.field(1, "Container Runtime Version", info.ContainerRuntimeVersion)
+	d.field(1, "Kubelet Version", info.KubeletVersion)
+	if node.Spec.PodCIDR != "" {
+		d.field(0, "PodCIDR", node.Spec.PodCIDR)
+	}
+	if node.Spec.ProviderID != "" {
+		d.field(0, "ProviderID", node.Spec.ProviderID)
+	}
+	if pods != nil {
+		var running []corev1.Pod
+		for _, pod := range pods.Items {
+			if pod.Spec.NodeName == node.Name && pod.Status.Phase != corev1.PodSucceeded && pod.Status.Phase != corev1.PodFailed {
+				running = append(running, pod)
+			}
+		}
+		d.field(0, "Non-terminated Pods", fmt.Sprintf("(%d in collected namespace)", len(running)))
+		if len(running) > 0 {
+			d.line(1, "Namespace\tName\tAge")
+			d.line(1, "---------\t----\t---")
+			for _, pod := range running {
+				d.line(1, "%s\t%s\t%s", pod.Namespace, pod.Name, d.age(pod.CreationTimestamp.Time))
+			}
+		}
+	}
+	d.events(objectEvents(events, "Node", &node))
+	if err := d.flush(); err != nil {
+		return "", err
+	}
+	return b.String(), nil
+}
+
+// nodeRoles returns roles of the node from its role labels.
+func nodeRoles(node corev1.Node) []string {
+	var roles []string
+	for _, k := range slices.Sorted(maps.Keys(node.Labels)) {
+		switch {
+		case strings.HasPrefix(k, "node-role.kubernetes.io/"):
+			roles = append(roles, strings.TrimPrefix(k, "node-role.kubernetes.io/"))
+		case k == "kubernetes.io/role":
+			roles = append(roles, node.Labels[k])
+		}
+	}
+	return roles
+}
+
+func priority(p *int32) string {
+	if p == nil {
+		return "0"
+	}
+	return fmt.Sprint(*p)
+}
+
+func orNone(s string) string {
+	if s == "" {
+		return "<none>"
+	}
+	return s
+}
+
+func titleBool(b bool) string {
+	if b {
+		return "True"
+	}
+	return "False"
+}
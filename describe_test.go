@@ -0,0 +1,391 @@
+package inspector_test
+
+import (
+	"bytes"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/qba73/inspector"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	discoveryv1 "k8s.io/api/discovery/v1"
+	eventsv1 "k8s.io/api/events/v1"
+	netv1 "k8s.io/api/networking/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+)
+
+func TestDescribePodRendersContainersAndEvents(t *testing.T) {
+	t.Parallel()
+
+	got, err := inspector.DescribePod(describedPod, describedEvents, describeNow)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `Name:             web-7d9f8-x2x4k
+Namespace:        shop
+Priority:         0
+Service Account:  web
+Node:             node-1/10.0.0.1
+Start Time:       Mon, 01 Jun 2026 09:00:00 +0000
+Labels:           app=web
+                  pod-template-hash=7d9f8
+Annotations:      <none>
+Status:           Running
+IP:               10.1.0.5
+Controlled By:    ReplicaSet/web-7d9f8
+Containers:
+  web:
+    Image:          nginx:1.27
+    Port:           80/TCP
+    State:          Waiting
+      Reason:       CrashLoopBackOff
+      Message:      back-off 1m20s restarting failed container
+    Last State:     Terminated
+      Reason:       OOMKilled
+      Exit Code:    137
+      Started:      Mon, 01 Jun 2026 09:57:00 +0000
+      Finished:     Mon, 01 Jun 2026 09:58:00 +0000
+    Ready:          False
+    Restart Count:  4
+    Limits:
+      memory:  128Mi
+    Environment:
+      LOG_LEVEL:  debug
+Conditions:
+  Type           Status
+  Ready          False
+QoS Class:       Burstable
+Node-Selectors:  <none>
+Events:
+  Type     Reason     Age                From               Message
+  ----     ------     ----               ----               -------
+  Normal   Scheduled  60m                default-scheduler  Successfully assigned shop/web-7d9f8-x2x4k to node-1
+  Warning  BackOff    60s (x5 over 10m)  kubelet            Back-off restarting failed container web in pod web-7d9f8-x2x4k_shop(1)
+`
+	if !cmp.Equal(want, got) {
+		t.Error(cmp.Diff(want, got))
+	}
+}
+
+var describeNow = time.Date(2026, 6, 1, 10, 0, 0, 0, time.UTC)
+
+var describedPod = corev1.Pod{
+	ObjectMeta: metav1.ObjectMeta{
+		Name:      "web-7d9f8-x2x4k",
+		Namespace: "shop",
+		UID:       "1",
+		Labels:    map[string]string{"app": "web", "pod-template-hash": "7d9f8"},
+		OwnerReferences: []metav1.OwnerReference{
+			{APIVersion: "apps/v1", Kind: "ReplicaSet", Name: "web-7d9f8", Controller: &isController},
+		},
+	},
+	Spec: corev1.PodSpec{
+		ServiceAccountName: "web",
+		NodeName:           "node-1",
+		Containers: []corev1.Container{{
+			Name:  "web",
+			Image: "nginx:1.27",
+			Ports: []corev1.ContainerPort{{ContainerPort: 80, Protocol: corev1.ProtocolTCP}},
+			Resources: corev1.ResourceRequirements{
+				Limits: corev1.ResourceList{corev1.ResourceMemory: resource.MustParse("128Mi")},
+			},
+			Env: []corev1.EnvVar{{Name: "LOG_LEVEL", Value: "debug"}},
+		}},
+	},
+	Status: corev1.PodStatus{
+		Phase:      corev1.PodRunning,
+		HostIP:     "10.0.0.1",
+		PodIP:      "10.1.0.5",
+		StartTime:  &metav1.Time{Time: describeNow.Add(-time.Hour)},
+		QOSClass:   corev1.PodQOSBurstable,
+		Conditions: []corev1.PodCondition{{Type: corev1.PodReady, Status: corev1.ConditionFalse}},
+		ContainerStatuses: []corev1.ContainerStatus{{
+			Name:         "web",
+			RestartCount: 4,
+			State:        corev1.ContainerState{Waiting: &corev1.ContainerStateWaiting{Reason: "CrashLoopBackOff", Message: "back-off 1m20s restarting failed container"}},
+			LastTerminationState: corev1.ContainerState{Terminated: &corev1.ContainerStateTerminated{
+				Reason:     "OOMKilled",
+				ExitCode:   137,
+				StartedAt:  metav1.NewTime(describeNow.Add(-3 * time.Minute)),
+				FinishedAt: metav1.NewTime(describeNow.Add(-2 * time.Minute)),
+			}},
+		}},
+	},
+}
+
+var describedEvents = &corev1.EventList{Items: []corev1.Event{
+	{
+		InvolvedObject: corev1.ObjectReference{Kind: "Pod", Namespace: "shop", Name: "web-7d9f8-x2x4k", UID: "1"},
+		Type:           corev1.EventTypeWarning,
+		Reason:         "BackOff",
+		Message:        "Back-off restarting failed container web in pod web-7d9f8-x2x4k_shop(1)",
+		Source:         corev1.EventSource{Component: "kubelet"},
+		Count:          5,
+		FirstTimestamp: metav1.NewTime(describeNow.Add(-10 * time.Minute)),
+		LastTimestamp:  metav1.NewTime(describeNow.Add(-time.Minute)),
+	},
+	{
+		InvolvedObject: corev1.ObjectReference{Kind: "Pod", Namespace: "shop", Name: "web-7d9f8-x2x4k", UID: "1"},
+		Type:           corev1.EventTypeNormal,
+		Reason:         "Scheduled",
+		Message:        "Successfully assigned shop/web-7d9f8-x2x4k to node-1",
+		Source:         corev1.EventSource{Component: "default-scheduler"},
+		Count:          1,
+		FirstTimestamp: metav1.NewTime(describeNow.Add(-time.Hour)),
+		LastTimestamp:  metav1.NewTime(describeNow.Add(-time.Hour)),
+	},
+	{
+		InvolvedObject: corev1.ObjectReference{Kind: "Pod", Namespace: "shop", Name: "api-5c4b9-q8w7e"},
+		Type:           corev1.EventTypeNormal,
+		Reason:         "Pulled",
+		LastTimestamp:  metav1.NewTime(describeNow.Add(-time.Minute)),
+	},
+}}
+
+func TestDescribeReportDescribesCollectedObjects(t *testing.T) {
+	t.Parallel()
+
+	files, err := inspector.DescribeReport(describedReport, describeNow)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var names []string
+	for name := range files {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+	want := []string{
+		"deployments/web.txt",
+		"ingresses/web.txt",
+		"nodes/node-1.txt",
+		"pods/web-7d9f8-x2x4k.txt",
+		"services/web.txt",
+	}
+	if !cmp.Equal(want, names) {
+		t.Error(cmp.Diff(want, names))
+	}
+
+	wantLines := map[string][]string{
+		"deployments/web.txt": {
+			"Replicas:           1 desired | 1 updated | 1 total | 0 available | 1 unavailable",
+			"OldReplicaSets:    <none>",
+			"NewReplicaSet:     web-7d9f8 (1/1 replicas created)",
+		},
+		"ingresses/web.txt": {
+			"  shop-tls terminates shop.example.com",
+			"                    /     web:80 (10.1.0.5:8080)",
+			`                    /api  api:http (<error: services "api" not found>)`,
+			"  Normal  AddedOrUpdated  10m   nginx-ingress-controller  Configuration for shop/web was added or updated",
+		},
+		"nodes/node-1.txt": {
+			"Roles:              worker",
+			"Taints:             dedicated=web:NoSchedule",
+			"  Ready  True    Mon, 01 Jun 2026 09:59:00 +0000  KubeletReady  kubelet is posting ready status",
+			"  shop                        web-7d9f8-x2x4k  <unknown>",
+		},
+		"services/web.txt": {
+			"Port:              http  80/TCP",
+			"Endpoints:         10.1.0.5:8080",
+		},
+	}
+	for name, lines := range wantLines {
+		got := strings.Split(files[name], "\n")
+		for _, line := range lines {
+			if !slices.Contains(got, line) {
+				t.Errorf("want %s holding line %q, got\n%s", name, line, files[name])
+			}
+		}
+	}
+	pod, err := inspector.DescribePod(describedPod, describedEvents, describeNow)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if files["pods/web-7d9f8-x2x4k.txt"] != pod {
+		t.Error(cmp.Diff(pod, files["pods/web-7d9f8-x2x4k.txt"]))
+	}
+}
+
+func TestDescribeReportInlinesEventsV1Events(t *testing.T) {
+	t.Parallel()
+
+	rep := inspector.Report{
+		Services: describedReport.Services,
+		EventsV1: &eventsv1.EventList{Items: []eventsv1.Event{{
+			ObjectMeta:          metav1.ObjectMeta{Name: "web.lb", Namespace: "shop"},
+			Regarding:           corev1.ObjectReference{Kind: "Service", Name: "web"},
+			Type:                corev1.EventTypeWarning,
+			Reason:              "SyncLoadBalancerFailed",
+			Note:                "Error syncing load balancer",
+			ReportingController: "service-controller",
+			EventTime:           metav1.NewMicroTime(describeNow.Add(-20 * time.Minute)),
+			Series:              &eventsv1.EventSeries{Count: 3, LastObservedTime: metav1.NewMicroTime(describeNow.Add(-5 * time.Minute))},
+		}}},
+	}
+	files, err := inspector.DescribeReport(rep, describeNow)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "  Warning  SyncLoadBalancerFailed  5m (x3 over 20m)  service-controller  Error syncing load balancer\n"
+	if !strings.HasSuffix(files["services/web.txt"], want) {
+		t.Errorf("want events of the service inlined, got\n%s", files["services/web.txt"])
+	}
+}
+
+func TestRunDescribeDescribesObjectsOfSavedReport(t *testing.T) {
+	t.Parallel()
+
+	path := writeReport(t, describedReport)
+	var stdout, stderr bytes.Buffer
+	if code := inspector.Run([]string{"describe", "-f", path, "svc", "deploy/web"}, &stdout, &stderr); code != inspector.ExitOK {
+		t.Fatalf("want exit code %d, got %d: %s", inspector.ExitOK, code, stderr.String())
+	}
+	files, err := inspector.DescribeReport(describedReport, describeNow)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Deployments are described before services, whatever the argument order.
+	got := stdout.String()
+	deployment := strings.Index(got, "StrategyType:")
+	service := strings.Index(got, "Session Affinity:")
+	if deployment < 0 || service < deployment || strings.Contains(got, "Containers:\n  web:") || strings.Contains(got, "Rules:") {
+		t.Errorf("want the web deployment and services described, got\n%s", got)
+	}
+	if !strings.HasSuffix(got, files["services/web.txt"]) {
+		t.Error(cmp.Diff(files["services/web.txt"], got))
+	}
+}
+
+func TestRunDescribeRejectsUnknownKindsAndObjects(t *testing.T) {
+	t.Parallel()
+
+	path := writeReport(t, describedReport)
+	for _, arg := range []string{"configmaps", "svc/api"} {
+		var stdout, stderr bytes.Buffer
+		if code := inspector.Run([]string{"describe", "-f", path, arg}, &stdout, &stderr); code != inspector.ExitConfig {
+			t.Errorf("%s: want exit code %d, got %d: %s", arg, inspector.ExitConfig, code, stderr.String())
+		}
+	}
+}
+
+var (
+	describedReplicas     int32 = 1
+	describedPort         int32 = 8080
+	describedPortName           = "http"
+	describedIngressClass       = "nginx"
+	isReady                     = true
+	notReady                    = false
+)
+
+// describedReport holds objects of the web application of the shop
+// namespace: the web ingress routes to the web service and to the
+// missing api service.
+var describedReport = inspector.Report{
+	Namespace: "shop",
+	Pods:      &corev1.PodList{Items: []corev1.Pod{describedPod}},
+	Events: &corev1.EventList{Items: append(slices.Clone(describedEvents.Items), corev1.Event{
+		ObjectMeta:     metav1.ObjectMeta{Name: "web.ing", Namespace: "shop"},
+		InvolvedObject: corev1.ObjectReference{Kind: "Ingress", Namespace: "shop", Name: "web"},
+		Type:           corev1.EventTypeNormal,
+		Reason:         "AddedOrUpdated",
+		Message:        "Configuration for shop/web was added or updated",
+		Source:         corev1.EventSource{Component: "nginx-ingress-controller"},
+		Count:          1,
+		LastTimestamp:  metav1.NewTime(describeNow.Add(-10 * time.Minute)),
+	})},
+	Deployments: &appsv1.DeploymentList{Items: []appsv1.Deployment{{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:              "web",
+			Namespace:         "shop",
+			CreationTimestamp: metav1.NewTime(describeNow.Add(-24 * time.Hour)),
+			Annotations:       map[string]string{"deployment.kubernetes.io/revision": "2"},
+		},
+		Spec: appsv1.DeploymentSpec{
+			Replicas: &describedReplicas,
+			Selector: &metav1.LabelSelector{MatchLabels: map[string]string{"app": "web"}},
+			Strategy: appsv1.DeploymentStrategy{Type: appsv1.RecreateDeploymentStrategyType},
+			Template: corev1.PodTemplateSpec{
+				ObjectMeta: metav1.ObjectMeta{Labels: map[string]string{"app": "web"}},
+				Spec:       corev1.PodSpec{Containers: []corev1.Container{{Name: "web", Image: "nginx:1.27"}}},
+			},
+		},
+		Status: appsv1.DeploymentStatus{Replicas: 1, UpdatedReplicas: 1, UnavailableReplicas: 1},
+	}}},
+	ReplicaSets: &appsv1.ReplicaSetList{Items: []appsv1.ReplicaSet{
+		describedReplicaSet("web-5c4b7", "1", 0),
+		describedReplicaSet("web-7d9f8", "2", 1),
+	}},
+	Services: &corev1.ServiceList{Items: []corev1.Service{{
+		ObjectMeta: metav1.ObjectMeta{Name: "web", Namespace: "shop"},
+		Spec: corev1.ServiceSpec{
+			Type:            corev1.ServiceTypeClusterIP,
+			Selector:        map[string]string{"app": "web"},
+			ClusterIP:       "10.96.0.10",
+			Ports:           []corev1.ServicePort{{Name: "http", Port: 80, Protocol: corev1.ProtocolTCP, TargetPort: intstr.FromInt32(8080)}},
+			SessionAffinity: corev1.ServiceAffinityNone,
+		},
+	}}},
+	EndpointSlices: &discoveryv1.EndpointSliceList{Items: []discoveryv1.EndpointSlice{{
+		ObjectMeta: metav1.ObjectMeta{Name: "web-abcde", Namespace: "shop", Labels: map[string]string{discoveryv1.LabelServiceName: "web"}},
+		Ports:      []discoveryv1.EndpointPort{{Name: &describedPortName, Port: &describedPort}},
+		Endpoints: []discoveryv1.Endpoint{
+			{Addresses: []string{"10.1.0.5"}, Conditions: discoveryv1.EndpointConditions{Ready: &isReady}},
+			{Addresses: []string{"10.1.0.6"}, Conditions: discoveryv1.EndpointConditions{Ready: &notReady}},
+		},
+	}}},
+	Ingresses: &netv1.IngressList{Items: []netv1.Ingress{{
+		ObjectMeta: metav1.ObjectMeta{Name: "web", Namespace: "shop"},
+		Spec: netv1.IngressSpec{
+			IngressClassName: &describedIngressClass,
+			TLS:              []netv1.IngressTLS{{Hosts: []string{"shop.example.com"}, SecretName: "shop-tls"}},
+			Rules: []netv1.IngressRule{{
+				Host: "shop.example.com",
+				IngressRuleValue: netv1.IngressRuleValue{HTTP: &netv1.HTTPIngressRuleValue{Paths: []netv1.HTTPIngressPath{
+					{Path: "/", Backend: netv1.IngressBackend{Service: &netv1.IngressServiceBackend{Name: "web", Port: netv1.ServiceBackendPort{Number: 80}}}},
+					{Path: "/api", Backend: netv1.IngressBackend{Service: &netv1.IngressServiceBackend{Name: "api", Port: netv1.ServiceBackendPort{Name: "http"}}}},
+				}}},
+			}},
+		},
+		Status: netv1.IngressStatus{LoadBalancer: netv1.IngressLoadBalancerStatus{Ingress: []netv1.IngressLoadBalancerIngress{{IP: "203.0.113.10"}}}},
+	}}},
+	ClusterNodes: &corev1.NodeList{Items: []corev1.Node{{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:              "node-1",
+			Labels:            map[string]string{"node-role.kubernetes.io/worker": ""},
+			CreationTimestamp: metav1.NewTime(describeNow.Add(-30 * 24 * time.Hour)),
+		},
+		Spec: corev1.NodeSpec{Taints: []corev1.Taint{{Key: "dedicated", Value: "web", Effect: corev1.TaintEffectNoSchedule}}},
+		Status: corev1.NodeStatus{
+			Conditions: []corev1.NodeCondition{{
+				Type:              corev1.NodeReady,
+				Status:            corev1.ConditionTrue,
+				LastHeartbeatTime: metav1.NewTime(describeNow.Add(-time.Minute)),
+				Reason:            "KubeletReady",
+				Message:           "kubelet is posting ready status",
+			}},
+			Addresses: []corev1.NodeAddress{{Type: corev1.NodeInternalIP, Address: "10.0.0.1"}},
+			Capacity:  corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("4"), corev1.ResourcePods: resource.MustParse("110")},
+			NodeInfo:  corev1.NodeSystemInfo{KubeletVersion: "v1.35.0", OperatingSystem: "linux", Architecture: "amd64"},
+		},
+	}}},
+}
+
+// describedReplicaSet returns a replica set of the web deployment
+// of the revision running the given replicas.
+func describedReplicaSet(name, revision string, replicas int32) appsv1.ReplicaSet {
+	return appsv1.ReplicaSet{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:            name,
+			Namespace:       "shop",
+			Annotations:     map[string]string{"deployment.kubernetes.io/revision": revision},
+			OwnerReferences: []metav1.OwnerReference{{Kind: "Deployment", Name: "web", Controller: &isController}},
+		},
+		Spec:   appsv1.ReplicaSetSpec{Replicas: &replicas},
+		Status: appsv1.ReplicaSetStatus{Replicas: replicas},
+	}
+}
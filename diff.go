@@ -0,0 +1,224 @@
+package inspector
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"slices"
+	"sort"
+)
+
+// ReportDiff lists changes between two reports.
+type ReportDiff struct {
+	Fields           []FieldChange  `json:"fields,omitempty"`
+	Added            []ObjectChange `json:"added,omitempty"`
+	Removed          []ObjectChange `json:"removed,omitempty"`
+	Modified         []ObjectChange `json:"modified,omitempty"`
+	NewFindings      []Finding      `json:"new_findings,omitempty"`
+	ResolvedFindings []Finding      `json:"resolved_findings,omitempty"`
+}
+
+// FieldChange describes a changed scalar report field, like the K8s version.
+type FieldChange struct {
+	Field string `json:"field"`
+	Old   any    `json:"old"`
+	New   any    `json:"new"`
+}
+
+// ObjectChange describes an object added, removed or modified
+// in a collected list. Fields lists top level fields that changed.
+type ObjectChange struct {
+	Collection string   `json:"collection"`
+	Namespace  string   `json:"namespace,omitempty"`
+	Name       string   `json:"name"`
+	Fields     []string `json:"fields,omitempty"`
+}
+
+// diffIgnoredMetadata lists metadata fields changing
+// on every write, which carry no diagnostic value.
+var diffIgnoredMetadata = map[string]bool{
+	"resourceVersion": true,
+	"managedFields":   true,
+}
+
+// DiffReports compares two reports. Objects in collected lists
+// are matched by namespace and name.
+func DiffReports(older, newer Report) (ReportDiff, error) {
+	oldFields, err := reportFields(older)
+	if err != nil {
+		return ReportDiff{}, err
+	}
+	newFields, err := reportFields(newer)
+	if err != nil {
+		return ReportDiff{}, err
+	}
+
+	var d ReportDiff
+	for _, key := range unionKeys(oldFields, newFields) {
+		if key == "findings" {
+			continue
+		}
+		oldValue, newValue := oldFields[key], newFields[key]
+		if isScalar(oldValue) && isScalar(newValue) {
+			if oldValue != newValue {
+				d.Fields = append(d.Fields, FieldChange{Field: key, Old: oldValue, New: newValue})
+			}
+			continue
+		}
+		oldItems, oldOK := listItems(oldValue)
+		newItems, newOK := listItems(newValue)
+		if !oldOK && !newOK {
+			continue
+		}
+		d.diffItems(key, oldItems, newItems)
+	}
+	d.NewFindings = findingsMissing(newer.Findings, older.Findings)
+	d.ResolvedFindings = findingsMissing(older.Findings, newer.Findings)
+	return d, nil
+}
+
+// diffItems records objects added, removed and modified in a collection.
+func (d *ReportDiff) diffItems(collection string, oldItems, newItems map[objectKey]map[string]any) {
+	for _, key := range sortedObjectKeys(oldItems, newItems) {
+		change := ObjectChange{Collection: collection, Namespace: key.namespace, Name: key.name}
+		oldItem, inOld := oldItems[key]
+		newItem, inNew := newItems[key]
+		switch {
+		case !inOld:
+			d.Added = append(d.Added, change)
+		case !inNew:
+			d.Removed = append(d.Removed, change)
+		default:
+			change.Fields = changedFields(oldItem, newItem)
+			if len(change.Fields) > 0 {
+				d.Modified = append(d.Modified, change)
+			}
+		}
+	}
+}
+
+// objectKey identifies an object in a collected list.
+type objectKey struct {
+	namespace string
+	name      string
+}
+
+// reportFields returns the report as a map of its JSON fields.
+func reportFields(rep Report) (map[string]any, error) {
+	b, err := json.Marshal(rep)
+	if err != nil {
+		return nil, fmt.Errorf("encoding report: %w", err)
+	}
+	var fields map[string]any
+	if err := json.Unmarshal(b, &fields); err != nil {
+		return nil, fmt.Errorf("decoding report: %w", err)
+	}
+	return fields, nil
+}
+
+// isScalar reports whether a JSON value is a string, number or boolean.
+func isScalar(v any) bool {
+	switch v.(type) {
+	case string, float64, bool:
+		return true
+	default:
+		return false
+	}
+}
+
+// listItems returns items of a K8s list keyed by namespace and name.
+// It reports false for values that are not K8s lists.
+func listItems(v any) (map[objectKey]map[string]any, bool) {
+	list, ok := v.(map[string]any)
+	if !ok {
+		return nil, false
+	}
+	raw, ok := list["items"]
+	if !ok {
+		return nil, false
+	}
+	items, _ := raw.([]any)
+	objects := make(map[objectKey]map[string]any, len(items))
+	for _, item := range items {
+		obj, ok := item.(map[string]any)
+		if !ok {
+			continue
+		}
+		meta, _ := obj["metadata"].(map[string]any)
+		namespace, _ := meta["namespace"].(string)
+		name, _ := meta["name"].(string)
+		objects[objectKey{namespace: namespace, name: name}] = obj
+	}
+	return objects, true
+}
+
+// changedFields returns top level object fields that differ.
+// Metadata is compared field by field, ignoring bookkeeping fields.
+func changedFields(older, newer map[string]any) []string {
+	var fields []string
+	for _, key := range unionKeys(older, newer) {
+		if key != "metadata" {
+			if !reflect.DeepEqual(older[key], newer[key]) {
+				fields = append(fields, key)
+			}
+			continue
+		}
+		oldMeta, _ := older[key].(map[string]any)
+		newMeta, _ := newer[key].(map[string]any)
+		for _, metaKey := range unionKeys(oldMeta, newMeta) {
+			if diffIgnoredMetadata[metaKey] {
+				continue
+			}
+			if !reflect.DeepEqual(oldMeta[metaKey], newMeta[metaKey]) {
+				fields = append(fields, "metadata."+metaKey)
+			}
+		}
+	}
+	return fields
+}
+
+// unionKeys returns sorted keys present in any of the maps.
+func unionKeys(a, b map[string]any) []string {
+	keys := make([]string, 0, len(a)+len(b))
+	for k := range a {
+		keys = append(keys, k)
+	}
+	for k := range b {
+		if _, ok := a[k]; !ok {
+			keys = append(keys, k)
+		}
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// sortedObjectKeys returns sorted keys present in any of the item maps.
+func sortedObjectKeys(a, b map[objectKey]map[string]any) []objectKey {
+	keys := make([]objectKey, 0, len(a)+len(b))
+	for k := range a {
+		keys = append(keys, k)
+	}
+	for k := range b {
+		if _, ok := a[k]; !ok {
+			keys = append(keys, k)
+		}
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if keys[i].namespace != keys[j].namespace {
+			return keys[i].namespace < keys[j].namespace
+		}
+		return keys[i].name < keys[j].name
+	})
+	return keys
+}
+
+// findingsMissing returns findings from a that are not in b.
+func findingsMissing(a, b []Finding) []Finding {
+	var missing []Finding
+	for _, f := range a {
+		if !slices.Contains(b, f) {
+			missing = append(missing, f)
+		}
+	}
+	return missing
+}
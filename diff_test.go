@@ -0,0 +1,89 @@
+package inspector_test
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/qba73/inspector"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestDiffReportsListsChangedObjectsFieldsAndFindings(t *testing.T) {
+	t.Parallel()
+
+	older := inspector.Report{
+		K8sVersion: "v1.29.4",
+		Services: &corev1.ServiceList{Items: []corev1.Service{
+			diffService("tea", "100", 80),
+			diffService("coffee", "101", 80),
+		}},
+		Findings: []inspector.Finding{findingPVCPending},
+	}
+	newer := inspector.Report{
+		K8sVersion: "v1.30.1",
+		Services: &corev1.ServiceList{Items: []corev1.Service{
+			diffService("tea", "200", 8080),
+			diffService("coffee", "201", 80),
+			diffService("juice", "202", 80),
+		}},
+		Findings: []inspector.Finding{findingHPAAtMax},
+	}
+
+	got, err := inspector.DiffReports(older, newer)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := inspector.ReportDiff{
+		Fields: []inspector.FieldChange{{Field: "k8s_version", Old: "v1.29.4", New: "v1.30.1"}},
+		Added:  []inspector.ObjectChange{{Collection: "services", Namespace: "default", Name: "juice"}},
+		Modified: []inspector.ObjectChange{
+			{Collection: "services", Namespace: "default", Name: "tea", Fields: []string{"spec"}},
+		},
+		NewFindings:      []inspector.Finding{findingHPAAtMax},
+		ResolvedFindings: []inspector.Finding{findingPVCPending},
+	}
+	if !cmp.Equal(want, got) {
+		t.Error(cmp.Diff(want, got))
+	}
+}
+
+func TestDiffReportsReportsRemovedObjectsFromMissingLists(t *testing.T) {
+	t.Parallel()
+
+	older := inspector.Report{
+		Services: &corev1.ServiceList{Items: []corev1.Service{diffService("tea", "100", 80)}},
+	}
+	got, err := inspector.DiffReports(older, inspector.Report{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []inspector.ObjectChange{{Collection: "services", Namespace: "default", Name: "tea"}}
+	if !cmp.Equal(want, got.Removed) {
+		t.Error(cmp.Diff(want, got.Removed))
+	}
+}
+
+func diffService(name, resourceVersion string, port int32) corev1.Service {
+	return corev1.Service{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "default", ResourceVersion: resourceVersion},
+		Spec:       corev1.ServiceSpec{Ports: []corev1.ServicePort{{Port: port}}},
+	}
+}
+
+var (
+	findingPVCPending = inspector.Finding{
+		Check:    inspector.CheckPVCPending,
+		Severity: inspector.SeverityWarning,
+		Object:   inspector.ObjectRef{Kind: "PersistentVolumeClaim", Namespace: "default", Name: "data"},
+		Message:  "claim is pending",
+	}
+
+	findingHPAAtMax = inspector.Finding{
+		Check:    inspector.CheckHPAAtMaxReplicas,
+		Severity: inspector.SeverityWarning,
+		Object:   inspector.ObjectRef{Kind: "HorizontalPodAutoscaler", Namespace: "default", Name: "frontend"},
+		Message:  "running at max replicas",
+	}
+)
@@ -0,0 +1,215 @@
+package inspector
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+)
+
+// Exit codes returned by Main.
+const (
+	ExitOK           = 0
+	ExitInternal     = 1
+	ExitConfig       = 2
+	ExitAuth         = 3
+	ExitConnectivity = 4
+	ExitPartial      = 5
+	ExitFindings     = 6
+)
+
+// Error kinds reported alongside exit codes.
+const (
+	KindConfig       = "config"
+	KindAuth         = "auth"
+	KindConnectivity = "connectivity"
+	KindPartial      = "partial_collection"
+	KindFindings     = "findings"
+	KindInternal     = "internal"
+)
+
+// ConfigError reports invalid flags or a kubeconfig
+// that cannot be loaded.
+type ConfigError struct {
+	Err error
+}
+
+func (e *ConfigError) Error() string {
+	return fmt.Sprintf("configuration: %v", e.Err)
+}
+
+func (e *ConfigError) Unwrap() error {
+	return e.Err
+}
+
+// CollectorError reports a failure of a single collector.
+type CollectorError struct {
+	Collector string
+	Resource  string
+	Err       error
+}
+
+// newCollectorError wraps err with the collector name and its resource.
+func newCollectorError(collector string, err error) *CollectorError {
+	return &CollectorError{
+		Collector: collector,
+		Resource:  collectorResource(collector),
+		Err:       err,
+	}
+}
+
+func (e *CollectorError) Error() string {
+	return fmt.Sprintf("collector %s: %s: %v", e.Collector, e.Resource, e.Err)
+}
+
+func (e *CollectorError) Unwrap() error {
+	return e.Err
+}
+
+// MarshalJSON encodes the collector error as a JSON object.
+func (e *CollectorError) MarshalJSON() ([]byte, error) {
+	return json.Marshal(struct {
+		Collector string `json:"collector"`
+		Resource  string `json:"resource"`
+		Kind      string `json:"kind"`
+		Error     string `json:"error"`
+	}{
+		Collector: e.Collector,
+		Resource:  e.Resource,
+		Kind:      ErrorKind(e.Err),
+		Error:     e.Err.Error(),
+	})
+}
+
+// UnmarshalJSON decodes a collector error saved in a report.
+func (e *CollectorError) UnmarshalJSON(b []byte) error {
+	var v struct {
+		Collector string `json:"collector"`
+		Resource  string `json:"resource"`
+		Error     string `json:"error"`
+	}
+	if err := json.Unmarshal(b, &v); err != nil {
+		return err
+	}
+	*e = CollectorError{Collector: v.Collector, Resource: v.Resource, Err: errors.New(v.Error)}
+	return nil
+}
+
+// PartialCollectionError reports collectors that failed
+// while the rest of the report was collected.
+type PartialCollectionError struct {
+	Total  int
+	Errors []*CollectorError
+}
+
+func (e *PartialCollectionError) Error() string {
+	msgs := make([]string, 0, len(e.Errors))
+	for _, err := range e.Errors {
+		msgs = append(msgs, err.Error())
+	}
+	return fmt.Sprintf("%d of %d collectors failed: %s", len(e.Errors), e.Total, strings.Join(msgs, "; "))
+}
+
+func (e *PartialCollectionError) Unwrap() []error {
+	errs := make([]error, 0, len(e.Errors))
+	for _, err := range e.Errors {
+		errs = append(errs, err)
+	}
+	return errs
+}
+
+// FindingsError reports findings at or above a severity
+// that should fail the command, like in CI pipelines.
+type FindingsError struct {
+	Severity string
+	Findings []Finding
+}
+
+func (e *FindingsError) Error() string {
+	return fmt.Sprintf("%d findings with severity %s or higher", len(e.Findings), e.Severity)
+}
+
+// ErrorKind classifies an error as a configuration, authentication,
+// connectivity, partial collection, findings or internal failure.
+// A partial collection in which every collector failed
+// is classified by the cause of its first failure.
+func ErrorKind(err error) string {
+	var partial *PartialCollectionError
+	if errors.As(err, &partial) {
+		if len(partial.Errors) < partial.Total || len(partial.Errors) == 0 {
+			return KindPartial
+		}
+		return ErrorKind(partial.Errors[0].Err)
+	}
+	var configErr *ConfigError
+	if errors.As(err, &configErr) {
+		return KindConfig
+	}
+	var findingsErr *FindingsError
+	if errors.As(err, &findingsErr) {
+		return KindFindings
+	}
+	if apierrors.IsUnauthorized(err) || apierrors.IsForbidden(err) {
+		return KindAuth
+	}
+	var uploadErr *UploadError
+	if errors.As(err, &uploadErr) {
+		switch code := uploadErr.StatusCode; {
+		case code == http.StatusUnauthorized || code == http.StatusForbidden:
+			return KindAuth
+		case code == http.StatusTooManyRequests || code >= 500:
+			return KindConnectivity
+		default:
+			return KindConfig
+		}
+	}
+	if isConnectivityError(err) {
+		return KindConnectivity
+	}
+	return KindInternal
+}
+
+// isConnectivityError reports whether err is caused by
+// an unreachable or unavailable API server.
+func isConnectivityError(err error) bool {
+	if errors.Is(err, context.DeadlineExceeded) {
+		return true
+	}
+	if apierrors.IsServerTimeout(err) || apierrors.IsTimeout(err) ||
+		apierrors.IsServiceUnavailable(err) || apierrors.IsTooManyRequests(err) {
+		return true
+	}
+	var urlErr *url.Error
+	if errors.As(err, &urlErr) {
+		return true
+	}
+	var netErr net.Error
+	return errors.As(err, &netErr)
+}
+
+// ExitCode returns the program exit code for err.
+func ExitCode(err error) int {
+	if err == nil {
+		return ExitOK
+	}
+	switch ErrorKind(err) {
+	case KindConfig:
+		return ExitConfig
+	case KindAuth:
+		return ExitAuth
+	case KindConnectivity:
+		return ExitConnectivity
+	case KindPartial:
+		return ExitPartial
+	case KindFindings:
+		return ExitFindings
+	default:
+		return ExitInternal
+	}
+}
@@ -0,0 +1,117 @@
+package inspector_test
+
+import (
+	"context"
+	"errors"
+	"net/url"
+	"syscall"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/qba73/inspector"
+
+	apiextfake "k8s.io/apiextensions-apiserver/pkg/client/clientset/clientset/fake"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	k8sruntime "k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	k8stesting "k8s.io/client-go/testing"
+)
+
+func TestExitCodeClassifiesErrors(t *testing.T) {
+	t.Parallel()
+
+	podsResource := schema.GroupResource{Resource: "pods"}
+	tests := []struct {
+		name string
+		err  error
+		want int
+	}{
+		{name: "no error", err: nil, want: inspector.ExitOK},
+		{name: "config", err: &inspector.ConfigError{Err: errors.New("no kubeconfig")}, want: inspector.ExitConfig},
+		{name: "forbidden", err: apierrors.NewForbidden(podsResource, "", errors.New("denied")), want: inspector.ExitAuth},
+		{name: "unauthorized", err: apierrors.NewUnauthorized("expired token"), want: inspector.ExitAuth},
+		{name: "connection refused", err: &url.Error{Op: "Get", URL: "https://127.0.0.1:6443", Err: syscall.ECONNREFUSED}, want: inspector.ExitConnectivity},
+		{name: "service unavailable", err: apierrors.NewServiceUnavailable("etcd down"), want: inspector.ExitConnectivity},
+		{name: "internal", err: errors.New("boom"), want: inspector.ExitInternal},
+		{name: "findings", err: &inspector.FindingsError{Severity: inspector.SeverityCritical}, want: inspector.ExitFindings},
+		{name: "upload forbidden", err: &inspector.UploadError{StatusCode: 403}, want: inspector.ExitAuth},
+		{name: "upload bucket missing", err: &inspector.UploadError{StatusCode: 404}, want: inspector.ExitConfig},
+		{name: "upload server error", err: &inspector.UploadError{StatusCode: 503}, want: inspector.ExitConnectivity},
+		{
+			name: "partial collection",
+			err: &inspector.PartialCollectionError{
+				Total:  3,
+				Errors: []*inspector.CollectorError{{Collector: "pods", Resource: "pods", Err: apierrors.NewForbidden(podsResource, "", errors.New("denied"))}},
+			},
+			want: inspector.ExitPartial,
+		},
+		{
+			name: "all collectors failed",
+			err: &inspector.PartialCollectionError{
+				Total:  1,
+				Errors: []*inspector.CollectorError{{Collector: "pods", Resource: "pods", Err: apierrors.NewUnauthorized("expired token")}},
+			},
+			want: inspector.ExitAuth,
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			if got := inspector.ExitCode(tc.err); got != tc.want {
+				t.Errorf("want exit code %d, got %d", tc.want, got)
+			}
+		})
+	}
+}
+
+func TestInspectorReportRecordsFailedCollectorsAndContinues(t *testing.T) {
+	t.Parallel()
+
+	client := newTestClientset(kubeSystemNameSpace, clusterNode1, teaServiceDefaultNS)
+	client.PrependReactor("list", "pods", func(action k8stesting.Action) (bool, k8sruntime.Object, error) {
+		return true, nil, apierrors.NewForbidden(schema.GroupResource{Resource: "pods"}, "", errors.New("denied"))
+	})
+	i := &inspector.Inspector{K8sClient: client, CRDClient: apiextfake.NewSimpleClientset()}
+
+	report, err := i.Report(context.Background(), "default")
+	var partial *inspector.PartialCollectionError
+	if !errors.As(err, &partial) {
+		t.Fatalf("want partial collection error, got %v", err)
+	}
+	var failed []string
+	for _, e := range report.Errors {
+		failed = append(failed, e.Collector)
+	}
+	want := []string{"pods", "pod_logs"}
+	if !cmp.Equal(want, failed) {
+		t.Error(cmp.Diff(want, failed))
+	}
+	if report.Errors[0].Resource != "pods" {
+		t.Errorf("want resource pods, got %s", report.Errors[0].Resource)
+	}
+	if report.Services == nil || len(report.Services.Items) != 1 {
+		t.Errorf("want services collected despite failed collectors, got %+v", report.Services)
+	}
+	if inspector.ExitCode(err) != inspector.ExitPartial {
+		t.Errorf("want exit code %d, got %d", inspector.ExitPartial, inspector.ExitCode(err))
+	}
+}
+
+func TestInspectorReportFailsWhenClusterIsUnreachable(t *testing.T) {
+	t.Parallel()
+
+	client := newTestClientset()
+	client.PrependReactor("get", "version", func(action k8stesting.Action) (bool, k8sruntime.Object, error) {
+		return true, nil, &url.Error{Op: "Get", URL: "https://127.0.0.1:6443/version", Err: syscall.ECONNREFUSED}
+	})
+	i := &inspector.Inspector{K8sClient: client}
+
+	_, err := i.Report(context.Background(), "default")
+	var collectorErr *inspector.CollectorError
+	if !errors.As(err, &collectorErr) || collectorErr.Collector != "k8s_version" {
+		t.Fatalf("want k8s_version collector error, got %v", err)
+	}
+	if inspector.ExitCode(err) != inspector.ExitConnectivity {
+		t.Errorf("want exit code %d, got %d", inspector.ExitConnectivity, inspector.ExitCode(err))
+	}
+}
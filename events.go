@@ -0,0 +1,407 @@
+package inspector
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	eventsv1 "k8s.io/api/events/v1"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// Timeline entry kinds.
+const (
+	EntryEvent      = "Event"
+	EntryPodRestart = "PodRestart"
+	EntryRollout    = "Rollout"
+)
+
+// defaultCorrelationWindow is the time window around a pod restart
+// or a rollout in which events are considered related.
+const defaultCorrelationWindow = 5 * time.Minute
+
+// EventsV1 returns [events] from the events.k8s.io/v1 API for a given namespace.
+//
+// [events]: https://kubernetes.io/docs/reference/kubernetes-api/cluster-resources/event-v1/
+func (i *Inspector) EventsV1(ctx context.Context, namespace string) (*eventsv1.EventList, error) {
+	events, err := listPages(ctx, i, "events_v1", i.K8sClient.EventsV1().Events(namespace).List)
+	if err != nil {
+		return nil, err
+	}
+	return events, nil
+}
+
+// ObjectRef identifies a K8s object an event or a timeline entry refers to.
+type ObjectRef struct {
+	Kind      string `json:"kind"`
+	Namespace string `json:"namespace,omitempty"`
+	Name      string `json:"name"`
+}
+
+// String returns the object reference in kind/namespace/name form.
+func (o ObjectRef) String() string {
+	if o.Namespace == "" {
+		return fmt.Sprintf("%s/%s", o.Kind, o.Name)
+	}
+	return fmt.Sprintf("%s/%s/%s", o.Kind, o.Namespace, o.Name)
+}
+
+// TimelineEntry represents a single point on the namespace timeline.
+// It is either a deduplicated event, a pod container restart
+// or a Deployment rollout.
+type TimelineEntry struct {
+	Entry     string    `json:"entry"`
+	Object    ObjectRef `json:"object"`
+	Type      string    `json:"type,omitempty"`
+	Reason    string    `json:"reason,omitempty"`
+	Message   string    `json:"message,omitempty"`
+	Count     int32     `json:"count"`
+	FirstSeen time.Time `json:"first_seen"`
+	LastSeen  time.Time `json:"last_seen"`
+}
+
+// EventGroup holds timeline entries that refer to the same object.
+type EventGroup struct {
+	Object  ObjectRef       `json:"object"`
+	Entries []TimelineEntry `json:"entries"`
+}
+
+// Correlation links a pod restart or a rollout with events
+// recorded for the related objects around the same time.
+type Correlation struct {
+	Anchor  TimelineEntry   `json:"anchor"`
+	Related []TimelineEntry `json:"related"`
+}
+
+// EventTimeline is an ordered story of what happened in a namespace.
+type EventTimeline struct {
+	Entries      []TimelineEntry `json:"entries"`
+	Groups       []EventGroup    `json:"groups"`
+	Correlations []Correlation   `json:"correlations"`
+}
+
+// AnalyzeEvents builds an event timeline from core and events.k8s.io/v1
+// events. Events are deduplicated, sorted by the time they were last seen,
+// grouped by the involved object and correlated with pod restarts and
+// Deployment rollouts reconstructed from pods and replica sets.
+func AnalyzeEvents(events *corev1.EventList, eventsV1 *eventsv1.EventList, pods *corev1.PodList, replicaSets *appsv1.ReplicaSetList) EventTimeline {
+	evs := dedupEvents(events, eventsV1)
+	restarts := podRestarts(pods)
+	rollouts := deploymentRollouts(replicaSets)
+
+	entries := make([]TimelineEntry, 0, len(evs)+len(restarts)+len(rollouts))
+	entries = append(entries, evs...)
+	entries = append(entries, restarts...)
+	entries = append(entries, rollouts...)
+	sortEntries(entries)
+
+	return EventTimeline{
+		Entries:      entries,
+		Groups:       groupEntries(entries),
+		Correlations: correlate(evs, restarts, rollouts, relatedObjects(pods, replicaSets)),
+	}
+}
+
+// eventKey identifies events describing the same occurrence.
+type eventKey struct {
+	object  ObjectRef
+	typ     string
+	reason  string
+	message string
+}
+
+// dedupEvents merges core and events.k8s.io/v1 events into timeline entries.
+// The same event is visible through both APIs, so events are first
+// deduplicated by UID and then by object, reason and message
+// with their counts summed up.
+func dedupEvents(events *corev1.EventList, eventsV1 *eventsv1.EventList) []TimelineEntry {
+	seenUIDs := map[string]bool{}
+	byKey := map[eventKey]int{}
+	entries := []TimelineEntry{}
+
+	add := func(uid string, e TimelineEntry) {
+		if uid != "" {
+			if seenUIDs[uid] {
+				return
+			}
+			seenUIDs[uid] = true
+		}
+		k := eventKey{object: e.Object, typ: e.Type, reason: e.Reason, message: e.Message}
+		idx, ok := byKey[k]
+		if !ok {
+			byKey[k] = len(entries)
+			entries = append(entries, e)
+			return
+		}
+		existing := &entries[idx]
+		existing.Count += e.Count
+		if e.FirstSeen.Before(existing.FirstSeen) {
+			existing.FirstSeen = e.FirstSeen
+		}
+		if e.LastSeen.After(existing.LastSeen) {
+			existing.LastSeen = e.LastSeen
+		}
+	}
+
+	if events != nil {
+		for _, e := range events.Items {
+			add(string(e.UID), coreEventEntry(e))
+		}
+	}
+	if eventsV1 != nil {
+		for _, e := range eventsV1.Items {
+			add(string(e.UID), eventV1Entry(e))
+		}
+	}
+	return entries
+}
+
+// coreEventEntry converts a core/v1 event to a timeline entry.
+func coreEventEntry(e corev1.Event) TimelineEntry {
+	count := e.Count
+	first := firstTime(e.FirstTimestamp.Time, e.EventTime.Time, e.CreationTimestamp.Time)
+	last := firstTime(e.LastTimestamp.Time, e.EventTime.Time, first)
+	if e.Series != nil {
+		count = e.Series.Count
+		last = firstTime(e.Series.LastObservedTime.Time, last)
+	}
+	namespace := e.InvolvedObject.Namespace
+	if namespace == "" {
+		namespace = e.Namespace
+	}
+	return TimelineEntry{
+		Entry: EntryEvent,
+		Object: ObjectRef{
+			Kind:      e.InvolvedObject.Kind,
+			Namespace: namespace,
+			Name:      e.InvolvedObject.Name,
+		},
+		Type:      e.Type,
+		Reason:    e.Reason,
+		Message:   e.Message,
+		Count:     max(count, 1),
+		FirstSeen: first,
+		LastSeen:  last,
+	}
+}
+
+// eventV1Entry converts an events.k8s.io/v1 event to a timeline entry.
+func eventV1Entry(e eventsv1.Event) TimelineEntry {
+	count := e.DeprecatedCount
+	first := firstTime(e.EventTime.Time, e.DeprecatedFirstTimestamp.Time, e.CreationTimestamp.Time)
+	last := firstTime(e.DeprecatedLastTimestamp.Time, first)
+	if e.Series != nil {
+		count = e.Series.Count
+		last = firstTime(e.Series.LastObservedTime.Time, last)
+	}
+	namespace := e.Regarding.Namespace
+	if namespace == "" {
+		namespace = e.Namespace
+	}
+	return TimelineEntry{
+		Entry: EntryEvent,
+		Object: ObjectRef{
+			Kind:      e.Regarding.Kind,
+			Namespace: namespace,
+			Name:      e.Regarding.Name,
+		},
+		Type:      e.Type,
+		Reason:    e.Reason,
+		Message:   e.Note,
+		Count:     max(count, 1),
+		FirstSeen: first,
+		LastSeen:  last,
+	}
+}
+
+// firstTime returns the first non-zero time from the given times.
+func firstTime(times ...time.Time) time.Time {
+	for _, t := range times {
+		if !t.IsZero() {
+			return t
+		}
+	}
+	return time.Time{}
+}
+
+// podRestarts returns timeline entries for containers that were restarted.
+func podRestarts(pods *corev1.PodList) []TimelineEntry {
+	if pods == nil {
+		return nil
+	}
+	entries := []TimelineEntry{}
+	for _, pod := range pods.Items {
+		statuses := append(append([]corev1.ContainerStatus{}, pod.Status.InitContainerStatuses...), pod.Status.ContainerStatuses...)
+		for _, cs := range statuses {
+			if cs.RestartCount == 0 {
+				continue
+			}
+			e := TimelineEntry{
+				Entry:  EntryPodRestart,
+				Object: ObjectRef{Kind: "Pod", Namespace: pod.Namespace, Name: pod.Name},
+				Type:   corev1.EventTypeWarning,
+				Reason: "Restarted",
+				Count:  cs.RestartCount,
+			}
+			if t := cs.LastTerminationState.Terminated; t != nil {
+				e.Reason = t.Reason
+				e.Message = fmt.Sprintf("container %s restarted (exit code %d)", cs.Name, t.ExitCode)
+				e.FirstSeen = t.FinishedAt.Time
+				e.LastSeen = t.FinishedAt.Time
+			} else {
+				e.Message = fmt.Sprintf("container %s restarted", cs.Name)
+			}
+			entries = append(entries, e)
+		}
+	}
+	return entries
+}
+
+// deploymentRollouts reconstructs Deployment rollouts from replica sets
+// owned by Deployments. Each replica set represents one revision.
+func deploymentRollouts(replicaSets *appsv1.ReplicaSetList) []TimelineEntry {
+	if replicaSets == nil {
+		return nil
+	}
+	entries := []TimelineEntry{}
+	for _, rs := range replicaSets.Items {
+		owner := controllerOf(rs.OwnerReferences)
+		if owner == nil || owner.Kind != "Deployment" {
+			continue
+		}
+		msg := fmt.Sprintf("rolled out replica set %s", rs.Name)
+		if rev := rs.Annotations["deployment.kubernetes.io/revision"]; rev != "" {
+			msg = fmt.Sprintf("rolled out revision %s (replica set %s)", rev, rs.Name)
+		}
+		entries = append(entries, TimelineEntry{
+			Entry:     EntryRollout,
+			Object:    ObjectRef{Kind: "Deployment", Namespace: rs.Namespace, Name: owner.Name},
+			Type:      corev1.EventTypeNormal,
+			Reason:    "Rollout",
+			Message:   msg,
+			Count:     1,
+			FirstSeen: rs.CreationTimestamp.Time,
+			LastSeen:  rs.CreationTimestamp.Time,
+		})
+	}
+	return entries
+}
+
+// controllerOf returns the controlling owner reference, if any.
+func controllerOf(refs []metav1.OwnerReference) *metav1.OwnerReference {
+	for i := range refs {
+		if refs[i].Controller != nil && *refs[i].Controller {
+			return &refs[i]
+		}
+	}
+	return nil
+}
+
+// sortEntries orders entries by the time they were last seen.
+// Entries without a timestamp are placed at the beginning.
+func sortEntries(entries []TimelineEntry) {
+	sort.SliceStable(entries, func(a, b int) bool {
+		return entries[a].LastSeen.Before(entries[b].LastSeen)
+	})
+}
+
+// groupEntries groups timeline entries by the object they refer to.
+func groupEntries(entries []TimelineEntry) []EventGroup {
+	idx := map[ObjectRef]int{}
+	groups := []EventGroup{}
+	for _, e := range entries {
+		i, ok := idx[e.Object]
+		if !ok {
+			i = len(groups)
+			idx[e.Object] = i
+			groups = append(groups, EventGroup{Object: e.Object})
+		}
+		groups[i].Entries = append(groups[i].Entries, e)
+	}
+	sort.SliceStable(groups, func(a, b int) bool {
+		return groups[a].Object.String() < groups[b].Object.String()
+	})
+	return groups
+}
+
+// relatedObjects maps a pod or a Deployment to the set of objects whose
+// events describe it: pods are related to their replica set and Deployment,
+// Deployments to their replica sets and pods.
+func relatedObjects(pods *corev1.PodList, replicaSets *appsv1.ReplicaSetList) map[ObjectRef][]ObjectRef {
+	rsToDeploy := map[ObjectRef]ObjectRef{}
+	related := map[ObjectRef][]ObjectRef{}
+	if replicaSets != nil {
+		for _, rs := range replicaSets.Items {
+			rsRef := ObjectRef{Kind: "ReplicaSet", Namespace: rs.Namespace, Name: rs.Name}
+			owner := controllerOf(rs.OwnerReferences)
+			if owner == nil || owner.Kind != "Deployment" {
+				continue
+			}
+			deploy := ObjectRef{Kind: "Deployment", Namespace: rs.Namespace, Name: owner.Name}
+			rsToDeploy[rsRef] = deploy
+			related[deploy] = append(related[deploy], rsRef)
+		}
+	}
+	if pods != nil {
+		for _, pod := range pods.Items {
+			podRef := ObjectRef{Kind: "Pod", Namespace: pod.Namespace, Name: pod.Name}
+			owner := controllerOf(pod.OwnerReferences)
+			if owner == nil {
+				continue
+			}
+			ownerRef := ObjectRef{Kind: owner.Kind, Namespace: pod.Namespace, Name: owner.Name}
+			related[podRef] = append(related[podRef], ownerRef)
+			if deploy, ok := rsToDeploy[ownerRef]; ok {
+				related[podRef] = append(related[podRef], deploy)
+				related[deploy] = append(related[deploy], podRef)
+			}
+		}
+	}
+	return related
+}
+
+// correlate links pod restarts and rollouts with events recorded
+// for the same or related objects within the correlation window.
+func correlate(events, restarts, rollouts []TimelineEntry, related map[ObjectRef][]ObjectRef) []Correlation {
+	anchors := make([]TimelineEntry, 0, len(restarts)+len(rollouts))
+	anchors = append(anchors, restarts...)
+	anchors = append(anchors, rollouts...)
+	sortEntries(anchors)
+
+	correlations := []Correlation{}
+	for _, a := range anchors {
+		// Restarts of containers without a last termination state carry
+		// no time, so there is no window to correlate events within.
+		if a.LastSeen.IsZero() {
+			continue
+		}
+		objects := map[ObjectRef]bool{a.Object: true}
+		for _, o := range related[a.Object] {
+			objects[o] = true
+		}
+		c := Correlation{Anchor: a}
+		for _, e := range events {
+			if !objects[e.Object] {
+				continue
+			}
+			if !withinWindow(e, a.LastSeen, defaultCorrelationWindow) {
+				continue
+			}
+			c.Related = append(c.Related, e)
+		}
+		sortEntries(c.Related)
+		correlations = append(correlations, c)
+	}
+	return correlations
+}
+
+// withinWindow reports whether the event was observed
+// within the window around the given time.
+func withinWindow(e TimelineEntry, t time.Time, window time.Duration) bool {
+	from, to := t.Add(-window), t.Add(window)
+	return !e.LastSeen.Before(from) && !e.FirstSeen.After(to)
+}
@@ -0,0 +1,310 @@
+package inspector_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/qba73/inspector"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	eventsv1 "k8s.io/api/events/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestInspectorListsEventsV1InAGivenNamespace(t *testing.T) {
+	t.Parallel()
+
+	i := newTestInspector(eventV1NginxIngress)
+	got, err := i.EventsV1(context.Background(), "nginx-ingress")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got.Items) != 1 {
+		t.Fatalf("want 1 event, got %d", len(got.Items))
+	}
+	if got.Items[0].Reason != "BackOff" {
+		t.Errorf("want reason BackOff, got %s", got.Items[0].Reason)
+	}
+}
+
+func TestAnalyzeEventsDeduplicatesEventsSeenThroughBothAPIs(t *testing.T) {
+	t.Parallel()
+
+	core := &corev1.EventList{Items: []corev1.Event{*coreEventBackOff, *coreEventBackOffRepeated}}
+	v1 := &eventsv1.EventList{Items: []eventsv1.Event{*eventV1NginxIngress}}
+
+	got := inspector.AnalyzeEvents(core, v1, nil, nil)
+	if len(got.Entries) != 1 {
+		t.Fatalf("want 1 deduplicated entry, got %d: %+v", len(got.Entries), got.Entries)
+	}
+	e := got.Entries[0]
+	if e.Count != 5 {
+		t.Errorf("want summed count 5, got %d", e.Count)
+	}
+	if !e.FirstSeen.Equal(timelineStart) {
+		t.Errorf("want first seen %v, got %v", timelineStart, e.FirstSeen)
+	}
+	if !e.LastSeen.Equal(timelineStart.Add(3 * time.Minute)) {
+		t.Errorf("want last seen %v, got %v", timelineStart.Add(3*time.Minute), e.LastSeen)
+	}
+}
+
+func TestAnalyzeEventsSortsEntriesIntoATimeline(t *testing.T) {
+	t.Parallel()
+
+	core := &corev1.EventList{Items: []corev1.Event{*coreEventScaled, *coreEventBackOff}}
+	pods := &corev1.PodList{Items: []corev1.Pod{*restartedPod}}
+	rss := &appsv1.ReplicaSetList{Items: []appsv1.ReplicaSet{*controllerReplicaSet}}
+
+	got := inspector.AnalyzeEvents(core, nil, pods, rss)
+	var kinds []string
+	for _, e := range got.Entries {
+		kinds = append(kinds, e.Entry+":"+e.Reason)
+	}
+	want := []string{
+		"Rollout:Rollout",
+		"Event:ScalingReplicaSet",
+		"Event:BackOff",
+		"PodRestart:OOMKilled",
+	}
+	if !cmp.Equal(want, kinds) {
+		t.Error(cmp.Diff(want, kinds))
+	}
+}
+
+func TestAnalyzeEventsGroupsEntriesByInvolvedObject(t *testing.T) {
+	t.Parallel()
+
+	core := &corev1.EventList{Items: []corev1.Event{*coreEventScaled, *coreEventBackOff}}
+	pods := &corev1.PodList{Items: []corev1.Pod{*restartedPod}}
+
+	got := inspector.AnalyzeEvents(core, nil, pods, nil)
+	var objects []string
+	for _, g := range got.Groups {
+		objects = append(objects, g.Object.String())
+	}
+	want := []string{
+		"Deployment/nginx-ingress/nginx-ingress",
+		"Pod/nginx-ingress/nginx-ingress-7d9c6b9d4-xk2lp",
+	}
+	if !cmp.Equal(want, objects) {
+		t.Error(cmp.Diff(want, objects))
+	}
+	if len(got.Groups[1].Entries) != 2 {
+		t.Errorf("want 2 entries for the pod, got %d", len(got.Groups[1].Entries))
+	}
+}
+
+func TestAnalyzeEventsCorrelatesPodRestartWithPodAndDeploymentEvents(t *testing.T) {
+	t.Parallel()
+
+	core := &corev1.EventList{Items: []corev1.Event{*coreEventScaled, *coreEventBackOff, *coreEventOld}}
+	pods := &corev1.PodList{Items: []corev1.Pod{*restartedPod}}
+	rss := &appsv1.ReplicaSetList{Items: []appsv1.ReplicaSet{*controllerReplicaSet}}
+
+	got := inspector.AnalyzeEvents(core, nil, pods, rss)
+	var restart *inspector.Correlation
+	for idx := range got.Correlations {
+		if got.Correlations[idx].Anchor.Entry == inspector.EntryPodRestart {
+			restart = &got.Correlations[idx]
+		}
+	}
+	if restart == nil {
+		t.Fatal("want pod restart correlation, got none")
+	}
+	var reasons []string
+	for _, e := range restart.Related {
+		reasons = append(reasons, e.Reason)
+	}
+	want := []string{"ScalingReplicaSet", "BackOff"}
+	if !cmp.Equal(want, reasons) {
+		t.Error(cmp.Diff(want, reasons))
+	}
+}
+
+func TestAnalyzeEventsSkipsRestartsWithoutTerminationTime(t *testing.T) {
+	t.Parallel()
+
+	pod := restartedPod.DeepCopy()
+	pod.Status.ContainerStatuses[0].LastTerminationState = corev1.ContainerState{}
+	core := &corev1.EventList{Items: []corev1.Event{*coreEventBackOff, *coreEventOld}}
+
+	got := inspector.AnalyzeEvents(core, nil, &corev1.PodList{Items: []corev1.Pod{*pod}}, nil)
+	for _, c := range got.Correlations {
+		if c.Anchor.Entry == inspector.EntryPodRestart {
+			t.Errorf("want no correlation of a restart without time, got %+v", c)
+		}
+	}
+}
+
+func TestAnalyzeEventsIgnoresOwnersNotControllingPods(t *testing.T) {
+	t.Parallel()
+
+	pod := restartedPod.DeepCopy()
+	pod.OwnerReferences[0].Controller = nil
+	core := &corev1.EventList{Items: []corev1.Event{*coreEventScaled, *coreEventBackOff}}
+	rss := &appsv1.ReplicaSetList{Items: []appsv1.ReplicaSet{*controllerReplicaSet}}
+
+	got := inspector.AnalyzeEvents(core, nil, &corev1.PodList{Items: []corev1.Pod{*pod}}, rss)
+	var reasons []string
+	for _, c := range got.Correlations {
+		if c.Anchor.Entry != inspector.EntryPodRestart {
+			continue
+		}
+		for _, e := range c.Related {
+			reasons = append(reasons, e.Reason)
+		}
+	}
+	want := []string{"BackOff"}
+	if !cmp.Equal(want, reasons) {
+		t.Error(cmp.Diff(want, reasons))
+	}
+}
+
+var timelineStart = time.Date(2024, 3, 1, 10, 0, 0, 0, time.UTC)
+
+// Events and workloads used for testing the events timeline.
+var (
+	controllerTrue = true
+
+	coreEventBackOff = &corev1.Event{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "nginx-ingress-7d9c6b9d4-xk2lp.backoff",
+			Namespace: "nginx-ingress",
+			UID:       "e1",
+		},
+		InvolvedObject: corev1.ObjectReference{
+			Kind:      "Pod",
+			Namespace: "nginx-ingress",
+			Name:      "nginx-ingress-7d9c6b9d4-xk2lp",
+		},
+		Type:           corev1.EventTypeWarning,
+		Reason:         "BackOff",
+		Message:        "Back-off restarting failed container",
+		Count:          3,
+		FirstTimestamp: metav1.NewTime(timelineStart),
+		LastTimestamp:  metav1.NewTime(timelineStart.Add(2 * time.Minute)),
+	}
+
+	coreEventBackOffRepeated = &corev1.Event{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "nginx-ingress-7d9c6b9d4-xk2lp.backoff2",
+			Namespace: "nginx-ingress",
+			UID:       "e2",
+		},
+		InvolvedObject: corev1.ObjectReference{
+			Kind:      "Pod",
+			Namespace: "nginx-ingress",
+			Name:      "nginx-ingress-7d9c6b9d4-xk2lp",
+		},
+		Type:           corev1.EventTypeWarning,
+		Reason:         "BackOff",
+		Message:        "Back-off restarting failed container",
+		Count:          2,
+		FirstTimestamp: metav1.NewTime(timelineStart.Add(time.Minute)),
+		LastTimestamp:  metav1.NewTime(timelineStart.Add(3 * time.Minute)),
+	}
+
+	coreEventScaled = &corev1.Event{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "nginx-ingress.scaled",
+			Namespace: "nginx-ingress",
+			UID:       "e3",
+		},
+		InvolvedObject: corev1.ObjectReference{
+			Kind:      "Deployment",
+			Namespace: "nginx-ingress",
+			Name:      "nginx-ingress",
+		},
+		Type:           corev1.EventTypeNormal,
+		Reason:         "ScalingReplicaSet",
+		Message:        "Scaled up replica set nginx-ingress-7d9c6b9d4 to 1",
+		Count:          1,
+		FirstTimestamp: metav1.NewTime(timelineStart.Add(-time.Minute)),
+		LastTimestamp:  metav1.NewTime(timelineStart.Add(-time.Minute)),
+	}
+
+	coreEventOld = &corev1.Event{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "nginx-ingress-7d9c6b9d4-xk2lp.pulled",
+			Namespace: "nginx-ingress",
+			UID:       "e4",
+		},
+		InvolvedObject: corev1.ObjectReference{
+			Kind:      "Pod",
+			Namespace: "nginx-ingress",
+			Name:      "nginx-ingress-7d9c6b9d4-xk2lp",
+		},
+		Type:           corev1.EventTypeNormal,
+		Reason:         "Pulled",
+		Message:        "Container image already present on machine",
+		Count:          1,
+		FirstTimestamp: metav1.NewTime(timelineStart.Add(-time.Hour)),
+		LastTimestamp:  metav1.NewTime(timelineStart.Add(-time.Hour)),
+	}
+
+	eventV1NginxIngress = &eventsv1.Event{
+		TypeMeta: metav1.TypeMeta{
+			Kind:       "Event",
+			APIVersion: "events.k8s.io/v1",
+		},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "nginx-ingress-7d9c6b9d4-xk2lp.backoff",
+			Namespace: "nginx-ingress",
+			UID:       "e1",
+		},
+		Regarding: corev1.ObjectReference{
+			Kind:      "Pod",
+			Namespace: "nginx-ingress",
+			Name:      "nginx-ingress-7d9c6b9d4-xk2lp",
+		},
+		Type:      corev1.EventTypeWarning,
+		Reason:    "BackOff",
+		Note:      "Back-off restarting failed container",
+		EventTime: metav1.NewMicroTime(timelineStart),
+		Series: &eventsv1.EventSeries{
+			Count:            3,
+			LastObservedTime: metav1.NewMicroTime(timelineStart.Add(2 * time.Minute)),
+		},
+	}
+
+	controllerReplicaSet = &appsv1.ReplicaSet{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:              "nginx-ingress-7d9c6b9d4",
+			Namespace:         "nginx-ingress",
+			CreationTimestamp: metav1.NewTime(timelineStart.Add(-2 * time.Minute)),
+			Annotations:       map[string]string{"deployment.kubernetes.io/revision": "2"},
+			OwnerReferences: []metav1.OwnerReference{
+				{Kind: "Deployment", Name: "nginx-ingress", Controller: &controllerTrue},
+			},
+		},
+	}
+
+	restartedPod = &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "nginx-ingress-7d9c6b9d4-xk2lp",
+			Namespace: "nginx-ingress",
+			OwnerReferences: []metav1.OwnerReference{
+				{Kind: "ReplicaSet", Name: "nginx-ingress-7d9c6b9d4", Controller: &controllerTrue},
+			},
+		},
+		Status: corev1.PodStatus{
+			ContainerStatuses: []corev1.ContainerStatus{
+				{
+					Name:         "nginx-ingress",
+					RestartCount: 4,
+					LastTerminationState: corev1.ContainerState{
+						Terminated: &corev1.ContainerStateTerminated{
+							Reason:     "OOMKilled",
+							ExitCode:   137,
+							FinishedAt: metav1.NewTime(timelineStart.Add(4 * time.Minute)),
+						},
+					},
+				},
+			},
+		},
+	}
+)
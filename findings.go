@@ -0,0 +1,58 @@
+package inspector
+
+import (
+	"fmt"
+	"sort"
+)
+
+// Finding severities.
+const (
+	SeverityInfo     = "info"
+	SeverityWarning  = "warning"
+	SeverityCritical = "critical"
+)
+
+// Finding represents a problem detected by an analyzer.
+type Finding struct {
+	Check    string    `json:"check"`
+	Severity string    `json:"severity"`
+	Object   ObjectRef `json:"object"`
+	Message  string    `json:"message"`
+}
+
+// severityRank orders severities from the least to the most severe.
+var severityRank = map[string]int{
+	SeverityInfo:     1,
+	SeverityWarning:  2,
+	SeverityCritical: 3,
+}
+
+// FailOn returns a [FindingsError] listing findings with
+// the given severity or higher, or nil if there are none.
+func FailOn(findings []Finding, severity string) error {
+	rank, ok := severityRank[severity]
+	if !ok {
+		return &ConfigError{Err: fmt.Errorf("unknown severity %q, want info, warning or critical", severity)}
+	}
+	var failed []Finding
+	for _, f := range findings {
+		if severityRank[f.Severity] >= rank {
+			failed = append(failed, f)
+		}
+	}
+	if len(failed) == 0 {
+		return nil
+	}
+	return &FindingsError{Severity: severity, Findings: failed}
+}
+
+// sortFindings orders findings by check name and the object they refer to,
+// so reports generated from the same cluster state are identical.
+func sortFindings(findings []Finding) {
+	sort.SliceStable(findings, func(a, b int) bool {
+		if findings[a].Check != findings[b].Check {
+			return findings[a].Check < findings[b].Check
+		}
+		return findings[a].Object.String() < findings[b].Object.String()
+	})
+}
@@ -0,0 +1,474 @@
+package inspector
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	discoveryv1 "k8s.io/api/discovery/v1"
+	netv1 "k8s.io/api/networking/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+)
+
+// OwnerGraph lists a root object and the objects it owns or references.
+type OwnerGraph struct {
+	Root    ObjectRef   `json:"root"`
+	Objects []ObjectRef `json:"objects"`
+}
+
+// Contains reports whether the object belongs to the graph.
+func (g OwnerGraph) Contains(ref ObjectRef) bool {
+	return slices.Contains(g.Objects, ref)
+}
+
+// ownerRootKinds maps kind names and their short names accepted
+// in owner graph roots to object kinds.
+var ownerRootKinds = map[string]string{
+	"deployment":  "Deployment",
+	"deploy":      "Deployment",
+	"statefulset": "StatefulSet",
+	"sts":         "StatefulSet",
+	"daemonset":   "DaemonSet",
+	"ds":          "DaemonSet",
+	"service":     "Service",
+	"svc":         "Service",
+	"ingress":     "Ingress",
+	"ing":         "Ingress",
+}
+
+// ParseOwnerRoot parses an owner graph root given in the kind/name form,
+// like deployment/web or ingress/shop.
+func ParseOwnerRoot(namespace, s string) (ObjectRef, error) {
+	kind, name, ok := strings.Cut(s, "/")
+	if !ok || name == "" {
+		return ObjectRef{}, &ConfigError{Err: fmt.Errorf("owner %q is not in the kind/name form", s)}
+	}
+	k, ok := ownerRootKinds[strings.ToLower(kind)]
+	if !ok {
+		return ObjectRef{}, &ConfigError{Err: fmt.Errorf("owner kind %q is not one of deployment, statefulset, daemonset, service or ingress", kind)}
+	}
+	return ObjectRef{Kind: k, Namespace: namespace, Name: name}, nil
+}
+
+// graphCollectors maps object kinds in owner graphs
+// to collectors listing them.
+var graphCollectors = map[string][]string{
+	"Deployment":              {"deployments"},
+	"StatefulSet":             {"stateful_sets"},
+	"DaemonSet":               {"daemon_sets"},
+	"ReplicaSet":              {"replica_sets"},
+	"ControllerRevision":      {"controller_revisions"},
+	"Pod":                     {"pods", "pod_logs"},
+	"Service":                 {"services", "endpoints"},
+	"ConfigMap":               {"config_maps"},
+	"Secret":                  {"secrets"},
+	"PersistentVolumeClaim":   {"persistent_volume_claims"},
+	"ServiceAccount":          {"service_accounts"},
+	"HorizontalPodAutoscaler": {"horizontal_pod_autoscalers"},
+	"PodDisruptionBudget":     {"pod_disruption_budgets"},
+	"Ingress":                 {"ingresses"},
+	"IngressClass":            {"ingress_classes"},
+}
+
+// OwnerReport collects a report limited to the root object and objects
+// it owns or references: ReplicaSets, ControllerRevisions, Pods and their
+// logs, Services and their endpoints, ConfigMaps, metadata of Secrets,
+// PersistentVolumeClaims, ServiceAccounts, autoscalers, disruption budgets
+// and events.
+func (i *Inspector) OwnerReport(ctx context.Context, namespace string, root ObjectRef) (Report, error) {
+	lister := *i
+	lister.PageHandler = nil
+	objects, err := lister.graphObjects(ctx, namespace)
+	if err != nil {
+		return Report{}, err
+	}
+	graph, err := BuildOwnerGraph(objects, root)
+	if err != nil {
+		return Report{}, err
+	}
+
+	scoped := *i
+	scoped.LabelSelector, scoped.FieldSelector, scoped.Names = "", "", nil
+	scoped.Collectors, scoped.Selectors = graphScope(graph, i.selectedCollectors())
+	// Objects are trimmed once the scoped report is analyzed again.
+	scoped.Trim = TrimOptions{KeepManagedFields: true, KeepLastApplied: true}
+	rep, err := scoped.Report(ctx, namespace)
+	filterGraphEvents(&rep, graph)
+	rep.Graph = &graph
+	rep.SetAnalysis(AnalyzeWithOptions(rep, i.analyzeOptions()))
+	if trimErr := trimReport(&rep, i.Trim); trimErr != nil {
+		return Report{}, trimErr
+	}
+	return rep, err
+}
+
+// graphObjects lists objects owner graphs are built from.
+func (i *Inspector) graphObjects(ctx context.Context, namespace string) (Report, error) {
+	var (
+		rep Report
+		err error
+	)
+	if rep.Deployments, err = i.Deployments(ctx, namespace); err != nil {
+		return Report{}, newCollectorError("deployments", err)
+	}
+	if rep.StatefulSets, err = i.StatefulSets(ctx, namespace); err != nil {
+		return Report{}, newCollectorError("stateful_sets", err)
+	}
+	if rep.DaemonSets, err = i.DaemonSets(ctx, namespace); err != nil {
+		return Report{}, newCollectorError("daemon_sets", err)
+	}
+	if rep.ReplicaSets, err = i.ReplicaSets(ctx, namespace); err != nil {
+		return Report{}, newCollectorError("replica_sets", err)
+	}
+	if rep.ControllerRevisions, err = i.ControllerRevisions(ctx, namespace); err != nil {
+		return Report{}, newCollectorError("controller_revisions", err)
+	}
+	if rep.Pods, err = i.Pods(ctx, namespace); err != nil {
+		return Report{}, newCollectorError("pods", err)
+	}
+	if rep.Services, err = i.Services(ctx, namespace); err != nil {
+		return Report{}, newCollectorError("services", err)
+	}
+	if rep.Ingresses, err = i.Ingresses(ctx, namespace); err != nil {
+		return Report{}, newCollectorError("ingresses", err)
+	}
+	if rep.HorizontalPodAutoscalers, err = i.HorizontalPodAutoscalers(ctx, namespace); err != nil {
+		return Report{}, newCollectorError("horizontal_pod_autoscalers", err)
+	}
+	if rep.PodDisruptionBudgets, err = i.PodDisruptionBudgets(ctx, namespace); err != nil {
+		return Report{}, newCollectorError("pod_disruption_budgets", err)
+	}
+	return rep, nil
+}
+
+// graphScope returns collectors and selectors collecting objects
+// of the graph. When selected is not nil, only selected collectors run.
+func graphScope(graph OwnerGraph, selected map[string]bool) ([]string, map[string]Selector) {
+	selectors := map[string]Selector{}
+	var services []string
+	for _, ref := range graph.Objects {
+		for _, c := range graphCollectors[ref.Kind] {
+			s := selectors[c]
+			s.Names = append(s.Names, ref.Name)
+			selectors[c] = s
+		}
+		if ref.Kind == "Service" {
+			services = append(services, ref.Name)
+		}
+	}
+	if len(services) > 0 {
+		selectors["endpoint_slices"] = Selector{
+			LabelSelector: fmt.Sprintf("%s in (%s)", discoveryv1.LabelServiceName, strings.Join(services, ",")),
+		}
+	}
+
+	collectors := []string{"cluster_id", "nodes", "platform", "events", "events_v1"}
+	for _, c := range Collectors {
+		if _, ok := selectors[c.Name]; ok {
+			collectors = append(collectors, c.Name)
+		}
+	}
+	if selected != nil {
+		collectors = slices.DeleteFunc(collectors, func(c string) bool { return !selected[c] })
+	}
+	return collectors, selectors
+}
+
+// filterGraphEvents removes events about objects outside the graph.
+func filterGraphEvents(rep *Report, graph OwnerGraph) {
+	if rep.Events != nil {
+		rep.Events.Items = slices.DeleteFunc(rep.Events.Items, func(e corev1.Event) bool {
+			o := e.InvolvedObject
+			return !graph.Contains(ObjectRef{Kind: o.Kind, Namespace: o.Namespace, Name: o.Name})
+		})
+	}
+	if rep.EventsV1 != nil {
+		for n := len(rep.EventsV1.Items) - 1; n >= 0; n-- {
+			o := rep.EventsV1.Items[n].Regarding
+			if !graph.Contains(ObjectRef{Kind: o.Kind, Namespace: o.Namespace, Name: o.Name}) {
+				rep.EventsV1.Items = slices.Delete(rep.EventsV1.Items, n, n+1)
+			}
+		}
+	}
+}
+
+// BuildOwnerGraph returns the graph of objects owned or referenced
+// by the root object, built from objects collected in the report.
+func BuildOwnerGraph(rep Report, root ObjectRef) (OwnerGraph, error) {
+	g := graphBuilder{rep: rep, namespace: root.Namespace, refs: map[ObjectRef]bool{}}
+	var found bool
+	switch root.Kind {
+	case "Deployment":
+		found = g.addDeployment(root.Name)
+	case "StatefulSet":
+		found = g.addStatefulSet(root.Name)
+	case "DaemonSet":
+		found = g.addDaemonSet(root.Name)
+	case "Service":
+		found = g.addService(root.Name, true)
+	case "Ingress":
+		found = g.addIngress(root.Name)
+	}
+	if !found {
+		return OwnerGraph{}, &ConfigError{Err: fmt.Errorf("%s %s not found in namespace %s", strings.ToLower(root.Kind), root.Name, root.Namespace)}
+	}
+	g.addPodReferences()
+
+	graph := OwnerGraph{Root: root}
+	for ref := range g.refs {
+		graph.Objects = append(graph.Objects, ref)
+	}
+	sort.Slice(graph.Objects, func(a, b int) bool {
+		return graph.Objects[a].String() < graph.Objects[b].String()
+	})
+	return graph, nil
+}
+
+// graphBuilder collects references of objects in an owner graph.
+type graphBuilder struct {
+	rep       Report
+	namespace string
+	refs      map[ObjectRef]bool
+	pods      []corev1.Pod
+}
+
+func (g *graphBuilder) add(kind, name string) {
+	g.refs[ObjectRef{Kind: kind, Namespace: g.namespace, Name: name}] = true
+}
+
+func (g *graphBuilder) addPod(pod corev1.Pod) {
+	if g.refs[ObjectRef{Kind: "Pod", Namespace: g.namespace, Name: pod.Name}] {
+		return
+	}
+	g.add("Pod", pod.Name)
+	g.pods = append(g.pods, pod)
+}
+
+// addDeployment adds the deployment with its ReplicaSets and Pods.
+func (g *graphBuilder) addDeployment(name string) bool {
+	if g.rep.Deployments == nil || !slices.ContainsFunc(g.rep.Deployments.Items, func(d appsv1.Deployment) bool { return d.Name == name }) {
+		return false
+	}
+	g.add("Deployment", name)
+	var replicaSets []string
+	if g.rep.ReplicaSets != nil {
+		for _, rs := range g.rep.ReplicaSets.Items {
+			if ownedBy(rs.OwnerReferences, "Deployment", name) {
+				g.add("ReplicaSet", rs.Name)
+				replicaSets = append(replicaSets, rs.Name)
+			}
+		}
+	}
+	for _, pod := range g.listPods() {
+		for _, rs := range replicaSets {
+			if ownedBy(pod.OwnerReferences, "ReplicaSet", rs) {
+				g.addPod(pod)
+			}
+		}
+	}
+	g.addWorkloadReferences("Deployment", name)
+	return true
+}
+
+// addStatefulSet adds the stateful set with its ControllerRevisions and Pods.
+func (g *graphBuilder) addStatefulSet(name string) bool {
+	if g.rep.StatefulSets == nil || !slices.ContainsFunc(g.rep.StatefulSets.Items, func(s appsv1.StatefulSet) bool { return s.Name == name }) {
+		return false
+	}
+	g.add("StatefulSet", name)
+	if g.rep.ControllerRevisions != nil {
+		for _, cr := range g.rep.ControllerRevisions.Items {
+			if ownedBy(cr.OwnerReferences, "StatefulSet", name) {
+				g.add("ControllerRevision", cr.Name)
+			}
+		}
+	}
+	for _, pod := range g.listPods() {
+		if ownedBy(pod.OwnerReferences, "StatefulSet", name) {
+			g.addPod(pod)
+		}
+	}
+	g.addWorkloadReferences("StatefulSet", name)
+	return true
+}
+
+// addDaemonSet adds the daemon set with its Pods.
+func (g *graphBuilder) addDaemonSet(name string) bool {
+	if g.rep.DaemonSets == nil || !slices.ContainsFunc(g.rep.DaemonSets.Items, func(d appsv1.DaemonSet) bool { return d.Name == name }) {
+		return false
+	}
+	g.add("DaemonSet", name)
+	for _, pod := range g.listPods() {
+		if ownedBy(pod.OwnerReferences, "DaemonSet", name) {
+			g.addPod(pod)
+		}
+	}
+	g.addWorkloadReferences("DaemonSet", name)
+	return true
+}
+
+// addWorkloadReferences adds Services selecting workload Pods
+// and autoscalers targeting the workload.
+func (g *graphBuilder) addWorkloadReferences(kind, name string) {
+	if g.rep.Services != nil {
+		for _, svc := range g.rep.Services.Items {
+			if slices.ContainsFunc(g.pods, func(pod corev1.Pod) bool { return selectsPod(svc, pod) }) {
+				g.addService(svc.Name, false)
+			}
+		}
+	}
+	if g.rep.HorizontalPodAutoscalers != nil {
+		for _, hpa := range g.rep.HorizontalPodAutoscalers.Items {
+			if hpa.Spec.ScaleTargetRef.Kind == kind && hpa.Spec.ScaleTargetRef.Name == name {
+				g.add("HorizontalPodAutoscaler", hpa.Name)
+			}
+		}
+	}
+}
+
+// addService adds the service and, if withPods is set, the Pods it selects.
+func (g *graphBuilder) addService(name string, withPods bool) bool {
+	g.add("Service", name)
+	if g.rep.Services == nil {
+		return false
+	}
+	i := slices.IndexFunc(g.rep.Services.Items, func(svc corev1.Service) bool { return svc.Name == name })
+	if i < 0 {
+		return false
+	}
+	if withPods {
+		for _, pod := range g.listPods() {
+			if selectsPod(g.rep.Services.Items[i], pod) {
+				g.addPod(pod)
+			}
+		}
+	}
+	return true
+}
+
+// addIngress adds the ingress, its class, TLS Secrets and backend Services.
+func (g *graphBuilder) addIngress(name string) bool {
+	if g.rep.Ingresses == nil {
+		return false
+	}
+	i := slices.IndexFunc(g.rep.Ingresses.Items, func(ing netv1.Ingress) bool { return ing.Name == name })
+	if i < 0 {
+		return false
+	}
+	ing := g.rep.Ingresses.Items[i]
+	g.add("Ingress", name)
+	if ing.Spec.IngressClassName != nil {
+		g.refs[ObjectRef{Kind: "IngressClass", Name: *ing.Spec.IngressClassName}] = true
+	}
+	for _, tls := range ing.Spec.TLS {
+		if tls.SecretName != "" {
+			g.add("Secret", tls.SecretName)
+		}
+	}
+	if b := ing.Spec.DefaultBackend; b != nil && b.Service != nil {
+		g.addService(b.Service.Name, true)
+	}
+	for _, rule := range ing.Spec.Rules {
+		if rule.HTTP == nil {
+			continue
+		}
+		for _, p := range rule.HTTP.Paths {
+			if p.Backend.Service != nil {
+				g.addService(p.Backend.Service.Name, true)
+			}
+		}
+	}
+	return true
+}
+
+// addPodReferences adds objects referenced by Pods in the graph and
+// disruption budgets selecting them.
+func (g *graphBuilder) addPodReferences() {
+	for _, pod := range g.pods {
+		if pod.Spec.ServiceAccountName != "" {
+			g.add("ServiceAccount", pod.Spec.ServiceAccountName)
+		}
+		for _, s := range pod.Spec.ImagePullSecrets {
+			g.add("Secret", s.Name)
+		}
+		for _, v := range pod.Spec.Volumes {
+			switch {
+			case v.ConfigMap != nil:
+				g.add("ConfigMap", v.ConfigMap.Name)
+			case v.Secret != nil:
+				g.add("Secret", v.Secret.SecretName)
+			case v.PersistentVolumeClaim != nil:
+				g.add("PersistentVolumeClaim", v.PersistentVolumeClaim.ClaimName)
+			case v.Projected != nil:
+				for _, src := range v.Projected.Sources {
+					if src.ConfigMap != nil {
+						g.add("ConfigMap", src.ConfigMap.Name)
+					}
+					if src.Secret != nil {
+						g.add("Secret", src.Secret.Name)
+					}
+				}
+			}
+		}
+		for _, c := range slices.Concat(pod.Spec.InitContainers, pod.Spec.Containers) {
+			for _, from := range c.EnvFrom {
+				if from.ConfigMapRef != nil {
+					g.add("ConfigMap", from.ConfigMapRef.Name)
+				}
+				if from.SecretRef != nil {
+					g.add("Secret", from.SecretRef.Name)
+				}
+			}
+			for _, env := range c.Env {
+				if env.ValueFrom == nil {
+					continue
+				}
+				if ref := env.ValueFrom.ConfigMapKeyRef; ref != nil {
+					g.add("ConfigMap", ref.Name)
+				}
+				if ref := env.ValueFrom.SecretKeyRef; ref != nil {
+					g.add("Secret", ref.Name)
+				}
+			}
+		}
+	}
+	if g.rep.PodDisruptionBudgets == nil {
+		return
+	}
+	for _, pdb := range g.rep.PodDisruptionBudgets.Items {
+		selector, err := metav1.LabelSelectorAsSelector(pdb.Spec.Selector)
+		if err != nil || selector.Empty() {
+			continue
+		}
+		if slices.ContainsFunc(g.pods, func(pod corev1.Pod) bool { return selector.Matches(labels.Set(pod.Labels)) }) {
+			g.add("PodDisruptionBudget", pdb.Name)
+		}
+	}
+}
+
+func (g *graphBuilder) listPods() []corev1.Pod {
+	if g.rep.Pods == nil {
+		return nil
+	}
+	return g.rep.Pods.Items
+}
+
+// ownedBy reports whether owner references include the named owner.
+func ownedBy(refs []metav1.OwnerReference, kind, name string) bool {
+	return slices.ContainsFunc(refs, func(ref metav1.OwnerReference) bool {
+		return ref.Kind == kind && ref.Name == name
+	})
+}
+
+// selectsPod reports whether the service selector matches pod labels.
+func selectsPod(svc corev1.Service, pod corev1.Pod) bool {
+	if len(svc.Spec.Selector) == 0 {
+		return false
+	}
+	return labels.SelectorFromSet(svc.Spec.Selector).Matches(labels.Set(pod.Labels))
+}
@@ -0,0 +1,291 @@
+package inspector_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/qba73/inspector"
+
+	appsv1 "k8s.io/api/apps/v1"
+	autoscalingv2 "k8s.io/api/autoscaling/v2"
+	corev1 "k8s.io/api/core/v1"
+	netv1 "k8s.io/api/networking/v1"
+	policyv1 "k8s.io/api/policy/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	k8sruntime "k8s.io/apimachinery/pkg/runtime"
+)
+
+func TestBuildOwnerGraphFollowsDeploymentOwnershipAndReferences(t *testing.T) {
+	t.Parallel()
+
+	root := inspector.ObjectRef{Kind: "Deployment", Namespace: "shop", Name: "web"}
+	got, err := inspector.BuildOwnerGraph(graphReport(), root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := inspector.OwnerGraph{
+		Root: root,
+		Objects: []inspector.ObjectRef{
+			{Kind: "ConfigMap", Namespace: "shop", Name: "web-config"},
+			{Kind: "Deployment", Namespace: "shop", Name: "web"},
+			{Kind: "HorizontalPodAutoscaler", Namespace: "shop", Name: "web"},
+			{Kind: "Pod", Namespace: "shop", Name: "web-5d8f-x2k"},
+			{Kind: "PodDisruptionBudget", Namespace: "shop", Name: "web"},
+			{Kind: "ReplicaSet", Namespace: "shop", Name: "web-5d8f"},
+			{Kind: "Secret", Namespace: "shop", Name: "web-db"},
+			{Kind: "Service", Namespace: "shop", Name: "web"},
+			{Kind: "ServiceAccount", Namespace: "shop", Name: "web"},
+		},
+	}
+	if !cmp.Equal(want, got) {
+		t.Error(cmp.Diff(want, got))
+	}
+}
+
+func TestBuildOwnerGraphFollowsDaemonSetPods(t *testing.T) {
+	t.Parallel()
+
+	rep := graphReport()
+	rep.DaemonSets = &appsv1.DaemonSetList{Items: []appsv1.DaemonSet{
+		{ObjectMeta: metav1.ObjectMeta{Name: "worker", Namespace: "shop"}},
+	}}
+	rep.Pods.Items[1].OwnerReferences = []metav1.OwnerReference{{Kind: "DaemonSet", Name: "worker"}}
+
+	root := inspector.ObjectRef{Kind: "DaemonSet", Namespace: "shop", Name: "worker"}
+	got, err := inspector.BuildOwnerGraph(rep, root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []inspector.ObjectRef{
+		{Kind: "DaemonSet", Namespace: "shop", Name: "worker"},
+		{Kind: "Pod", Namespace: "shop", Name: "worker"},
+		{Kind: "Service", Namespace: "shop", Name: "worker"},
+	}
+	if !cmp.Equal(want, got.Objects) {
+		t.Error(cmp.Diff(want, got.Objects))
+	}
+}
+
+func TestBuildOwnerGraphFollowsStatefulSetRevisions(t *testing.T) {
+	t.Parallel()
+
+	rep := graphReport()
+	owner := []metav1.OwnerReference{{Kind: "StatefulSet", Name: "worker"}}
+	rep.StatefulSets = &appsv1.StatefulSetList{Items: []appsv1.StatefulSet{
+		{ObjectMeta: metav1.ObjectMeta{Name: "worker", Namespace: "shop"}},
+	}}
+	rep.ControllerRevisions = &appsv1.ControllerRevisionList{Items: []appsv1.ControllerRevision{
+		{ObjectMeta: metav1.ObjectMeta{Name: "worker-5c4b9", Namespace: "shop", OwnerReferences: owner}},
+	}}
+	rep.Pods.Items[1].OwnerReferences = owner
+
+	root := inspector.ObjectRef{Kind: "StatefulSet", Namespace: "shop", Name: "worker"}
+	got, err := inspector.BuildOwnerGraph(rep, root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []inspector.ObjectRef{
+		{Kind: "ControllerRevision", Namespace: "shop", Name: "worker-5c4b9"},
+		{Kind: "Pod", Namespace: "shop", Name: "worker"},
+		{Kind: "Service", Namespace: "shop", Name: "worker"},
+		{Kind: "StatefulSet", Namespace: "shop", Name: "worker"},
+	}
+	if !cmp.Equal(want, got.Objects) {
+		t.Error(cmp.Diff(want, got.Objects))
+	}
+}
+
+func TestBuildOwnerGraphFollowsIngressBackends(t *testing.T) {
+	t.Parallel()
+
+	root := inspector.ObjectRef{Kind: "Ingress", Namespace: "shop", Name: "shop"}
+	got, err := inspector.BuildOwnerGraph(graphReport(), root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []inspector.ObjectRef{
+		{Kind: "ConfigMap", Namespace: "shop", Name: "web-config"},
+		{Kind: "Ingress", Namespace: "shop", Name: "shop"},
+		{Kind: "IngressClass", Name: "nginx"},
+		{Kind: "Pod", Namespace: "shop", Name: "web-5d8f-x2k"},
+		{Kind: "PodDisruptionBudget", Namespace: "shop", Name: "web"},
+		{Kind: "Secret", Namespace: "shop", Name: "shop-tls"},
+		{Kind: "Secret", Namespace: "shop", Name: "web-db"},
+		{Kind: "Service", Namespace: "shop", Name: "web"},
+		{Kind: "ServiceAccount", Namespace: "shop", Name: "web"},
+	}
+	if !cmp.Equal(want, got.Objects) {
+		t.Error(cmp.Diff(want, got.Objects))
+	}
+}
+
+func TestBuildOwnerGraphReportsMissingRoot(t *testing.T) {
+	t.Parallel()
+
+	_, err := inspector.BuildOwnerGraph(graphReport(), inspector.ObjectRef{Kind: "Deployment", Namespace: "shop", Name: "api"})
+	var configErr *inspector.ConfigError
+	if !errors.As(err, &configErr) {
+		t.Errorf("want config error, got %v", err)
+	}
+}
+
+func TestParseOwnerRootAcceptsShortKindNames(t *testing.T) {
+	t.Parallel()
+
+	got, err := inspector.ParseOwnerRoot("shop", "deploy/web")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := inspector.ObjectRef{Kind: "Deployment", Namespace: "shop", Name: "web"}
+	if want != got {
+		t.Errorf("want %s, got %s", want, got)
+	}
+	got, err = inspector.ParseOwnerRoot("shop", "ds/nginx-ingress")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want = inspector.ObjectRef{Kind: "DaemonSet", Namespace: "shop", Name: "nginx-ingress"}
+	if want != got {
+		t.Errorf("want %s, got %s", want, got)
+	}
+	if _, err := inspector.ParseOwnerRoot("shop", "configmap/web"); err == nil {
+		t.Error("want error for unsupported kind")
+	}
+}
+
+func TestInspectorOwnerReportCollectsOnlyGraphObjects(t *testing.T) {
+	t.Parallel()
+
+	rep := graphReport()
+	objects := []k8sruntime.Object{kubeSystemNameSpace, clusterNode1, graphConfigMap, graphOtherConfigMap, graphPodEvent, graphOtherPodEvent}
+	objects = append(objects, &rep.Deployments.Items[0], &rep.ReplicaSets.Items[0], &rep.Services.Items[0], &rep.Services.Items[1])
+	for n := range rep.Pods.Items {
+		objects = append(objects, &rep.Pods.Items[n])
+	}
+	i := &inspector.Inspector{K8sClient: newTestClientset(objects...)}
+
+	got, err := i.OwnerReport(context.Background(), "shop", inspector.ObjectRef{Kind: "Deployment", Namespace: "shop", Name: "web"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var names []string
+	for _, pod := range got.Pods.Items {
+		names = append(names, pod.Name)
+	}
+	for _, svc := range got.Services.Items {
+		names = append(names, svc.Name)
+	}
+	for _, cm := range got.ConfigMaps.Items {
+		names = append(names, cm.Name)
+	}
+	for _, e := range got.Events.Items {
+		names = append(names, e.Name)
+	}
+	for _, l := range got.Podlogs {
+		names = append(names, l.Name)
+	}
+	want := []string{"web-5d8f-x2k", "web", "web-config", "web-5d8f-x2k.1", "web-5d8f-x2k_web"}
+	if !cmp.Equal(want, names) {
+		t.Error(cmp.Diff(want, names))
+	}
+	if got.Graph == nil || got.Graph.Root.Name != "web" {
+		t.Errorf("want owner graph in the report, got %+v", got.Graph)
+	}
+	if got.Leases != nil || got.Roles != nil {
+		t.Error("want collectors unrelated to the graph skipped")
+	}
+}
+
+func graphReport() inspector.Report {
+	ingressClass := "nginx"
+	return inspector.Report{
+		Deployments: &appsv1.DeploymentList{Items: []appsv1.Deployment{
+			{ObjectMeta: metav1.ObjectMeta{Name: "web", Namespace: "shop"}},
+		}},
+		ReplicaSets: &appsv1.ReplicaSetList{Items: []appsv1.ReplicaSet{
+			{ObjectMeta: metav1.ObjectMeta{
+				Name: "web-5d8f", Namespace: "shop",
+				OwnerReferences: []metav1.OwnerReference{{Kind: "Deployment", Name: "web"}},
+			}},
+		}},
+		Pods: &corev1.PodList{Items: []corev1.Pod{
+			{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: "web-5d8f-x2k", Namespace: "shop",
+					Labels:          map[string]string{"app": "web"},
+					OwnerReferences: []metav1.OwnerReference{{Kind: "ReplicaSet", Name: "web-5d8f"}},
+				},
+				Spec: corev1.PodSpec{
+					ServiceAccountName: "web",
+					Volumes: []corev1.Volume{{
+						Name:         "config",
+						VolumeSource: corev1.VolumeSource{ConfigMap: &corev1.ConfigMapVolumeSource{LocalObjectReference: corev1.LocalObjectReference{Name: "web-config"}}},
+					}},
+					Containers: []corev1.Container{{
+						Name: "web",
+						Env: []corev1.EnvVar{{
+							Name: "DB_PASSWORD",
+							ValueFrom: &corev1.EnvVarSource{SecretKeyRef: &corev1.SecretKeySelector{
+								LocalObjectReference: corev1.LocalObjectReference{Name: "web-db"},
+								Key:                  "password",
+							}},
+						}},
+					}},
+				},
+			},
+			{
+				ObjectMeta: metav1.ObjectMeta{Name: "worker", Namespace: "shop", Labels: map[string]string{"app": "worker"}},
+				Spec:       corev1.PodSpec{Containers: []corev1.Container{{Name: "worker"}}},
+			},
+		}},
+		Services: &corev1.ServiceList{Items: []corev1.Service{
+			{ObjectMeta: metav1.ObjectMeta{Name: "web", Namespace: "shop"}, Spec: corev1.ServiceSpec{Selector: map[string]string{"app": "web"}}},
+			{ObjectMeta: metav1.ObjectMeta{Name: "worker", Namespace: "shop"}, Spec: corev1.ServiceSpec{Selector: map[string]string{"app": "worker"}}},
+		}},
+		Ingresses: &netv1.IngressList{Items: []netv1.Ingress{
+			{
+				ObjectMeta: metav1.ObjectMeta{Name: "shop", Namespace: "shop"},
+				Spec: netv1.IngressSpec{
+					IngressClassName: &ingressClass,
+					TLS:              []netv1.IngressTLS{{SecretName: "shop-tls"}},
+					Rules: []netv1.IngressRule{{
+						IngressRuleValue: netv1.IngressRuleValue{HTTP: &netv1.HTTPIngressRuleValue{
+							Paths: []netv1.HTTPIngressPath{{
+								Path:    "/",
+								Backend: netv1.IngressBackend{Service: &netv1.IngressServiceBackend{Name: "web"}},
+							}},
+						}},
+					}},
+				},
+			},
+		}},
+		HorizontalPodAutoscalers: &autoscalingv2.HorizontalPodAutoscalerList{Items: []autoscalingv2.HorizontalPodAutoscaler{
+			{
+				ObjectMeta: metav1.ObjectMeta{Name: "web", Namespace: "shop"},
+				Spec:       autoscalingv2.HorizontalPodAutoscalerSpec{ScaleTargetRef: autoscalingv2.CrossVersionObjectReference{Kind: "Deployment", Name: "web"}},
+			},
+		}},
+		PodDisruptionBudgets: &policyv1.PodDisruptionBudgetList{Items: []policyv1.PodDisruptionBudget{
+			{
+				ObjectMeta: metav1.ObjectMeta{Name: "web", Namespace: "shop"},
+				Spec:       policyv1.PodDisruptionBudgetSpec{Selector: &metav1.LabelSelector{MatchLabels: map[string]string{"app": "web"}}},
+			},
+		}},
+	}
+}
+
+var (
+	graphConfigMap      = &corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Name: "web-config", Namespace: "shop"}}
+	graphOtherConfigMap = &corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Name: "worker-config", Namespace: "shop"}}
+
+	graphPodEvent = &corev1.Event{
+		ObjectMeta:     metav1.ObjectMeta{Name: "web-5d8f-x2k.1", Namespace: "shop"},
+		InvolvedObject: corev1.ObjectReference{Kind: "Pod", Namespace: "shop", Name: "web-5d8f-x2k"},
+	}
+	graphOtherPodEvent = &corev1.Event{
+		ObjectMeta:     metav1.ObjectMeta{Name: "worker.1", Namespace: "shop"},
+		InvolvedObject: corev1.ObjectReference{Kind: "Pod", Namespace: "shop", Name: "worker"},
+	}
+)
@@ -4,16 +4,24 @@ import (
 	"context"
 	"encoding/json"
 	"errors"
-	"flag"
 	"fmt"
 	"io"
+	"log/slog"
 	"os"
 	"strings"
+	"time"
 
 	appsv1 "k8s.io/api/apps/v1"
+	autoscalingv2 "k8s.io/api/autoscaling/v2"
+	batchv1 "k8s.io/api/batch/v1"
 	coordv1 "k8s.io/api/coordination/v1"
 	corev1 "k8s.io/api/core/v1"
+	discoveryv1 "k8s.io/api/discovery/v1"
+	eventsv1 "k8s.io/api/events/v1"
 	netv1 "k8s.io/api/networking/v1"
+	policyv1 "k8s.io/api/policy/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
+	storagev1 "k8s.io/api/storage/v1"
 
 	apiextv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
 	crd "k8s.io/apiextensions-apiserver/pkg/client/clientset/clientset"
@@ -21,28 +29,157 @@ import (
 	metrics "k8s.io/metrics/pkg/client/clientset/versioned"
 
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/discovery"
 	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/rest"
 	"k8s.io/client-go/tools/clientcmd"
 )
 
 // Inspector is an inspector client.
+//
+// Progress of collectors is logged to Logger. When Logger is nil and
+// Verbose is set, progress is logged to stderr, otherwise it is discarded.
 type Inspector struct {
 	Verbose       bool
+	Logger        *slog.Logger
 	K8sClient     kubernetes.Interface
-	CRDClient     *crd.Clientset
-	MetricsClient *metrics.Clientset
+	CRDClient     crd.Interface
+	MetricsClient metrics.Interface
+
+	// Collectors lists names of collectors to run. All collectors run when empty.
+	Collectors []string
+	// LabelSelector, FieldSelector and Names filter objects listed
+	// by namespaced collectors, except events.
+	LabelSelector string
+	FieldSelector string
+	Names         []string
+	// Selectors holds selectors of individual collectors,
+	// overriding the selectors above.
+	Selectors map[string]Selector
+	// Redact lists rules masking sensitive text in collected data.
+	Redact []RedactRule
+
+	// PageSize is the number of objects requested per list call,
+	// DefaultPageSize when zero.
+	PageSize int64
+	// PageHandler, when set, receives pages of streamed collectors
+	// instead of the report.
+	PageHandler PageHandler
+	// Retry configures retries of failed API calls.
+	// Calls are not retried by default.
+	Retry RetryPolicy
+	// Timeout bounds each attempt of an API call. Log reads and
+	// watches are not bounded. Calls have no timeout when zero.
+	Timeout time.Duration
+	// CertExpiryWindow is the window in which expiring certificates
+	// are reported, DefaultCertExpiryWindow when zero.
+	CertExpiryWindow time.Duration
+	// Trim selects fields removed from collected objects after
+	// the report is analyzed. Managed fields and the last applied
+	// configuration annotation are removed by default.
+	Trim TrimOptions
+
+	// KubeContext is the name of the kubeconfig context
+	// the inspector connects to.
+	KubeContext string
+	// Options holds options of the collection not known to
+	// the inspector, such as CLI flags. They are recorded with
+	// the effective inspector options in the report metadata.
+	Options map[string]string
 }
 
 // BuildInspectorFromKubeConfig builds an inspector client ready to interact with the K8s cluster.
 func BuildInspectorFromKubeConfig() (*Inspector, error) {
-	home, err := os.UserHomeDir()
+	return BuildInspector("", "")
+}
+
+// BuildInspector builds an inspector client for the given kubeconfig file
+// and context. Empty kubeconfig and context select the kubectl defaults:
+// files listed in KUBECONFIG or ${HOME}/.kube/config and their current context.
+// In a pod without a kubeconfig, the in-cluster configuration is used.
+func BuildInspector(kubeconfig, kubeContext string) (*Inspector, error) {
+	return BuildInspectorWithOptions(ClientOptions{Kubeconfig: kubeconfig, Context: kubeContext})
+}
+
+// ClientOptions configures connections to the API server.
+type ClientOptions struct {
+	// Kubeconfig and Context select the kubeconfig file and context,
+	// kubectl defaults are used when empty.
+	Kubeconfig string
+	Context    string
+	// QPS and Burst limit the rate of API calls,
+	// client-go defaults are used when zero.
+	QPS   float32
+	Burst int
+	// Timeout bounds each API call, except log reads and watches,
+	// which last as long as they need. Calls have no timeout when zero.
+	Timeout time.Duration
+	// InCluster connects with the service account of the pod
+	// inspector runs in, ignoring Kubeconfig and Context.
+	InCluster bool
+}
+
+// InClusterContext is the KubeContext of inspectors connecting
+// with the in-cluster configuration.
+const InClusterContext = "in-cluster"
+
+// BuildInspectorWithOptions builds an inspector client
+// connecting to the API server with the given options.
+func BuildInspectorWithOptions(opts ClientOptions) (*Inspector, error) {
+	config, kubeContext, err := restConfig(opts)
 	if err != nil {
 		return nil, err
 	}
-	config, err := clientcmd.BuildConfigFromFlags("", home+"/.kube/config")
+	config.QPS = opts.QPS
+	config.Burst = opts.Burst
+	i, err := NewInspector(config)
 	if err != nil {
 		return nil, err
 	}
+	i.KubeContext = kubeContext
+	i.Timeout = opts.Timeout
+	if opts.Timeout > 0 {
+		// Discovery calls take no context, so the timeout
+		// bounds them through their HTTP client.
+		discoveryConfig := rest.CopyConfig(config)
+		discoveryConfig.Timeout = opts.Timeout
+		dc, err := discovery.NewDiscoveryClientForConfig(discoveryConfig)
+		if err != nil {
+			return nil, err
+		}
+		i.K8sClient.(*kubernetes.Clientset).DiscoveryClient = dc
+	}
+	return i, nil
+}
+
+// restConfig returns the REST config selected by the options
+// and the name of its kubeconfig context.
+func restConfig(opts ClientOptions) (*rest.Config, string, error) {
+	if opts.InCluster {
+		config, err := rest.InClusterConfig()
+		if err != nil {
+			return nil, "", err
+		}
+		return config, InClusterContext, nil
+	}
+	clientConfig := kubeClientConfig(opts.Kubeconfig, opts.Context)
+	config, err := clientConfig.ClientConfig()
+	if err != nil {
+		return nil, "", err
+	}
+	kubeContext := opts.Context
+	if raw, err := clientConfig.RawConfig(); err == nil && kubeContext == "" {
+		kubeContext = raw.CurrentContext
+	}
+	if kubeContext == "" && os.Getenv("KUBERNETES_SERVICE_HOST") != "" {
+		// Without a kubeconfig, client-go falls back to the in-cluster configuration.
+		kubeContext = InClusterContext
+	}
+	return config, kubeContext, nil
+}
+
+// NewInspector builds an inspector client using the given REST config.
+func NewInspector(config *rest.Config) (*Inspector, error) {
 	kubeClient, err := kubernetes.NewForConfig(config)
 	if err != nil {
 		return nil, err
@@ -65,6 +202,27 @@ func BuildInspectorFromKubeConfig() (*Inspector, error) {
 	return &i, nil
 }
 
+// kubeClientConfig returns kubeconfig loaded with kubectl loading rules.
+func kubeClientConfig(kubeconfig, kubeContext string) clientcmd.ClientConfig {
+	rules := clientcmd.NewDefaultClientConfigLoadingRules()
+	rules.ExplicitPath = kubeconfig
+	overrides := &clientcmd.ConfigOverrides{CurrentContext: kubeContext}
+	return clientcmd.NewNonInteractiveDeferredLoadingClientConfig(rules, overrides)
+}
+
+// selectedCollectors returns names of collectors to run,
+// or nil when all collectors run.
+func (i *Inspector) selectedCollectors() map[string]bool {
+	if len(i.Collectors) == 0 {
+		return nil
+	}
+	selected := make(map[string]bool, len(i.Collectors))
+	for _, name := range i.Collectors {
+		selected[name] = true
+	}
+	return selected
+}
+
 // ClusterVersion returns K8s version.
 func (i *Inspector) ClusterVersion() (string, error) {
 	sv, err := i.K8sClient.Discovery().ServerVersion()
@@ -76,7 +234,9 @@ func (i *Inspector) ClusterVersion() (string, error) {
 
 // ClusterID returns kube-system namespace UID representing K8s clusterID.
 func (i *Inspector) ClusterID(ctx context.Context) (string, error) {
-	cluster, err := i.K8sClient.CoreV1().Namespaces().Get(ctx, "kube-system", metav1.GetOptions{})
+	cluster, err := retry(ctx, i, func(ctx context.Context) (*corev1.Namespace, error) {
+		return i.K8sClient.CoreV1().Namespaces().Get(ctx, "kube-system", metav1.GetOptions{})
+	})
 	if err != nil {
 		return "", err
 	}
@@ -85,7 +245,9 @@ func (i *Inspector) ClusterID(ctx context.Context) (string, error) {
 
 // Platform returns K8s platform name.
 func (i *Inspector) Platform(ctx context.Context) (string, error) {
-	nodes, err := i.K8sClient.CoreV1().Nodes().List(ctx, metav1.ListOptions{})
+	nodes, err := retry(ctx, i, func(ctx context.Context) (*corev1.NodeList, error) {
+		return i.K8sClient.CoreV1().Nodes().List(ctx, metav1.ListOptions{Limit: 1})
+	})
 	if err != nil {
 		return "", err
 	}
@@ -118,7 +280,7 @@ func platformName(providerID string) string {
 //
 // [nodes]: https://kubernetes.io/docs/concepts/architecture/nodes/
 func (i *Inspector) Nodes(ctx context.Context) (int, error) {
-	nodes, err := i.K8sClient.CoreV1().Nodes().List(ctx, metav1.ListOptions{})
+	nodes, err := listPages(ctx, i, "nodes", i.K8sClient.CoreV1().Nodes().List)
 	if err != nil {
 		return 0, err
 	}
@@ -130,7 +292,7 @@ func (i *Inspector) Nodes(ctx context.Context) (int, error) {
 // [pods]: https://kubernetes.io/docs/concepts/workloads/pods/
 // [namespace]: https://kubernetes.io/docs/concepts/overview/working-with-objects/namespaces/
 func (i *Inspector) Pods(ctx context.Context, namespace string) (*corev1.PodList, error) {
-	pods, err := i.K8sClient.CoreV1().Pods(namespace).List(ctx, metav1.ListOptions{})
+	pods, err := listPages(ctx, i, "pods", i.K8sClient.CoreV1().Pods(namespace).List)
 	if err != nil {
 		return nil, err
 	}
@@ -149,19 +311,24 @@ type PodLog struct {
 // [pods]: https://kubernetes.io/docs/concepts/workloads/pods/
 // [namespace]: https://kubernetes.io/docs/concepts/overview/working-with-objects/namespaces/
 func (i *Inspector) Podlogs(ctx context.Context, namespace string) ([]PodLog, error) {
-	pods, err := i.K8sClient.CoreV1().Pods(namespace).List(ctx, metav1.ListOptions{})
+	pods, err := listPages(ctx, i, "pod_logs", i.K8sClient.CoreV1().Pods(namespace).List)
 	if err != nil {
 		return nil, err
 	}
 	logs := []PodLog{}
 	for _, pod := range pods.Items {
+		if !i.matchesNames("pod_logs", pod.Name) {
+			continue
+		}
 		for _, container := range pod.Spec.Containers {
-			logReq := i.K8sClient.CoreV1().Pods(namespace).GetLogs(pod.Name, &corev1.PodLogOptions{Container: container.Name})
-			res, err := logReq.Stream(ctx)
-			if err != nil {
-				return nil, err
-			}
-			log, err := io.ReadAll(res)
+			log, err := retryWithTimeout(ctx, i, 0, func(ctx context.Context) ([]byte, error) {
+				res, err := i.K8sClient.CoreV1().Pods(namespace).GetLogs(pod.Name, &corev1.PodLogOptions{Container: container.Name}).Stream(ctx)
+				if err != nil {
+					return nil, err
+				}
+				defer res.Close()
+				return io.ReadAll(res)
+			})
 			if err != nil {
 				return []PodLog{}, err
 			}
@@ -180,7 +347,7 @@ func (i *Inspector) Podlogs(ctx context.Context, namespace string) ([]PodLog, er
 //
 // [events]: https://kubernetes.io/docs/reference/kubectl/generated/kubectl_events/
 func (i *Inspector) Events(ctx context.Context, namespace string) (*corev1.EventList, error) {
-	events, err := i.K8sClient.CoreV1().Events(namespace).List(ctx, metav1.ListOptions{})
+	events, err := listPages(ctx, i, "events", i.K8sClient.CoreV1().Events(namespace).List)
 	if err != nil {
 		return nil, err
 	}
@@ -191,7 +358,7 @@ func (i *Inspector) Events(ctx context.Context, namespace string) (*corev1.Event
 //
 // [config maps]: https://kubernetes.io/docs/concepts/configuration/configmap/
 func (i *Inspector) ConfigMaps(ctx context.Context, namespace string) (*corev1.ConfigMapList, error) {
-	configMaps, err := i.K8sClient.CoreV1().ConfigMaps(namespace).List(ctx, metav1.ListOptions{})
+	configMaps, err := listPages(ctx, i, "config_maps", i.K8sClient.CoreV1().ConfigMaps(namespace).List)
 	if err != nil {
 		return nil, err
 	}
@@ -203,7 +370,7 @@ func (i *Inspector) ConfigMaps(ctx context.Context, namespace string) (*corev1.C
 //
 // [services]: https://kubernetes.io/docs/concepts/services-networking/service/
 func (i *Inspector) Services(ctx context.Context, namespace string) (*corev1.ServiceList, error) {
-	services, err := i.K8sClient.CoreV1().Services(namespace).List(ctx, metav1.ListOptions{})
+	services, err := listPages(ctx, i, "services", i.K8sClient.CoreV1().Services(namespace).List)
 	if err != nil {
 		return nil, err
 	}
@@ -214,7 +381,7 @@ func (i *Inspector) Services(ctx context.Context, namespace string) (*corev1.Ser
 //
 // [deployments]: https://kubernetes.io/docs/concepts/workloads/controllers/deployment/
 func (i *Inspector) Deployments(ctx context.Context, namespace string) (*appsv1.DeploymentList, error) {
-	deployments, err := i.K8sClient.AppsV1().Deployments(namespace).List(ctx, metav1.ListOptions{})
+	deployments, err := listPages(ctx, i, "deployments", i.K8sClient.AppsV1().Deployments(namespace).List)
 	if err != nil {
 		return nil, err
 	}
@@ -225,7 +392,7 @@ func (i *Inspector) Deployments(ctx context.Context, namespace string) (*appsv1.
 //
 // [stateful set]: https://kubernetes.io/docs/concepts/workloads/controllers/statefulset/
 func (i *Inspector) StatefulSets(ctx context.Context, namespace string) (*appsv1.StatefulSetList, error) {
-	statefulSets, err := i.K8sClient.AppsV1().StatefulSets(namespace).List(ctx, metav1.ListOptions{})
+	statefulSets, err := listPages(ctx, i, "stateful_sets", i.K8sClient.AppsV1().StatefulSets(namespace).List)
 	if err != nil {
 		return nil, err
 	}
@@ -236,7 +403,7 @@ func (i *Inspector) StatefulSets(ctx context.Context, namespace string) (*appsv1
 //
 // [replica sets]: https://kubernetes.io/docs/concepts/workloads/controllers/replicaset/
 func (i *Inspector) ReplicaSets(ctx context.Context, namespace string) (*appsv1.ReplicaSetList, error) {
-	replicaSets, err := i.K8sClient.AppsV1().ReplicaSets(namespace).List(ctx, metav1.ListOptions{})
+	replicaSets, err := listPages(ctx, i, "replica_sets", i.K8sClient.AppsV1().ReplicaSets(namespace).List)
 	if err != nil {
 		return nil, err
 	}
@@ -247,7 +414,7 @@ func (i *Inspector) ReplicaSets(ctx context.Context, namespace string) (*appsv1.
 //
 // [leases]: https://kubernetes.io/docs/concepts/architecture/leases/
 func (i *Inspector) Leases(ctx context.Context, namespace string) (*coordv1.LeaseList, error) {
-	leases, err := i.K8sClient.CoordinationV1().Leases(namespace).List(ctx, metav1.ListOptions{})
+	leases, err := listPages(ctx, i, "leases", i.K8sClient.CoordinationV1().Leases(namespace).List)
 	if err != nil {
 		return nil, err
 	}
@@ -258,7 +425,7 @@ func (i *Inspector) Leases(ctx context.Context, namespace string) (*coordv1.Leas
 //
 // [ingress classes]: https://kubernetes.io/docs/concepts/services-networking/ingress/#ingress-class
 func (i *Inspector) IngressClasses(ctx context.Context) (*netv1.IngressClassList, error) {
-	ingressClasses, err := i.K8sClient.NetworkingV1().IngressClasses().List(ctx, metav1.ListOptions{})
+	ingressClasses, err := listPages(ctx, i, "ingress_classes", i.K8sClient.NetworkingV1().IngressClasses().List)
 	if err != nil {
 		return nil, err
 	}
@@ -269,7 +436,7 @@ func (i *Inspector) IngressClasses(ctx context.Context) (*netv1.IngressClassList
 //
 // [ingresses]: https://kubernetes.io/docs/concepts/services-networking/ingress/
 func (i *Inspector) Ingresses(ctx context.Context, namespace string) (*netv1.IngressList, error) {
-	ingresses, err := i.K8sClient.NetworkingV1().Ingresses(namespace).List(ctx, metav1.ListOptions{})
+	ingresses, err := listPages(ctx, i, "ingresses", i.K8sClient.NetworkingV1().Ingresses(namespace).List)
 	if err != nil {
 		return nil, err
 	}
@@ -280,7 +447,7 @@ func (i *Inspector) Ingresses(ctx context.Context, namespace string) (*netv1.Ing
 //
 // [CRDs]: https://kubernetes.io/docs/concepts/extend-kubernetes/api-extension/custom-resources/
 func (i *Inspector) CustomResourceDefinitions(ctx context.Context) (*apiextv1.CustomResourceDefinitionList, error) {
-	crds, err := i.CRDClient.ApiextensionsV1().CustomResourceDefinitions().List(ctx, metav1.ListOptions{})
+	crds, err := listPages(ctx, i, "crds", i.CRDClient.ApiextensionsV1().CustomResourceDefinitions().List)
 	if err != nil {
 		return nil, err
 	}
@@ -292,7 +459,7 @@ func (i *Inspector) CustomResourceDefinitions(ctx context.Context) (*apiextv1.Cu
 // [nodes]: https://kubernetes.io/docs/concepts/architecture/nodes/
 // [cluster]: https://kubernetes.io/docs/concepts/cluster-administration/
 func (i *Inspector) ClusterNodes(ctx context.Context) (*corev1.NodeList, error) {
-	nodes, err := i.K8sClient.CoreV1().Nodes().List(ctx, metav1.ListOptions{})
+	nodes, err := listPages(ctx, i, "cluster_nodes", i.K8sClient.CoreV1().Nodes().List)
 	if err != nil {
 		return nil, err
 	}
@@ -303,7 +470,7 @@ func (i *Inspector) ClusterNodes(ctx context.Context) (*corev1.NodeList, error)
 //
 // [node metrics]: https://kubernetes.io/docs/concepts/cluster-administration/system-metrics/
 func (i *Inspector) NodeMetrics(ctx context.Context) (*v1beta1.NodeMetricsList, error) {
-	metrics, err := i.MetricsClient.MetricsV1beta1().NodeMetricses().List(ctx, metav1.ListOptions{})
+	metrics, err := listPages(ctx, i, "node_metrics", i.MetricsClient.MetricsV1beta1().NodeMetricses().List)
 	if err != nil {
 		return nil, err
 	}
@@ -314,116 +481,211 @@ func (i *Inspector) NodeMetrics(ctx context.Context) (*v1beta1.NodeMetricsList,
 //
 // [pods metrics]: https://kubernetes.io/docs/concepts/cluster-administration/kube-state-metrics/
 func (i *Inspector) PodMetrics(ctx context.Context, namespace string) (*v1beta1.PodMetricsList, error) {
-	metrics, err := i.MetricsClient.MetricsV1beta1().PodMetricses(namespace).List(ctx, metav1.ListOptions{})
+	metrics, err := listPages(ctx, i, "pod_metrics", i.MetricsClient.MetricsV1beta1().PodMetricses(namespace).List)
 	if err != nil {
 		return nil, err
 	}
 	return metrics, nil
 }
 
-// RunDiagnostics collects cluster data points for a given namespace.
+// Report collects cluster data points for a given namespace.
+//
+// The cluster version is collected first to verify the cluster is
+// reachable. Failures of other collectors do not stop the collection.
+// They are recorded in the report and returned as a [PartialCollectionError]
+// together with the partially filled report.
 func (i *Inspector) Report(ctx context.Context, namespace string) (Report, error) {
-	version, err := i.ClusterVersion()
-	if err != nil {
-		return Report{}, err
-	}
-	id, err := i.ClusterID(ctx)
-	if err != nil {
-		return Report{}, err
-	}
-	n, err := i.Nodes(ctx)
-	if err != nil {
-		return Report{}, err
-	}
-	p, err := i.Platform(ctx)
-	if err != nil {
-		return Report{}, err
-	}
-
-	pods, err := i.Pods(ctx, namespace)
-	if err != nil {
-		return Report{}, err
-	}
-
-	podLogs, err := i.Podlogs(ctx, namespace)
-	if err != nil {
-		return Report{}, err
-	}
-
-	events, err := i.Events(ctx, namespace)
-	if err != nil {
-		return Report{}, err
-	}
-
-	configMaps, err := i.ConfigMaps(ctx, namespace)
-	if err != nil {
-		return Report{}, err
-	}
-
-	services, err := i.Services(ctx, namespace)
-	if err != nil {
-		return Report{}, err
-	}
-
-	deployments, err := i.Deployments(ctx, namespace)
-	if err != nil {
-		return Report{}, err
-	}
-
-	statefulSets, err := i.StatefulSets(ctx, namespace)
-	if err != nil {
-		return Report{}, err
-	}
-
-	replicaSets, err := i.ReplicaSets(ctx, namespace)
-	if err != nil {
-		return Report{}, err
-	}
-
-	leases, err := i.Leases(ctx, namespace)
-	if err != nil {
-		return Report{}, err
-	}
-
-	ingressClasses, err := i.IngressClasses(ctx)
-	if err != nil {
-		return Report{}, err
-	}
-
-	ingresses, err := i.Ingresses(ctx, namespace)
-	if err != nil {
-		return Report{}, err
-	}
-
-	crds, err := i.CustomResourceDefinitions(ctx)
-	if err != nil {
+	started := time.Now()
+	versionCtx, versionRetries := withRetryScope(ctx, "k8s_version")
+	version, err := retry(versionCtx, i, func(context.Context) (string, error) {
+		return i.ClusterVersion()
+	})
+	if err != nil {
+		return Report{}, newCollectorError("k8s_version", err)
+	}
+
+	logger := i.logger()
+	logger.Info("collection started", "namespace", namespace, "k8s_version", version)
+	start := time.Now()
+	c := &collection{
+		ctx:      ctx,
+		logger:   logger,
+		selected: i.selectedCollectors(),
+		names: func(collector string) []string {
+			return i.selector(collector).Names
+		},
+	}
+	c.recordDuration("k8s_version", start.Sub(started))
+	if versionRetries.retries > 0 {
+		c.retries = map[string]int{"k8s_version": versionRetries.retries}
+	}
+	id := collect(c, "cluster_id", i.ClusterID)
+	n := collect(c, "nodes", i.Nodes)
+	p := collect(c, "platform", i.Platform)
+	pods := collect(c, "pods", func(ctx context.Context) (*corev1.PodList, error) {
+		return i.Pods(ctx, namespace)
+	})
+	podLogs := collect(c, "pod_logs", func(ctx context.Context) ([]PodLog, error) {
+		return i.Podlogs(ctx, namespace)
+	})
+	events := collect(c, "events", func(ctx context.Context) (*corev1.EventList, error) {
+		return i.Events(ctx, namespace)
+	})
+	eventsV1 := collect(c, "events_v1", func(ctx context.Context) (*eventsv1.EventList, error) {
+		return i.EventsV1(ctx, namespace)
+	})
+	configMaps := collect(c, "config_maps", func(ctx context.Context) (*corev1.ConfigMapList, error) {
+		return i.ConfigMaps(ctx, namespace)
+	})
+	secrets := collect(c, "secrets", func(ctx context.Context) ([]SecretInfo, error) {
+		return i.Secrets(ctx, namespace)
+	})
+	services := collect(c, "services", func(ctx context.Context) (*corev1.ServiceList, error) {
+		return i.Services(ctx, namespace)
+	})
+	deployments := collect(c, "deployments", func(ctx context.Context) (*appsv1.DeploymentList, error) {
+		return i.Deployments(ctx, namespace)
+	})
+	statefulSets := collect(c, "stateful_sets", func(ctx context.Context) (*appsv1.StatefulSetList, error) {
+		return i.StatefulSets(ctx, namespace)
+	})
+	replicaSets := collect(c, "replica_sets", func(ctx context.Context) (*appsv1.ReplicaSetList, error) {
+		return i.ReplicaSets(ctx, namespace)
+	})
+	controllerRevisions := collect(c, "controller_revisions", func(ctx context.Context) (*appsv1.ControllerRevisionList, error) {
+		return i.ControllerRevisions(ctx, namespace)
+	})
+	daemonSets := collect(c, "daemon_sets", func(ctx context.Context) (*appsv1.DaemonSetList, error) {
+		return i.DaemonSets(ctx, namespace)
+	})
+	jobs := collect(c, "jobs", func(ctx context.Context) (*batchv1.JobList, error) {
+		return i.Jobs(ctx, namespace)
+	})
+	cronJobs := collect(c, "cron_jobs", func(ctx context.Context) (*batchv1.CronJobList, error) {
+		return i.CronJobs(ctx, namespace)
+	})
+	leases := collect(c, "leases", func(ctx context.Context) (*coordv1.LeaseList, error) {
+		return i.Leases(ctx, namespace)
+	})
+	ingressClasses := collect(c, "ingress_classes", i.IngressClasses)
+	ingresses := collect(c, "ingresses", func(ctx context.Context) (*netv1.IngressList, error) {
+		return i.Ingresses(ctx, namespace)
+	})
+	endpointSlices := collect(c, "endpoint_slices", func(ctx context.Context) (*discoveryv1.EndpointSliceList, error) {
+		return i.EndpointSlices(ctx, namespace)
+	})
+	endpoints := collect(c, "endpoints", func(ctx context.Context) (*corev1.EndpointsList, error) {
+		return i.Endpoints(ctx, namespace)
+	})
+	networkPolicies := collect(c, "network_policies", func(ctx context.Context) (*netv1.NetworkPolicyList, error) {
+		return i.NetworkPolicies(ctx, namespace)
+	})
+	pvcs := collect(c, "persistent_volume_claims", func(ctx context.Context) (*corev1.PersistentVolumeClaimList, error) {
+		return i.PersistentVolumeClaims(ctx, namespace)
+	})
+	pvs := collect(c, "persistent_volumes", func(ctx context.Context) (*corev1.PersistentVolumeList, error) {
+		return i.PersistentVolumes(ctx, namespace)
+	})
+	storageClasses := collect(c, "storage_classes", i.StorageClasses)
+	volumeAttachments := collect(c, "volume_attachments", i.VolumeAttachments)
+	csiDrivers := collect(c, "csi_drivers", i.CSIDrivers)
+	hpas := collect(c, "horizontal_pod_autoscalers", func(ctx context.Context) (*autoscalingv2.HorizontalPodAutoscalerList, error) {
+		return i.HorizontalPodAutoscalers(ctx, namespace)
+	})
+	pdbs := collect(c, "pod_disruption_budgets", func(ctx context.Context) (*policyv1.PodDisruptionBudgetList, error) {
+		return i.PodDisruptionBudgets(ctx, namespace)
+	})
+	resourceQuotas := collect(c, "resource_quotas", func(ctx context.Context) (*corev1.ResourceQuotaList, error) {
+		return i.ResourceQuotas(ctx, namespace)
+	})
+	limitRanges := collect(c, "limit_ranges", func(ctx context.Context) (*corev1.LimitRangeList, error) {
+		return i.LimitRanges(ctx, namespace)
+	})
+	serviceAccounts := collect(c, "service_accounts", func(ctx context.Context) (*corev1.ServiceAccountList, error) {
+		return i.ServiceAccounts(ctx, namespace)
+	})
+	roles := collect(c, "roles", func(ctx context.Context) (*rbacv1.RoleList, error) {
+		return i.Roles(ctx, namespace)
+	})
+	roleBindings := collect(c, "role_bindings", func(ctx context.Context) (*rbacv1.RoleBindingList, error) {
+		return i.RoleBindings(ctx, namespace)
+	})
+	clusterRoleBindings := collect(c, "cluster_role_bindings", func(ctx context.Context) (*rbacv1.ClusterRoleBindingList, error) {
+		return i.ClusterRoleBindings(ctx, namespace)
+	})
+	clusterRoles := collect(c, "cluster_roles", func(ctx context.Context) (*rbacv1.ClusterRoleList, error) {
+		return i.ClusterRoles(ctx, roleBindings, clusterRoleBindings)
+	})
+	accessChecks := collect(c, "access_checks", func(ctx context.Context) ([]AccessCheck, error) {
+		checks := []AccessCheck{}
+		for _, sa := range IngressControllerServiceAccounts(pods) {
+			saChecks, err := i.CheckServiceAccountAccess(ctx, namespace, sa, IngressControllerAccess)
+			if err != nil {
+				return nil, err
+			}
+			checks = append(checks, saChecks...)
+		}
+		return checks, nil
+	})
+	crds := collect(c, "crds", i.CustomResourceDefinitions)
+	clusterNodes := collect(c, "cluster_nodes", i.ClusterNodes)
+
+	logger.Info("collection finished", "namespace", namespace, "duration", time.Since(start), "collectors", c.total, "failed", len(c.errors))
+
+	rep := Report{
+		Namespace:                namespace,
+		K8sVersion:               version,
+		ClusterID:                id,
+		Nodes:                    n,
+		Platform:                 p,
+		Pods:                     pods,
+		Podlogs:                  podLogs,
+		Events:                   events,
+		EventsV1:                 eventsV1,
+		ConfigMaps:               configMaps,
+		Secrets:                  secrets,
+		Services:                 services,
+		Deployments:              deployments,
+		StatefulSets:             statefulSets,
+		ReplicaSets:              replicaSets,
+		ControllerRevisions:      controllerRevisions,
+		DaemonSets:               daemonSets,
+		Jobs:                     jobs,
+		CronJobs:                 cronJobs,
+		Leases:                   leases,
+		IngressClasses:           ingressClasses,
+		Ingresses:                ingresses,
+		EndpointSlices:           endpointSlices,
+		Endpoints:                endpoints,
+		NetworkPolicies:          networkPolicies,
+		PersistentVolumeClaims:   pvcs,
+		PersistentVolumes:        pvs,
+		StorageClasses:           storageClasses,
+		VolumeAttachments:        volumeAttachments,
+		CSIDrivers:               csiDrivers,
+		HorizontalPodAutoscalers: hpas,
+		PodDisruptionBudgets:     pdbs,
+		ResourceQuotas:           resourceQuotas,
+		LimitRanges:              limitRanges,
+		ServiceAccounts:          serviceAccounts,
+		Roles:                    roles,
+		RoleBindings:             roleBindings,
+		ClusterRoles:             clusterRoles,
+		ClusterRoleBindings:      clusterRoleBindings,
+		AccessChecks:             accessChecks,
+		CRDs:                     crds,
+		ClusterNodes:             clusterNodes,
+		Errors:                   c.errors,
+		Metadata:                 i.metadata(started, c),
+	}
+	if err := redactReport(&rep, i.Redact); err != nil {
 		return Report{}, err
 	}
-
-	clusterNodes, err := i.ClusterNodes(ctx)
-	if err != nil {
+	rep.SetAnalysis(AnalyzeWithOptions(rep, i.analyzeOptions()))
+	if err := trimReport(&rep, i.Trim); err != nil {
 		return Report{}, err
 	}
-
-	return Report{
-		K8sVersion:     version,
-		ClusterID:      id,
-		Nodes:          n,
-		Platform:       p,
-		Pods:           pods,
-		Podlogs:        podLogs,
-		Events:         events,
-		ConfigMaps:     configMaps,
-		Services:       services,
-		Deployments:    deployments,
-		StatefulSets:   statefulSets,
-		ReplicaSets:    replicaSets,
-		Leases:         leases,
-		IngressClasses: ingressClasses,
-		Ingresses:      ingresses,
-		CRDs:           crds,
-		ClusterNodes:   clusterNodes,
-	}, nil
+	return rep, c.err()
 }
 
 // ReportJSON returns collected metrics in a JSON format.
@@ -435,64 +697,73 @@ func ReportJSON(rep Report) (string, error) {
 	return string(b), nil
 }
 
+// ReadReport reads a report saved in the JSON format. Reports written
+// with a newer major schema version than [SchemaVersion] are rejected.
+func ReadReport(r io.Reader) (Report, error) {
+	var rep Report
+	if err := json.NewDecoder(r).Decode(&rep); err != nil {
+		return Report{}, err
+	}
+	if err := checkSchemaVersion(rep.Metadata.SchemaVersion); err != nil {
+		return Report{}, err
+	}
+	return rep, nil
+}
+
 // Report holds collected data points.
 type Report struct {
-	K8sVersion     string                                 `json:"k8s_version"`
-	ClusterID      string                                 `json:"cluster_id"`
-	Nodes          int                                    `json:"nodes"`
-	Platform       string                                 `json:"platform"`
-	Pods           *corev1.PodList                        `json:"pods"`
-	Podlogs        []PodLog                               `json:"pod_logs"`
-	Events         *corev1.EventList                      `json:"events"`
-	ConfigMaps     *corev1.ConfigMapList                  `json:"config_maps"`
-	Services       *corev1.ServiceList                    `json:"services"`
-	Deployments    *appsv1.DeploymentList                 `json:"deployments"`
-	StatefulSets   *appsv1.StatefulSetList                `json:"stateful_sets"`
-	ReplicaSets    *appsv1.ReplicaSetList                 `json:"replica_sets"`
-	Leases         *coordv1.LeaseList                     `json:"leases"`
-	IngressClasses *netv1.IngressClassList                `json:"ingress_classes"`
-	Ingresses      *netv1.IngressList                     `json:"ingresses"`
-	CRDs           *apiextv1.CustomResourceDefinitionList `json:"crds"`
-	ClusterNodes   *corev1.NodeList                       `json:"cluster_nodes"`
+	Metadata                 Metadata                                   `json:"metadata"`
+	Namespace                string                                     `json:"namespace"`
+	K8sVersion               string                                     `json:"k8s_version"`
+	ClusterID                string                                     `json:"cluster_id"`
+	Nodes                    int                                        `json:"nodes"`
+	Platform                 string                                     `json:"platform"`
+	Pods                     *corev1.PodList                            `json:"pods"`
+	Podlogs                  []PodLog                                   `json:"pod_logs"`
+	Events                   *corev1.EventList                          `json:"events"`
+	EventsV1                 *eventsv1.EventList                        `json:"events_v1"`
+	Timeline                 EventTimeline                              `json:"timeline"`
+	ConfigMaps               *corev1.ConfigMapList                      `json:"config_maps"`
+	Secrets                  []SecretInfo                               `json:"secrets"`
+	Services                 *corev1.ServiceList                        `json:"services"`
+	Deployments              *appsv1.DeploymentList                     `json:"deployments"`
+	StatefulSets             *appsv1.StatefulSetList                    `json:"stateful_sets"`
+	ReplicaSets              *appsv1.ReplicaSetList                     `json:"replica_sets"`
+	ControllerRevisions      *appsv1.ControllerRevisionList             `json:"controller_revisions"`
+	Rollouts                 []RolloutHistory                           `json:"rollouts"`
+	DaemonSets               *appsv1.DaemonSetList                      `json:"daemon_sets"`
+	Jobs                     *batchv1.JobList                           `json:"jobs"`
+	CronJobs                 *batchv1.CronJobList                       `json:"cron_jobs"`
+	Leases                   *coordv1.LeaseList                         `json:"leases"`
+	IngressClasses           *netv1.IngressClassList                    `json:"ingress_classes"`
+	Ingresses                *netv1.IngressList                         `json:"ingresses"`
+	EndpointSlices           *discoveryv1.EndpointSliceList             `json:"endpoint_slices"`
+	Endpoints                *corev1.EndpointsList                      `json:"endpoints"`
+	NetworkPolicies          *netv1.NetworkPolicyList                   `json:"network_policies"`
+	Backends                 NetworkAnalysis                            `json:"backends"`
+	PersistentVolumeClaims   *corev1.PersistentVolumeClaimList          `json:"persistent_volume_claims"`
+	PersistentVolumes        *corev1.PersistentVolumeList               `json:"persistent_volumes"`
+	StorageClasses           *storagev1.StorageClassList                `json:"storage_classes"`
+	VolumeAttachments        *storagev1.VolumeAttachmentList            `json:"volume_attachments"`
+	CSIDrivers               *storagev1.CSIDriverList                   `json:"csi_drivers"`
+	HorizontalPodAutoscalers *autoscalingv2.HorizontalPodAutoscalerList `json:"horizontal_pod_autoscalers"`
+	PodDisruptionBudgets     *policyv1.PodDisruptionBudgetList          `json:"pod_disruption_budgets"`
+	ResourceQuotas           *corev1.ResourceQuotaList                  `json:"resource_quotas"`
+	LimitRanges              *corev1.LimitRangeList                     `json:"limit_ranges"`
+	ServiceAccounts          *corev1.ServiceAccountList                 `json:"service_accounts"`
+	Roles                    *rbacv1.RoleList                           `json:"roles"`
+	RoleBindings             *rbacv1.RoleBindingList                    `json:"role_bindings"`
+	ClusterRoles             *rbacv1.ClusterRoleList                    `json:"cluster_roles"`
+	ClusterRoleBindings      *rbacv1.ClusterRoleBindingList             `json:"cluster_role_bindings"`
+	AccessChecks             []AccessCheck                              `json:"access_checks"`
+	CRDs                     *apiextv1.CustomResourceDefinitionList     `json:"crds"`
+	ClusterNodes             *corev1.NodeList                           `json:"cluster_nodes"`
+	Findings                 []Finding                                  `json:"findings"`
+	Graph                    *OwnerGraph                                `json:"graph,omitempty"`
+	Errors                   []*CollectorError                          `json:"errors,omitempty"`
 }
 
-var usage = `Usage:
-
-	inspector [-h] [-v] [-n] namespace
-
-Collect K8s and Ingress Controller diagnostics in the given namespace.
-
-In verbose mode (-v), prints out progess, steps and all data points to stdout.`
-
 // Main runs the inspector program.
 func Main() int {
-	namespace := flag.String("n", "default", "K8s namespace")
-	verbose := flag.Bool("v", false, "verbose output")
-	help := flag.Bool("h", false, "show help")
-	flag.Parse()
-
-	if *help {
-		fmt.Println(usage)
-		return 0
-	}
-
-	i, err := BuildInspectorFromKubeConfig()
-	if err != nil {
-		fmt.Fprintf(os.Stderr, "%s\n", usage)
-		return 1
-	}
-	i.Verbose = *verbose
-
-	report, err := i.Report(context.Background(), *namespace)
-	if err != nil {
-		fmt.Fprintf(os.Stderr, "%s\n", usage)
-		return 1
-	}
-	rep, err := ReportJSON(report)
-	if err != nil {
-		fmt.Fprintf(os.Stderr, "%s\n", usage)
-		return 1
-	}
-	fmt.Println(rep)
-	return 0
+	return Run(os.Args[1:], os.Stdout, os.Stderr)
 }
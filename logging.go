@@ -0,0 +1,69 @@
+package inspector
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"reflect"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+// newLogger returns a logger writing records at or above
+// the given level to w in the text or JSON format.
+func newLogger(w io.Writer, format string, level slog.Level) *slog.Logger {
+	opts := &slog.HandlerOptions{Level: level}
+	if format == "json" {
+		return slog.New(slog.NewJSONHandler(w, opts))
+	}
+	return slog.New(slog.NewTextHandler(w, opts))
+}
+
+// parseLogLevel converts a level name to a slog level.
+func parseLogLevel(name string) (slog.Level, error) {
+	var level slog.Level
+	if err := level.UnmarshalText([]byte(strings.ToUpper(name))); err != nil {
+		return 0, &ConfigError{Err: fmt.Errorf("unknown log level %q", name)}
+	}
+	return level, nil
+}
+
+// logger returns the logger collectors report their progress to.
+func (i *Inspector) logger() *slog.Logger {
+	if i.Logger != nil {
+		return i.Logger
+	}
+	if i.Verbose {
+		return newLogger(os.Stderr, "text", slog.LevelInfo)
+	}
+	return slog.New(slog.DiscardHandler)
+}
+
+// objectCount returns the number of objects in collected data.
+// K8s lists and slices are counted by their items,
+// other values count as a single object.
+func objectCount(v any) int {
+	if obj, ok := v.(runtime.Object); ok && meta.IsListType(obj) {
+		return meta.LenList(obj)
+	}
+	rv := reflect.ValueOf(v)
+	switch rv.Kind() {
+	case reflect.Slice, reflect.Array, reflect.Map:
+		return rv.Len()
+	default:
+		return 1
+	}
+}
+
+// jsonSize returns the size of collected data encoded as JSON.
+func jsonSize(v any) int {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return 0
+	}
+	return len(b)
+}
@@ -0,0 +1,79 @@
+package inspector_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"testing"
+
+	"github.com/qba73/inspector"
+
+	apiextfake "k8s.io/apiextensions-apiserver/pkg/client/clientset/clientset/fake"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	k8sruntime "k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	k8stesting "k8s.io/client-go/testing"
+)
+
+func TestInspectorLogsCollectorProgress(t *testing.T) {
+	t.Parallel()
+
+	var buf bytes.Buffer
+	client := newTestClientset(kubeSystemNameSpace, clusterNode1, teaServiceDefaultNS)
+	client.PrependReactor("list", "leases", func(action k8stesting.Action) (bool, k8sruntime.Object, error) {
+		return true, nil, apierrors.NewForbidden(schema.GroupResource{Group: "coordination.k8s.io", Resource: "leases"}, "", errors.New("denied"))
+	})
+	i := &inspector.Inspector{
+		K8sClient: client,
+		CRDClient: apiextfake.NewSimpleClientset(),
+		Logger:    slog.New(slog.NewJSONHandler(&buf, &slog.HandlerOptions{Level: slog.LevelDebug})),
+	}
+	_, _ = i.Report(context.Background(), "default")
+
+	records := map[string]map[string]any{}
+	var started int
+	dec := json.NewDecoder(&buf)
+	for dec.More() {
+		var rec map[string]any
+		if err := dec.Decode(&rec); err != nil {
+			t.Fatal(err)
+		}
+		collector, _ := rec["collector"].(string)
+		switch rec["msg"] {
+		case "collector started":
+			started++
+		case "collector finished", "collector failed":
+			records[collector] = rec
+		}
+	}
+	if started != len(records) {
+		t.Errorf("want each started collector to finish, got %d started and %d finished", started, len(records))
+	}
+
+	services := records["services"]
+	if services["level"] != "INFO" || services["objects"] != float64(1) {
+		t.Errorf("want services collector finished with 1 object, got %v", services)
+	}
+	if b, _ := services["bytes"].(float64); b <= 0 {
+		t.Errorf("want collected bytes logged, got %v", services["bytes"])
+	}
+	if _, ok := services["duration"]; !ok {
+		t.Error("want collector duration logged")
+	}
+
+	leases := records["leases"]
+	if leases["level"] != "WARN" || leases["msg"] != "collector failed" {
+		t.Errorf("want failed leases collector logged as warning, got %v", leases)
+	}
+}
+
+func TestInspectorWithoutLoggerDoesNotPanic(t *testing.T) {
+	t.Parallel()
+
+	i := &inspector.Inspector{K8sClient: newTestClientset(kubeSystemNameSpace, clusterNode1), CRDClient: apiextfake.NewSimpleClientset()}
+	if _, err := i.Report(context.Background(), "default"); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,109 @@
+package inspector
+
+import (
+	"fmt"
+	"maps"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// SchemaVersion is the version of the report format described by
+// [ReportSchema]. The minor version changes when fields are added,
+// the major version when fields are removed or change their meaning.
+const SchemaVersion = "1.4"
+
+// Metadata describes how a report was collected.
+type Metadata struct {
+	// SchemaVersion is the version of the report format.
+	// It is empty in reports written before the format was versioned.
+	SchemaVersion string      `json:"schema_version"`
+	Inspector     VersionInfo `json:"inspector"`
+	Hostname      string      `json:"hostname,omitempty"`
+	// KubeContext is the kubeconfig context the report was collected from.
+	KubeContext string    `json:"kube_context,omitempty"`
+	StartedAt   time.Time `json:"started_at,omitzero"`
+	FinishedAt  time.Time `json:"finished_at,omitzero"`
+	// Durations maps collector names to their run times in milliseconds.
+	Durations map[string]int64 `json:"durations_ms,omitempty"`
+	// Options holds effective options of the collection.
+	Options map[string]string `json:"options,omitempty"`
+	// Retries maps collector names to the number of
+	// API calls the collectors retried.
+	Retries map[string]int `json:"retries,omitempty"`
+}
+
+// metadata returns metadata of a report collected since started.
+func (i *Inspector) metadata(started time.Time, c *collection) Metadata {
+	hostname, _ := os.Hostname()
+	return Metadata{
+		SchemaVersion: SchemaVersion,
+		Inspector:     BuildVersion(),
+		Hostname:      hostname,
+		KubeContext:   i.KubeContext,
+		StartedAt:     started.UTC(),
+		FinishedAt:    time.Now().UTC(),
+		Durations:     c.durations,
+		Options:       i.effectiveOptions(),
+		Retries:       c.retries,
+	}
+}
+
+// effectiveOptions returns the collection options set on the inspector
+// merged with Options. Unset selectors and filters are omitted.
+func (i *Inspector) effectiveOptions() map[string]string {
+	opts := map[string]string{
+		"page_size":   strconv.FormatInt(i.pageSize(), 10),
+		"max_retries": strconv.Itoa(i.Retry.MaxRetries),
+		"trim":        i.Trim.String(),
+	}
+	if i.Trim.Neat {
+		opts["neat"] = "true"
+	}
+	if len(i.Collectors) > 0 {
+		opts["collectors"] = strings.Join(i.Collectors, ",")
+	}
+	if i.LabelSelector != "" {
+		opts["label_selector"] = i.LabelSelector
+	}
+	if i.FieldSelector != "" {
+		opts["field_selector"] = i.FieldSelector
+	}
+	if len(i.Names) > 0 {
+		opts["names"] = strings.Join(i.Names, ",")
+	}
+	for collector, s := range i.Selectors {
+		if s.LabelSelector != "" {
+			opts["selectors."+collector+".label_selector"] = s.LabelSelector
+		}
+		if s.FieldSelector != "" {
+			opts["selectors."+collector+".field_selector"] = s.FieldSelector
+		}
+		if len(s.Names) > 0 {
+			opts["selectors."+collector+".names"] = strings.Join(s.Names, ",")
+		}
+	}
+	if i.CertExpiryWindow != 0 {
+		opts["cert_expiry_window"] = i.CertExpiryWindow.String()
+	}
+	if len(i.Redact) > 0 {
+		opts["redact_rules"] = strconv.Itoa(len(i.Redact))
+	}
+	maps.Copy(opts, i.Options)
+	return opts
+}
+
+// checkSchemaVersion returns an error if reports of the schema
+// version cannot be read by this version of the inspector.
+func checkSchemaVersion(version string) error {
+	if version == "" {
+		return nil
+	}
+	major, _, _ := strings.Cut(version, ".")
+	supported, _, _ := strings.Cut(SchemaVersion, ".")
+	if major != supported {
+		return fmt.Errorf("unsupported report schema version %s, expected %s.x", version, supported)
+	}
+	return nil
+}
@@ -0,0 +1,76 @@
+package inspector_test
+
+import (
+	"context"
+	"maps"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/qba73/inspector"
+)
+
+func TestInspectorReportRecordsCollectionMetadata(t *testing.T) {
+	t.Parallel()
+
+	i := &inspector.Inspector{
+		K8sClient:     newTestClientset(teaServiceDefaultNS),
+		Collectors:    []string{"services", "config_maps"},
+		LabelSelector: "app=tea",
+		KubeContext:   "kind-dev",
+		Options:       map[string]string{"profile": "minimal"},
+	}
+	report, err := i.Report(context.Background(), "default")
+	if err != nil {
+		t.Fatal(err)
+	}
+	m := report.Metadata
+	if m.SchemaVersion != inspector.SchemaVersion {
+		t.Errorf("want schema version %s, got %q", inspector.SchemaVersion, m.SchemaVersion)
+	}
+	if m.Inspector != inspector.BuildVersion() {
+		t.Errorf("want inspector version %+v, got %+v", inspector.BuildVersion(), m.Inspector)
+	}
+	if m.KubeContext != "kind-dev" {
+		t.Errorf("want kube context kind-dev, got %q", m.KubeContext)
+	}
+	if m.StartedAt.IsZero() || m.FinishedAt.Before(m.StartedAt) {
+		t.Errorf("want collection start before its end, got %v and %v", m.StartedAt, m.FinishedAt)
+	}
+	want := []string{"config_maps", "k8s_version", "services"}
+	got := slices.Sorted(maps.Keys(m.Durations))
+	if !cmp.Equal(want, got) {
+		t.Error(cmp.Diff(want, got))
+	}
+	wantOptions := map[string]string{
+		"collectors":     "services,config_maps",
+		"label_selector": "app=tea",
+		"max_retries":    "0",
+		"page_size":      "500",
+		"profile":        "minimal",
+		"trim":           "managed-fields,last-applied",
+	}
+	if !cmp.Equal(wantOptions, m.Options) {
+		t.Error(cmp.Diff(wantOptions, m.Options))
+	}
+}
+
+func TestReadReportRejectsUnsupportedSchemaVersion(t *testing.T) {
+	t.Parallel()
+
+	_, err := inspector.ReadReport(strings.NewReader(`{"metadata": {"schema_version": "2.0"}}`))
+	if err == nil {
+		t.Fatal("want error reading report of unsupported schema version")
+	}
+}
+
+func TestReadReportAcceptsNewerMinorAndUnversionedReports(t *testing.T) {
+	t.Parallel()
+
+	for _, doc := range []string{`{"metadata": {"schema_version": "1.99"}}`, `{"namespace": "default"}`} {
+		if _, err := inspector.ReadReport(strings.NewReader(doc)); err != nil {
+			t.Errorf("reading %s: %v", doc, err)
+		}
+	}
+}
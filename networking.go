@@ -0,0 +1,245 @@
+//lint:file-ignore SA1019 Legacy endpoints are collected on purpose for clusters without endpoint slices.
+
+package inspector
+
+import (
+	"context"
+	"sort"
+
+	corev1 "k8s.io/api/core/v1"
+	discoveryv1 "k8s.io/api/discovery/v1"
+	netv1 "k8s.io/api/networking/v1"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+)
+
+// EndpointSlices returns a list of [endpoint slices] in a given namespace.
+//
+// [endpoint slices]: https://kubernetes.io/docs/concepts/services-networking/endpoint-slices/
+func (i *Inspector) EndpointSlices(ctx context.Context, namespace string) (*discoveryv1.EndpointSliceList, error) {
+	slices, err := listPages(ctx, i, "endpoint_slices", i.K8sClient.DiscoveryV1().EndpointSlices(namespace).List)
+	if err != nil {
+		return nil, err
+	}
+	return slices, nil
+}
+
+// Endpoints returns a list of legacy [endpoints] in a given namespace.
+//
+// [endpoints]: https://kubernetes.io/docs/reference/kubernetes-api/service-resources/endpoints-v1/
+func (i *Inspector) Endpoints(ctx context.Context, namespace string) (*corev1.EndpointsList, error) {
+	endpoints, err := listPages(ctx, i, "endpoints", i.K8sClient.CoreV1().Endpoints(namespace).List)
+	if err != nil {
+		return nil, err
+	}
+	return endpoints, nil
+}
+
+// NetworkPolicies returns a list of [network policies] in a given namespace.
+//
+// [network policies]: https://kubernetes.io/docs/concepts/services-networking/network-policies/
+func (i *Inspector) NetworkPolicies(ctx context.Context, namespace string) (*netv1.NetworkPolicyList, error) {
+	policies, err := listPages(ctx, i, "network_policies", i.K8sClient.NetworkingV1().NetworkPolicies(namespace).List)
+	if err != nil {
+		return nil, err
+	}
+	return policies, nil
+}
+
+// EndpointCounts holds the number of endpoints by their condition.
+type EndpointCounts struct {
+	Ready       int `json:"ready"`
+	Serving     int `json:"serving"`
+	Terminating int `json:"terminating"`
+}
+
+// ServiceBackends describes endpoints backing a service
+// and network policies selecting the service pods.
+type ServiceBackends struct {
+	Service         ObjectRef      `json:"service"`
+	Endpoints       EndpointCounts `json:"endpoints"`
+	Pods            []string       `json:"pods"`
+	NetworkPolicies []string       `json:"network_policies"`
+}
+
+// IngressBackend describes a single backend referenced by an ingress rule.
+type IngressBackend struct {
+	Ingress         ObjectRef      `json:"ingress"`
+	Host            string         `json:"host,omitempty"`
+	Path            string         `json:"path,omitempty"`
+	Service         string         `json:"service"`
+	ServiceFound    bool           `json:"service_found"`
+	Endpoints       EndpointCounts `json:"endpoints"`
+	NetworkPolicies []string       `json:"network_policies"`
+}
+
+// NetworkAnalysis maps services and ingresses to their ready backends.
+type NetworkAnalysis struct {
+	Services  []ServiceBackends `json:"services"`
+	Ingresses []IngressBackend  `json:"ingresses"`
+}
+
+// AnalyzeBackends maps each service and each ingress backend to the number
+// of its ready, serving and terminating endpoints and to the network
+// policies that select the pods backing the service.
+//
+// Endpoint counts come from endpoint slices. Legacy endpoints are used
+// only for services that have no endpoint slices.
+func AnalyzeBackends(services *corev1.ServiceList, ingresses *netv1.IngressList, slices *discoveryv1.EndpointSliceList, endpoints *corev1.EndpointsList, pods *corev1.PodList, policies *netv1.NetworkPolicyList) NetworkAnalysis {
+	analysis := NetworkAnalysis{
+		Services:  []ServiceBackends{},
+		Ingresses: []IngressBackend{},
+	}
+	if services == nil {
+		services = &corev1.ServiceList{}
+	}
+	counts := endpointCounts(slices, endpoints)
+
+	byName := map[string]ServiceBackends{}
+	for _, svc := range services.Items {
+		backingPods := selectPods(svc.Spec.Selector, svc.Namespace, pods)
+		sb := ServiceBackends{
+			Service:         ObjectRef{Kind: "Service", Namespace: svc.Namespace, Name: svc.Name},
+			Endpoints:       counts[svc.Name],
+			Pods:            podNames(backingPods),
+			NetworkPolicies: selectingPolicies(backingPods, policies),
+		}
+		byName[svc.Name] = sb
+		analysis.Services = append(analysis.Services, sb)
+	}
+
+	if ingresses == nil {
+		return analysis
+	}
+	for _, ing := range ingresses.Items {
+		ref := ObjectRef{Kind: "Ingress", Namespace: ing.Namespace, Name: ing.Name}
+		if b := ing.Spec.DefaultBackend; b != nil && b.Service != nil {
+			analysis.Ingresses = append(analysis.Ingresses, ingressBackend(ref, "", "", b.Service.Name, byName))
+		}
+		for _, rule := range ing.Spec.Rules {
+			if rule.HTTP == nil {
+				continue
+			}
+			for _, p := range rule.HTTP.Paths {
+				if p.Backend.Service == nil {
+					continue
+				}
+				analysis.Ingresses = append(analysis.Ingresses, ingressBackend(ref, rule.Host, p.Path, p.Backend.Service.Name, byName))
+			}
+		}
+	}
+	return analysis
+}
+
+// ingressBackend builds an ingress backend entry for the named service.
+func ingressBackend(ing ObjectRef, host, path, service string, services map[string]ServiceBackends) IngressBackend {
+	ib := IngressBackend{
+		Ingress:         ing,
+		Host:            host,
+		Path:            path,
+		Service:         service,
+		NetworkPolicies: []string{},
+	}
+	if sb, ok := services[service]; ok {
+		ib.ServiceFound = true
+		ib.Endpoints = sb.Endpoints
+		ib.NetworkPolicies = sb.NetworkPolicies
+	}
+	return ib
+}
+
+// endpointCounts returns endpoint counts keyed by service name.
+func endpointCounts(slices *discoveryv1.EndpointSliceList, endpoints *corev1.EndpointsList) map[string]EndpointCounts {
+	counts := map[string]EndpointCounts{}
+	fromSlices := map[string]bool{}
+	if slices != nil {
+		for _, s := range slices.Items {
+			svc := s.Labels[discoveryv1.LabelServiceName]
+			if svc == "" {
+				continue
+			}
+			fromSlices[svc] = true
+			c := counts[svc]
+			for _, e := range s.Endpoints {
+				// Nil conditions should be interpreted as ready and serving.
+				if e.Conditions.Ready == nil || *e.Conditions.Ready {
+					c.Ready++
+				}
+				if e.Conditions.Serving == nil || *e.Conditions.Serving {
+					c.Serving++
+				}
+				if e.Conditions.Terminating != nil && *e.Conditions.Terminating {
+					c.Terminating++
+				}
+			}
+			counts[svc] = c
+		}
+	}
+	if endpoints != nil {
+		for _, ep := range endpoints.Items {
+			if fromSlices[ep.Name] {
+				continue
+			}
+			c := counts[ep.Name]
+			for _, subset := range ep.Subsets {
+				c.Ready += len(subset.Addresses)
+				c.Serving += len(subset.Addresses)
+			}
+			counts[ep.Name] = c
+		}
+	}
+	return counts
+}
+
+// selectPods returns pods in the namespace matching the service selector.
+// Services without a selector do not select any pods.
+func selectPods(selector map[string]string, namespace string, pods *corev1.PodList) []corev1.Pod {
+	if len(selector) == 0 || pods == nil {
+		return nil
+	}
+	sel := labels.SelectorFromSet(selector)
+	matched := []corev1.Pod{}
+	for _, pod := range pods.Items {
+		if pod.Namespace != namespace {
+			continue
+		}
+		if sel.Matches(labels.Set(pod.Labels)) {
+			matched = append(matched, pod)
+		}
+	}
+	return matched
+}
+
+// selectingPolicies returns names of network policies
+// selecting at least one of the given pods.
+func selectingPolicies(pods []corev1.Pod, policies *netv1.NetworkPolicyList) []string {
+	names := []string{}
+	if policies == nil {
+		return names
+	}
+	for _, np := range policies.Items {
+		sel, err := metav1.LabelSelectorAsSelector(&np.Spec.PodSelector)
+		if err != nil {
+			continue
+		}
+		for _, pod := range pods {
+			if pod.Namespace == np.Namespace && sel.Matches(labels.Set(pod.Labels)) {
+				names = append(names, np.Name)
+				break
+			}
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+// podNames returns sorted names of the given pods.
+func podNames(pods []corev1.Pod) []string {
+	names := make([]string, 0, len(pods))
+	for _, p := range pods {
+		names = append(names, p.Name)
+	}
+	sort.Strings(names)
+	return names
+}
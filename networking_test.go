@@ -0,0 +1,186 @@
+package inspector_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/qba73/inspector"
+
+	corev1 "k8s.io/api/core/v1"
+	discoveryv1 "k8s.io/api/discovery/v1"
+	netv1 "k8s.io/api/networking/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestInspectorListsEndpointSlicesInAGivenNamespace(t *testing.T) {
+	t.Parallel()
+
+	i := newTestInspector(cafeEndpointSlice)
+	got, err := i.EndpointSlices(context.Background(), "cafe")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got.Items) != 1 || got.Items[0].Name != "coffee-svc-abcde" {
+		t.Errorf("want endpoint slice coffee-svc-abcde, got %+v", got.Items)
+	}
+}
+
+func TestInspectorListsNetworkPoliciesInAGivenNamespace(t *testing.T) {
+	t.Parallel()
+
+	i := newTestInspector(cafeDenyAll, cafeAllowCoffee)
+	got, err := i.NetworkPolicies(context.Background(), "cafe")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got.Items) != 2 {
+		t.Errorf("want 2 network policies, got %d", len(got.Items))
+	}
+}
+
+func TestAnalyzeBackendsCountsEndpointsByCondition(t *testing.T) {
+	t.Parallel()
+
+	got := inspector.AnalyzeBackends(
+		&corev1.ServiceList{Items: []corev1.Service{*coffeeService}},
+		nil,
+		&discoveryv1.EndpointSliceList{Items: []discoveryv1.EndpointSlice{*cafeEndpointSlice}},
+		nil,
+		nil,
+		nil,
+	)
+	want := inspector.EndpointCounts{Ready: 2, Serving: 3, Terminating: 1}
+	if !cmp.Equal(want, got.Services[0].Endpoints) {
+		t.Error(cmp.Diff(want, got.Services[0].Endpoints))
+	}
+}
+
+func TestAnalyzeBackendsFallsBackToLegacyEndpoints(t *testing.T) {
+	t.Parallel()
+
+	got := inspector.AnalyzeBackends(
+		&corev1.ServiceList{Items: []corev1.Service{*coffeeService}},
+		nil,
+		nil,
+		&corev1.EndpointsList{Items: []corev1.Endpoints{*coffeeEndpoints}},
+		nil,
+		nil,
+	)
+	want := inspector.EndpointCounts{Ready: 1, Serving: 1}
+	if !cmp.Equal(want, got.Services[0].Endpoints) {
+		t.Error(cmp.Diff(want, got.Services[0].Endpoints))
+	}
+}
+
+func TestAnalyzeBackendsMapsIngressToServiceEndpointsAndPolicies(t *testing.T) {
+	t.Parallel()
+
+	got := inspector.AnalyzeBackends(
+		&corev1.ServiceList{Items: []corev1.Service{*coffeeService}},
+		&netv1.IngressList{Items: []netv1.Ingress{*cafeIngress}},
+		&discoveryv1.EndpointSliceList{Items: []discoveryv1.EndpointSlice{*cafeEndpointSlice}},
+		nil,
+		&corev1.PodList{Items: []corev1.Pod{*coffeePod, *teaPod}},
+		&netv1.NetworkPolicyList{Items: []netv1.NetworkPolicy{*cafeDenyAll, *cafeAllowCoffee, *cafeAllowTea}},
+	)
+	want := []inspector.IngressBackend{
+		{
+			Ingress:         inspector.ObjectRef{Kind: "Ingress", Namespace: "cafe", Name: "cafe-ingress"},
+			Host:            "cafe.example.com",
+			Path:            "/coffee",
+			Service:         "coffee-svc",
+			ServiceFound:    true,
+			Endpoints:       inspector.EndpointCounts{Ready: 2, Serving: 3, Terminating: 1},
+			NetworkPolicies: []string{"allow-coffee", "deny-all"},
+		},
+		{
+			Ingress:         inspector.ObjectRef{Kind: "Ingress", Namespace: "cafe", Name: "cafe-ingress"},
+			Host:            "cafe.example.com",
+			Path:            "/tea",
+			Service:         "tea-svc",
+			NetworkPolicies: []string{},
+		},
+	}
+	if !cmp.Equal(want, got.Ingresses) {
+		t.Error(cmp.Diff(want, got.Ingresses))
+	}
+}
+
+// Networking objects used for testing backend analysis.
+var (
+	boolTrue  = true
+	boolFalse = false
+
+	coffeeService = &corev1.Service{
+		ObjectMeta: metav1.ObjectMeta{Name: "coffee-svc", Namespace: "cafe"},
+		Spec: corev1.ServiceSpec{
+			Selector: map[string]string{"app": "coffee"},
+		},
+	}
+
+	cafeEndpointSlice = &discoveryv1.EndpointSlice{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "coffee-svc-abcde",
+			Namespace: "cafe",
+			Labels:    map[string]string{discoveryv1.LabelServiceName: "coffee-svc"},
+		},
+		Endpoints: []discoveryv1.Endpoint{
+			{Addresses: []string{"10.0.0.1"}, Conditions: discoveryv1.EndpointConditions{Ready: &boolTrue}},
+			{Addresses: []string{"10.0.0.2"}},
+			{Addresses: []string{"10.0.0.3"}, Conditions: discoveryv1.EndpointConditions{Ready: &boolFalse, Serving: &boolTrue, Terminating: &boolTrue}},
+		},
+	}
+
+	coffeeEndpoints = &corev1.Endpoints{
+		ObjectMeta: metav1.ObjectMeta{Name: "coffee-svc", Namespace: "cafe"},
+		Subsets: []corev1.EndpointSubset{
+			{Addresses: []corev1.EndpointAddress{{IP: "10.0.0.1"}}},
+		},
+	}
+
+	coffeePod = &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: "coffee-1", Namespace: "cafe", Labels: map[string]string{"app": "coffee"}},
+	}
+
+	teaPod = &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: "tea-1", Namespace: "cafe", Labels: map[string]string{"app": "tea"}},
+	}
+
+	cafeDenyAll = &netv1.NetworkPolicy{
+		ObjectMeta: metav1.ObjectMeta{Name: "deny-all", Namespace: "cafe"},
+	}
+
+	cafeAllowCoffee = &netv1.NetworkPolicy{
+		ObjectMeta: metav1.ObjectMeta{Name: "allow-coffee", Namespace: "cafe"},
+		Spec: netv1.NetworkPolicySpec{
+			PodSelector: metav1.LabelSelector{MatchLabels: map[string]string{"app": "coffee"}},
+		},
+	}
+
+	cafeAllowTea = &netv1.NetworkPolicy{
+		ObjectMeta: metav1.ObjectMeta{Name: "allow-tea", Namespace: "cafe"},
+		Spec: netv1.NetworkPolicySpec{
+			PodSelector: metav1.LabelSelector{MatchLabels: map[string]string{"app": "tea"}},
+		},
+	}
+
+	cafeIngress = &netv1.Ingress{
+		ObjectMeta: metav1.ObjectMeta{Name: "cafe-ingress", Namespace: "cafe"},
+		Spec: netv1.IngressSpec{
+			Rules: []netv1.IngressRule{
+				{
+					Host: "cafe.example.com",
+					IngressRuleValue: netv1.IngressRuleValue{
+						HTTP: &netv1.HTTPIngressRuleValue{
+							Paths: []netv1.HTTPIngressPath{
+								{Path: "/coffee", Backend: netv1.IngressBackend{Service: &netv1.IngressServiceBackend{Name: "coffee-svc"}}},
+								{Path: "/tea", Backend: netv1.IngressBackend{Service: &netv1.IngressServiceBackend{Name: "tea-svc"}}},
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+)
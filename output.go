@@ -0,0 +1,152 @@
+package inspector
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/yaml"
+)
+
+// StreamRecord is a line of a report in the jsonl format.
+// Objects of streamed collectors are written as they are listed,
+// a record each, followed by a record of the report collector
+// holding the rest of the report.
+type StreamRecord struct {
+	Namespace string `json:"namespace"`
+	Collector string `json:"collector"`
+	Object    any    `json:"object"`
+}
+
+// EncodeReport encodes the report in the json, yaml or jsonl format.
+func EncodeReport(rep Report, format string) ([]byte, error) {
+	if format == "jsonl" {
+		return encodeRecord(StreamRecord{Namespace: rep.Namespace, Collector: "report", Object: rep})
+	}
+	data, err := ReportJSON(rep)
+	if err != nil {
+		return nil, err
+	}
+	switch format {
+	case "json":
+		return []byte(data + "\n"), nil
+	case "yaml":
+		return yaml.JSONToYAML([]byte(data))
+	default:
+		return nil, &ConfigError{Err: fmt.Errorf("unknown report format %q", format)}
+	}
+}
+
+// encodeRecord encodes a jsonl record.
+func encodeRecord(r StreamRecord) ([]byte, error) {
+	b, err := json.Marshal(r)
+	if err != nil {
+		return nil, err
+	}
+	return append(b, '\n'), nil
+}
+
+// reportWriter writes reports to destinations of a sink.
+type reportWriter struct {
+	sink        Sink
+	destination string
+	format      string
+	// multi is set when several reports may go to the same destination,
+	// YAML documents are then separated with ---.
+	multi   bool
+	writers map[string]io.WriteCloser
+}
+
+func newReportWriter(sink Sink, destination, format string, multi bool) *reportWriter {
+	return &reportWriter{
+		sink:        sink,
+		destination: destination,
+		format:      format,
+		multi:       multi,
+		writers:     map[string]io.WriteCloser{},
+	}
+}
+
+// write writes the report collected from the namespace.
+func (w *reportWriter) write(namespace string, rep Report) error {
+	b, err := EncodeReport(rep, w.format)
+	if err != nil {
+		return err
+	}
+	dst, err := w.dst(namespace)
+	if err != nil {
+		return err
+	}
+	if w.format == "yaml" && w.multi {
+		if _, err := io.WriteString(dst, "---\n"); err != nil {
+			return err
+		}
+	}
+	_, err = dst.Write(b)
+	return err
+}
+
+// pageHandler returns a handler writing objects of streamed
+// collectors in the jsonl format as pages are listed.
+func (w *reportWriter) pageHandler(namespace string) PageHandler {
+	return func(collector string, page runtime.Object) error {
+		dst, err := w.dst(namespace)
+		if err != nil {
+			return err
+		}
+		items, err := meta.ExtractList(page)
+		if err != nil {
+			return err
+		}
+		for _, item := range items {
+			b, err := encodeRecord(StreamRecord{Namespace: namespace, Collector: collector, Object: item})
+			if err != nil {
+				return err
+			}
+			if _, err := dst.Write(b); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+}
+
+// dst returns the writer of reports collected from the namespace.
+func (w *reportWriter) dst(namespace string) (io.Writer, error) {
+	destination := strings.ReplaceAll(w.destination, "{namespace}", namespace)
+	if dst, ok := w.writers[destination]; ok {
+		return dst, nil
+	}
+	dst, err := w.sink.Create(context.Background(), destination)
+	if err != nil {
+		return nil, err
+	}
+	w.writers[destination] = dst
+	return dst, nil
+}
+
+// close closes report writers, storing the reports in the sink.
+func (w *reportWriter) close() error {
+	var errs []error
+	for destination, dst := range w.writers {
+		errs = append(errs, dst.Close())
+		delete(w.writers, destination)
+	}
+	return errors.Join(errs...)
+}
+
+// abort discards reports of writers not closed yet,
+// so reports of failed runs are not stored.
+func (w *reportWriter) abort() error {
+	var errs []error
+	for destination, dst := range w.writers {
+		errs = append(errs, abortWriter(dst))
+		delete(w.writers, destination)
+	}
+	return errors.Join(errs...)
+}
@@ -0,0 +1,167 @@
+package inspector
+
+import (
+	"context"
+	"fmt"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+// DefaultPageSize is the number of objects requested
+// per list call when Inspector.PageSize is not set.
+const DefaultPageSize = 500
+
+// maxListRestarts bounds how many times listing restarts
+// after its continue token expired.
+const maxListRestarts = 3
+
+// PageHandler receives pages of objects listed by streamed collectors.
+// Objects already passed to the handler are not repeated when listing
+// restarts after an expired continue token.
+type PageHandler func(collector string, page runtime.Object) error
+
+// unstreamedCollectors lists collectors whose objects are needed
+// to complete other collectors, or which filter listed objects.
+// Their pages are never passed to the page handler, so secrets
+// are never streamed with their values. Node and pod metrics are
+// listed outside of reports and always returned as lists.
+var unstreamedCollectors = map[string]bool{
+	"nodes":                 true,
+	"pods":                  true,
+	"pod_logs":              true,
+	"role_bindings":         true,
+	"cluster_role_bindings": true,
+	"cluster_roles":         true,
+	"persistent_volumes":    true,
+	"secrets":               true,
+	"node_metrics":          true,
+	"pod_metrics":           true,
+}
+
+// listPages lists objects of the collector page by page, following
+// continue tokens. Listing restarts from the first page when the API
+// server reports the continue token expired. Pages of streamed collectors
+// are passed to the inspector page handler and no list is returned, so
+// analyzers treat the kind as not collected instead of as empty.
+func listPages[L runtime.Object](ctx context.Context, i *Inspector, collector string, list func(context.Context, metav1.ListOptions) (L, error)) (L, error) {
+	var (
+		result L
+		err    error
+		seen   map[types.UID]bool
+	)
+	stream := i.PageHandler != nil && !unstreamedCollectors[collector]
+	if stream {
+		seen = map[types.UID]bool{}
+	}
+	for restarts := 0; ; restarts++ {
+		result, err = listPagesOnce(ctx, i, collector, list, stream, seen)
+		if err == nil || restarts == maxListRestarts || !(apierrors.IsResourceExpired(err) || apierrors.IsGone(err)) {
+			return result, err
+		}
+		i.logger().Warn("continue token expired, listing restarts", "collector", collector, "restarts", restarts+1)
+	}
+}
+
+// listPagesOnce lists all pages starting from the first one.
+func listPagesOnce[L runtime.Object](ctx context.Context, i *Inspector, collector string, list func(context.Context, metav1.ListOptions) (L, error), stream bool, seen map[types.UID]bool) (L, error) {
+	var zero L
+	opts := i.listOptions(collector)
+	opts.Limit = i.pageSize()
+	var (
+		first L
+		items []runtime.Object
+	)
+	for page := 0; ; page++ {
+		l, err := retry(ctx, i, func(ctx context.Context) (L, error) {
+			return list(ctx, opts)
+		})
+		if err != nil {
+			return zero, err
+		}
+		pageItems, err := meta.ExtractList(l)
+		if err != nil {
+			return zero, fmt.Errorf("listing %s: %w", collector, err)
+		}
+		if stream {
+			if err := i.handlePage(collector, l, pageItems, seen); err != nil {
+				return zero, err
+			}
+			pageItems = nil
+		}
+		if page == 0 {
+			first = l
+		}
+		items = append(items, pageItems...)
+
+		listMeta, err := meta.ListAccessor(l)
+		if err != nil {
+			return zero, fmt.Errorf("listing %s: %w", collector, err)
+		}
+		if listMeta.GetContinue() == "" {
+			if stream {
+				return zero, nil
+			}
+			if page == 0 {
+				return first, nil
+			}
+			break
+		}
+		opts.Continue = listMeta.GetContinue()
+	}
+	if err := meta.SetList(first, items); err != nil {
+		return zero, fmt.Errorf("listing %s: %w", collector, err)
+	}
+	if listMeta, err := meta.ListAccessor(first); err == nil {
+		listMeta.SetContinue("")
+		listMeta.SetRemainingItemCount(nil)
+	}
+	return first, nil
+}
+
+// handlePage passes objects not seen before and matching name
+// patterns of the collector to the page handler, redacted and
+// trimmed like objects of the report.
+func (i *Inspector) handlePage(collector string, page runtime.Object, items []runtime.Object, seen map[types.UID]bool) error {
+	patterns := i.selector(collector).Names
+	redact, err := newRedactor(i.Redact)
+	if err != nil {
+		return err
+	}
+	kept := make([]runtime.Object, 0, len(items))
+	for _, item := range items {
+		obj, err := meta.Accessor(item)
+		if err != nil {
+			return err
+		}
+		if seen[obj.GetUID()] && obj.GetUID() != "" {
+			continue
+		}
+		seen[obj.GetUID()] = true
+		if !matchName(patterns, obj.GetName()) {
+			continue
+		}
+		if redact != nil {
+			redactObject(item, redact)
+		}
+		if err := trimObject(item, i.Trim); err != nil {
+			return err
+		}
+		kept = append(kept, item)
+	}
+	if err := meta.SetList(page, kept); err != nil {
+		return err
+	}
+	return i.PageHandler(collector, page)
+}
+
+// pageSize returns the number of objects requested per list call.
+func (i *Inspector) pageSize() int64 {
+	if i.PageSize > 0 {
+		return i.PageSize
+	}
+	return DefaultPageSize
+}
@@ -0,0 +1,234 @@
+package inspector_test
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/qba73/inspector"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	k8sruntime "k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	k8stesting "k8s.io/client-go/testing"
+	metricsv1beta1 "k8s.io/metrics/pkg/apis/metrics/v1beta1"
+	metricsfake "k8s.io/metrics/pkg/client/clientset/versioned/fake"
+)
+
+func TestInspectorListsObjectsPageByPage(t *testing.T) {
+	t.Parallel()
+
+	client := newTestClientset()
+	var requests []string
+	client.PrependReactor("list", "configmaps", pagedConfigMaps(5, &requests, nil))
+	i := &inspector.Inspector{K8sClient: client, PageSize: 2}
+
+	got, err := i.ConfigMaps(context.Background(), "default")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"cm-0", "cm-1", "cm-2", "cm-3", "cm-4"}; !cmp.Equal(want, configMapNames(got.Items)) {
+		t.Error(cmp.Diff(want, configMapNames(got.Items)))
+	}
+	if got.Continue != "" {
+		t.Errorf("want no continue token in merged list, got %q", got.Continue)
+	}
+	if want := []string{"limit=2 continue=", "limit=2 continue=2", "limit=2 continue=4"}; !cmp.Equal(want, requests) {
+		t.Error(cmp.Diff(want, requests))
+	}
+}
+
+func TestInspectorRestartsListingWhenContinueTokenExpires(t *testing.T) {
+	t.Parallel()
+
+	client := newTestClientset()
+	var requests []string
+	expired := map[string]bool{"4": true}
+	client.PrependReactor("list", "configmaps", pagedConfigMaps(5, &requests, expired))
+	i := &inspector.Inspector{K8sClient: client, PageSize: 2}
+
+	got, err := i.ConfigMaps(context.Background(), "default")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"cm-0", "cm-1", "cm-2", "cm-3", "cm-4"}; !cmp.Equal(want, configMapNames(got.Items)) {
+		t.Error(cmp.Diff(want, configMapNames(got.Items)))
+	}
+	if len(requests) != 6 {
+		t.Errorf("want listing restarted from the first page, got requests %v", requests)
+	}
+}
+
+func TestInspectorStreamsPagesToPageHandlerWithoutDuplicates(t *testing.T) {
+	t.Parallel()
+
+	client := newTestClientset()
+	var requests []string
+	expired := map[string]bool{"4": true}
+	client.PrependReactor("list", "configmaps", pagedConfigMaps(5, &requests, expired))
+	var streamed []string
+	i := &inspector.Inspector{
+		K8sClient: client,
+		PageSize:  2,
+		PageHandler: func(collector string, page k8sruntime.Object) error {
+			items, err := meta.ExtractList(page)
+			if err != nil {
+				return err
+			}
+			for _, item := range items {
+				streamed = append(streamed, collector+"/"+item.(*corev1.ConfigMap).Name)
+			}
+			return nil
+		},
+	}
+
+	got, err := i.ConfigMaps(context.Background(), "default")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got != nil {
+		t.Errorf("want no list of streamed objects, got %d objects", len(got.Items))
+	}
+	want := []string{"config_maps/cm-0", "config_maps/cm-1", "config_maps/cm-2", "config_maps/cm-3", "config_maps/cm-4"}
+	if !cmp.Equal(want, streamed) {
+		t.Error(cmp.Diff(want, streamed))
+	}
+}
+
+func TestInspectorReportLeavesStreamedCollectorsOutOfAnalysis(t *testing.T) {
+	t.Parallel()
+
+	client := newTestClientset(&corev1.Service{
+		ObjectMeta: metav1.ObjectMeta{Name: "web", Namespace: "default"},
+	})
+	streamed := 0
+	i := &inspector.Inspector{
+		K8sClient:  client,
+		Collectors: []string{"services"},
+		PageHandler: func(collector string, page k8sruntime.Object) error {
+			streamed += meta.LenList(page)
+			return nil
+		},
+	}
+
+	rep, err := i.Report(context.Background(), "default")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if streamed != 1 {
+		t.Errorf("want 1 streamed service, got %d", streamed)
+	}
+	if rep.Services != nil {
+		t.Errorf("want streamed services not collected in the report, got %d", len(rep.Services.Items))
+	}
+	if len(rep.Backends.Services) != 0 {
+		t.Errorf("want no backends analysed from streamed services, got %+v", rep.Backends.Services)
+	}
+}
+
+func TestInspectorListsPodMetricsInPages(t *testing.T) {
+	t.Parallel()
+
+	client := metricsfake.NewSimpleClientset()
+	var requests []string
+	client.PrependReactor("list", "pods", func(action k8stesting.Action) (bool, k8sruntime.Object, error) {
+		opts := action.(k8stesting.ListActionImpl).GetListOptions()
+		requests = append(requests, fmt.Sprintf("limit=%d continue=%s", opts.Limit, opts.Continue))
+		list := &metricsv1beta1.PodMetricsList{}
+		if opts.Continue == "" {
+			list.Continue = "1"
+			list.Items = []metricsv1beta1.PodMetrics{{ObjectMeta: metav1.ObjectMeta{Name: "web-0", Namespace: "default"}}}
+			return true, list, nil
+		}
+		list.Items = []metricsv1beta1.PodMetrics{{ObjectMeta: metav1.ObjectMeta{Name: "web-1", Namespace: "default"}}}
+		return true, list, nil
+	})
+	i := &inspector.Inspector{
+		MetricsClient: client,
+		PageSize:      1,
+		PageHandler:   func(string, k8sruntime.Object) error { return nil },
+	}
+
+	got, err := i.PodMetrics(context.Background(), "default")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var names []string
+	for _, m := range got.Items {
+		names = append(names, m.Name)
+	}
+	if want := []string{"web-0", "web-1"}; !cmp.Equal(want, names) {
+		t.Error(cmp.Diff(want, names))
+	}
+	if want := []string{"limit=1 continue=", "limit=1 continue=1"}; !cmp.Equal(want, requests) {
+		t.Error(cmp.Diff(want, requests))
+	}
+}
+
+func TestInspectorRedactsStreamedPages(t *testing.T) {
+	t.Parallel()
+
+	client := newTestClientset(&corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Name: "web", Namespace: "default"},
+		Data:       map[string]string{"pw": "secret123", "user": "admin"},
+	})
+	var streamed []map[string]string
+	i := &inspector.Inspector{
+		K8sClient: client,
+		Redact:    []inspector.RedactRule{{Name: "secrets", Pattern: "^secret.*"}},
+		PageHandler: func(collector string, page k8sruntime.Object) error {
+			for _, cm := range page.(*corev1.ConfigMapList).Items {
+				streamed = append(streamed, cm.Data)
+			}
+			return nil
+		},
+	}
+
+	if _, err := i.ConfigMaps(context.Background(), "default"); err != nil {
+		t.Fatal(err)
+	}
+	want := []map[string]string{{"pw": "REDACTED", "user": "admin"}}
+	if !cmp.Equal(want, streamed) {
+		t.Error(cmp.Diff(want, streamed))
+	}
+}
+
+// pagedConfigMaps returns a reactor listing n config maps in pages
+// of the requested size. Continue tokens hold the index of the next item.
+// Each token in expired fails once with 410 Gone.
+func pagedConfigMaps(n int, requests *[]string, expired map[string]bool) k8stesting.ReactionFunc {
+	return func(action k8stesting.Action) (bool, k8sruntime.Object, error) {
+		opts := action.(k8stesting.ListActionImpl).GetListOptions()
+		*requests = append(*requests, fmt.Sprintf("limit=%d continue=%s", opts.Limit, opts.Continue))
+		if expired[opts.Continue] {
+			delete(expired, opts.Continue)
+			return true, nil, apierrors.NewResourceExpired("continue token expired")
+		}
+		start, _ := strconv.Atoi(opts.Continue)
+		end := min(start+int(opts.Limit), n)
+		list := &corev1.ConfigMapList{}
+		for idx := start; idx < end; idx++ {
+			name := fmt.Sprintf("cm-%d", idx)
+			list.Items = append(list.Items, corev1.ConfigMap{
+				ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "default", UID: types.UID(name)},
+			})
+		}
+		if end < n {
+			list.Continue = strconv.Itoa(end)
+		}
+		return true, list, nil
+	}
+}
+
+func configMapNames(items []corev1.ConfigMap) []string {
+	var names []string
+	for _, cm := range items {
+		names = append(names, cm.Name)
+	}
+	return names
+}
@@ -0,0 +1,224 @@
+package inspector
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"slices"
+	"sort"
+	"strings"
+	"text/tabwriter"
+
+	authzv1 "k8s.io/api/authorization/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/yaml"
+)
+
+// PreflightCheck holds the result of checking whether the current
+// user is allowed to perform an action a collector needs.
+type PreflightCheck struct {
+	Collector string         `json:"collector"`
+	Namespace string         `json:"namespace,omitempty"`
+	Access    ResourceAccess `json:"access"`
+	Allowed   bool           `json:"allowed"`
+	Reason    string         `json:"reason,omitempty"`
+}
+
+// Preflight holds results of permission checks for all registered collectors.
+type Preflight struct {
+	Namespaces []string         `json:"namespaces"`
+	Checks     []PreflightCheck `json:"checks"`
+}
+
+// Preflight verifies with [SelfSubjectAccessReviews] whether the current
+// user can perform every action the registered collectors need in the given
+// namespaces. Cluster scoped access is checked once.
+//
+// [SelfSubjectAccessReviews]: https://kubernetes.io/docs/reference/access-authn-authz/authorization/#checking-api-access
+func (i *Inspector) Preflight(ctx context.Context, namespaces []string) (Preflight, error) {
+	type reviewKey struct {
+		namespace string
+		access    ResourceAccess
+	}
+	reviewed := map[reviewKey]authzv1.SubjectAccessReviewStatus{}
+
+	p := Preflight{Namespaces: namespaces}
+	for _, c := range Collectors {
+		for _, a := range c.Access {
+			scopes := []string{""}
+			if a.Namespaced {
+				scopes = namespaces
+			}
+			for _, ns := range scopes {
+				key := reviewKey{namespace: ns, access: a}
+				status, ok := reviewed[key]
+				if !ok {
+					var err error
+					status, err = i.selfAccessReview(ctx, ns, a)
+					if err != nil {
+						return Preflight{}, err
+					}
+					reviewed[key] = status
+				}
+				p.Checks = append(p.Checks, PreflightCheck{
+					Collector: c.Name,
+					Namespace: ns,
+					Access:    a,
+					Allowed:   status.Allowed,
+					Reason:    status.Reason,
+				})
+			}
+		}
+	}
+	return p, nil
+}
+
+// selfAccessReview checks if the current user has the given access in a namespace.
+func (i *Inspector) selfAccessReview(ctx context.Context, namespace string, a ResourceAccess) (authzv1.SubjectAccessReviewStatus, error) {
+	attrs := resourceAttributes(a, namespace)
+	review := &authzv1.SelfSubjectAccessReview{
+		Spec: authzv1.SelfSubjectAccessReviewSpec{ResourceAttributes: &attrs},
+	}
+	res, err := retry(ctx, i, func(ctx context.Context) (*authzv1.SelfSubjectAccessReview, error) {
+		return i.K8sClient.AuthorizationV1().SelfSubjectAccessReviews().Create(ctx, review, metav1.CreateOptions{})
+	})
+	if err != nil {
+		return authzv1.SubjectAccessReviewStatus{}, err
+	}
+	return res.Status, nil
+}
+
+// Passed reports whether all preflight checks passed.
+func (p Preflight) Passed() bool {
+	for _, c := range p.Checks {
+		if !c.Allowed {
+			return false
+		}
+	}
+	return true
+}
+
+// WriteMatrix writes a pass/fail matrix of preflight checks to w.
+func (p Preflight) WriteMatrix(w io.Writer) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "COLLECTOR\tNAMESPACE\tVERB\tRESOURCE\tRESULT")
+	for _, c := range p.Checks {
+		ns := c.Namespace
+		if ns == "" {
+			ns = "*"
+		}
+		result := "pass"
+		if !c.Allowed {
+			result = "FAIL"
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\n", c.Collector, ns, c.Access.Verb, qualifiedResource(c.Access), result)
+	}
+	return tw.Flush()
+}
+
+// qualifiedResource returns the resource name qualified with its API group.
+func qualifiedResource(a ResourceAccess) string {
+	if a.Group == "" {
+		return a.Resource
+	}
+	return a.Resource + "." + a.Group
+}
+
+// Manifest returns a YAML manifest with a ClusterRole granting cluster scoped
+// access and a Role in each checked namespace granting namespaced access
+// the registered collectors need. Kinds the watch and capture commands
+// follow with informers are granted watch as well. Roles are named after
+// the given name.
+func (p Preflight) Manifest(name string) (string, error) {
+	clusterAccess, namespacedAccess := collectorAccess(nil)
+	clusterAccess, namespacedAccess = watchAccess(clusterAccess), watchAccess(namespacedAccess)
+
+	objects := []any{
+		rbacv1.ClusterRole{
+			TypeMeta:   metav1.TypeMeta{APIVersion: "rbac.authorization.k8s.io/v1", Kind: "ClusterRole"},
+			ObjectMeta: metav1.ObjectMeta{Name: name},
+			Rules:      policyRules(clusterAccess),
+		},
+	}
+	for _, ns := range p.Namespaces {
+		objects = append(objects, rbacv1.Role{
+			TypeMeta:   metav1.TypeMeta{APIVersion: "rbac.authorization.k8s.io/v1", Kind: "Role"},
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: ns},
+			Rules:      policyRules(namespacedAccess),
+		})
+	}
+
+	docs := make([]string, 0, len(objects))
+	for _, o := range objects {
+		b, err := yaml.Marshal(o)
+		if err != nil {
+			return "", err
+		}
+		docs = append(docs, string(b))
+	}
+	return strings.Join(docs, "---\n"), nil
+}
+
+// watchAccess returns access extended with watch for every listed
+// resource of a watched collector.
+func watchAccess(access []ResourceAccess) []ResourceAccess {
+	watched := map[ResourceAccess]bool{}
+	for _, c := range Collectors {
+		if !slices.Contains(WatchedCollectors(), c.Name) {
+			continue
+		}
+		for _, a := range c.Access {
+			if a.Verb == "list" {
+				watched[a] = true
+			}
+		}
+	}
+	for _, a := range access {
+		if watched[a] {
+			a.Verb = "watch"
+			access = append(access, a)
+		}
+	}
+	return access
+}
+
+// policyRules builds RBAC rules granting the given access, one rule per
+// API group and resource, with sorted and deduplicated verbs.
+func policyRules(access []ResourceAccess) []rbacv1.PolicyRule {
+	type groupResource struct{ group, resource string }
+	verbs := map[groupResource]map[string]bool{}
+	for _, a := range access {
+		gr := groupResource{a.Group, a.Resource}
+		if verbs[gr] == nil {
+			verbs[gr] = map[string]bool{}
+		}
+		verbs[gr][a.Verb] = true
+	}
+	keys := make([]groupResource, 0, len(verbs))
+	for gr := range verbs {
+		keys = append(keys, gr)
+	}
+	sort.Slice(keys, func(a, b int) bool {
+		if keys[a].group != keys[b].group {
+			return keys[a].group < keys[b].group
+		}
+		return keys[a].resource < keys[b].resource
+	})
+
+	rules := make([]rbacv1.PolicyRule, 0, len(keys))
+	for _, gr := range keys {
+		vs := make([]string, 0, len(verbs[gr]))
+		for v := range verbs[gr] {
+			vs = append(vs, v)
+		}
+		sort.Strings(vs)
+		rules = append(rules, rbacv1.PolicyRule{
+			APIGroups: []string{gr.group},
+			Resources: []string{gr.resource},
+			Verbs:     vs,
+		})
+	}
+	return rules
+}
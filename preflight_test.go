@@ -0,0 +1,160 @@
+package inspector_test
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/qba73/inspector"
+
+	authzv1 "k8s.io/api/authorization/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
+	k8sruntime "k8s.io/apimachinery/pkg/runtime"
+	k8stesting "k8s.io/client-go/testing"
+	"sigs.k8s.io/yaml"
+)
+
+func TestInspectorPreflightChecksEveryCollectorInEveryNamespace(t *testing.T) {
+	t.Parallel()
+
+	i, reviews := newPreflightInspector(func(attrs *authzv1.ResourceAttributes) bool { return true })
+	got, err := i.Preflight(context.Background(), []string{"default", "nginx-ingress"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !got.Passed() {
+		t.Error("want preflight passed")
+	}
+	for _, c := range inspector.Collectors {
+		if len(c.Access) == 0 {
+			continue
+		}
+		found := false
+		for _, check := range got.Checks {
+			if check.Collector == c.Name {
+				found = true
+			}
+		}
+		if !found {
+			t.Errorf("collector %s not checked", c.Name)
+		}
+	}
+	for _, check := range got.Checks {
+		if check.Access.Namespaced && check.Namespace == "" {
+			t.Errorf("namespaced access %+v checked without namespace", check.Access)
+		}
+		if !check.Access.Namespaced && check.Namespace != "" {
+			t.Errorf("cluster access %+v checked in namespace %s", check.Access, check.Namespace)
+		}
+	}
+	if *reviews >= len(got.Checks) {
+		t.Errorf("want repeated access reviewed once, got %d reviews for %d checks", *reviews, len(got.Checks))
+	}
+}
+
+func TestInspectorPreflightReportsDeniedAccessInMatrix(t *testing.T) {
+	t.Parallel()
+
+	i, _ := newPreflightInspector(func(attrs *authzv1.ResourceAttributes) bool {
+		return attrs.Resource != "nodes"
+	})
+	got, err := i.Preflight(context.Background(), []string{"default"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.Passed() {
+		t.Error("want preflight failed")
+	}
+	var buf bytes.Buffer
+	if err := got.WriteMatrix(&buf); err != nil {
+		t.Fatal(err)
+	}
+	var failed []string
+	for _, line := range strings.Split(buf.String(), "\n") {
+		if strings.HasSuffix(line, "FAIL") {
+			failed = append(failed, strings.Fields(line)[0])
+		}
+	}
+	want := "nodes platform cluster_nodes"
+	if strings.Join(failed, " ") != want {
+		t.Errorf("want failed collectors %q, got %q", want, failed)
+	}
+}
+
+func TestPreflightManifestGrantsCollectorAccess(t *testing.T) {
+	t.Parallel()
+
+	p := inspector.Preflight{Namespaces: []string{"default", "nginx-ingress"}}
+	manifest, err := p.Manifest("inspector")
+	if err != nil {
+		t.Fatal(err)
+	}
+	docs := strings.Split(manifest, "---\n")
+	if len(docs) != 3 {
+		t.Fatalf("want ClusterRole and 2 Roles, got %d documents", len(docs))
+	}
+
+	var clusterRole rbacv1.ClusterRole
+	if err := yaml.Unmarshal([]byte(docs[0]), &clusterRole); err != nil {
+		t.Fatal(err)
+	}
+	if clusterRole.Kind != "ClusterRole" || clusterRole.Name != "inspector" {
+		t.Errorf("unexpected cluster role %s/%s", clusterRole.Kind, clusterRole.Name)
+	}
+	if !hasRule(clusterRole.Rules, "", "nodes", "list") {
+		t.Error("want cluster role granting list nodes")
+	}
+	if hasRule(clusterRole.Rules, "", "pods", "list") {
+		t.Error("want namespaced pods access not granted by cluster role")
+	}
+
+	var role rbacv1.Role
+	if err := yaml.Unmarshal([]byte(docs[2]), &role); err != nil {
+		t.Fatal(err)
+	}
+	if role.Namespace != "nginx-ingress" {
+		t.Errorf("want role in nginx-ingress namespace, got %s", role.Namespace)
+	}
+	if !hasRule(role.Rules, "", "pods/log", "get") {
+		t.Error("want role granting get pods/log")
+	}
+	if !hasRule(role.Rules, "", "pods", "watch") {
+		t.Error("want role granting watch pods")
+	}
+	if !hasRule(clusterRole.Rules, "", "persistentvolumes", "watch") {
+		t.Error("want cluster role granting watch persistentvolumes")
+	}
+	if hasRule(clusterRole.Rules, "", "nodes", "watch") {
+		t.Error("want unwatched nodes not granted watch")
+	}
+}
+
+// newPreflightInspector returns an inspector with a fake clientset answering
+// self subject access reviews with allow, and a counter of reviews made.
+func newPreflightInspector(allow func(*authzv1.ResourceAttributes) bool) (*inspector.Inspector, *int) {
+	client := newTestClientset()
+	reviews := 0
+	client.PrependReactor("create", "selfsubjectaccessreviews", func(action k8stesting.Action) (bool, k8sruntime.Object, error) {
+		review := action.(k8stesting.CreateAction).GetObject().(*authzv1.SelfSubjectAccessReview)
+		reviews++
+		review.Status.Allowed = allow(review.Spec.ResourceAttributes)
+		return true, review, nil
+	})
+	return &inspector.Inspector{K8sClient: client}, &reviews
+}
+
+// hasRule reports whether rules grant the verb on the resource.
+func hasRule(rules []rbacv1.PolicyRule, group, resource, verb string) bool {
+	for _, r := range rules {
+		if r.APIGroups[0] != group || r.Resources[0] != resource {
+			continue
+		}
+		for _, v := range r.Verbs {
+			if v == verb {
+				return true
+			}
+		}
+	}
+	return false
+}
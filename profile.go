@@ -0,0 +1,160 @@
+package inspector
+
+import (
+	"embed"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"regexp"
+	"slices"
+	"strings"
+
+	"sigs.k8s.io/yaml"
+)
+
+//go:embed profiles/*.yaml
+var builtinProfiles embed.FS
+
+// Profile declares what a collection gathers and where the report goes.
+// Profiles are read from YAML or JSON files, or picked from the
+// built-in profiles by name.
+type Profile struct {
+	Name        string `json:"name"`
+	Description string `json:"description,omitempty"`
+	// Collectors lists collectors to run. All collectors run when empty.
+	Collectors []string `json:"collectors,omitempty"`
+	// Namespaces lists namespaces to collect, one report each.
+	Namespaces []string `json:"namespaces,omitempty"`
+	// LabelSelector, FieldSelector and Names filter objects listed
+	// by namespaced collectors, except events.
+	LabelSelector string   `json:"label_selector,omitempty"`
+	FieldSelector string   `json:"field_selector,omitempty"`
+	Names         []string `json:"names,omitempty"`
+	// Selectors maps collector names to their selectors.
+	Selectors map[string]Selector `json:"selectors,omitempty"`
+	Log       LogOptions          `json:"log,omitempty"`
+	Redact    []RedactRule        `json:"redact,omitempty"`
+	Trim      TrimOptions         `json:"trim,omitzero"`
+	Output    OutputOptions       `json:"output,omitempty"`
+}
+
+// LogOptions selects the log level and format.
+type LogOptions struct {
+	Level  string `json:"level,omitempty"`
+	Format string `json:"format,omitempty"`
+}
+
+// OutputOptions selects the report format and destination.
+type OutputOptions struct {
+	// Format is json, yaml or jsonl.
+	Format string `json:"format,omitempty"`
+	// Destination is a file path, an s3://bucket/key destination or
+	// an http(s) upload URL, empty or - for stdout. A {namespace}
+	// placeholder writes one report per namespace.
+	Destination string `json:"destination,omitempty"`
+}
+
+// BuiltinProfiles returns names of profiles shipped with inspector.
+func BuiltinProfiles() []string {
+	entries, err := fs.ReadDir(builtinProfiles, "profiles")
+	if err != nil {
+		return nil
+	}
+	names := make([]string, 0, len(entries))
+	for _, e := range entries {
+		names = append(names, strings.TrimSuffix(e.Name(), path.Ext(e.Name())))
+	}
+	return names
+}
+
+// LoadProfile returns the built-in profile of the given name
+// or reads the profile from a file.
+func LoadProfile(nameOrPath string) (Profile, error) {
+	b, err := builtinProfiles.ReadFile("profiles/" + nameOrPath + ".yaml")
+	if err != nil {
+		b, err = os.ReadFile(nameOrPath)
+	}
+	if errors.Is(err, fs.ErrNotExist) {
+		return Profile{}, &ConfigError{Err: fmt.Errorf("profile %q is neither a built-in profile (%s) nor a file", nameOrPath, strings.Join(BuiltinProfiles(), ", "))}
+	}
+	if err != nil {
+		return Profile{}, &ConfigError{Err: err}
+	}
+	p, err := ParseProfile(b)
+	if err != nil {
+		return Profile{}, fmt.Errorf("profile %s: %w", nameOrPath, err)
+	}
+	return p, nil
+}
+
+// ParseProfile decodes and validates a profile in the YAML or JSON format.
+func ParseProfile(b []byte) (Profile, error) {
+	var p Profile
+	if err := yaml.UnmarshalStrict(b, &p); err != nil {
+		return Profile{}, &ConfigError{Err: err}
+	}
+	if err := p.validate(); err != nil {
+		return Profile{}, &ConfigError{Err: err}
+	}
+	return p, nil
+}
+
+// validate reports unknown collectors and invalid options.
+func (p Profile) validate() error {
+	for _, name := range p.Collectors {
+		if !isCollector(name) {
+			return fmt.Errorf("unknown collector %q", name)
+		}
+	}
+	if err := (Selector{Names: p.Names}).validate(); err != nil {
+		return err
+	}
+	for name, s := range p.Selectors {
+		if !isCollector(name) {
+			return fmt.Errorf("selector of unknown collector %q", name)
+		}
+		if err := s.validate(); err != nil {
+			return fmt.Errorf("selector of %s: %w", name, err)
+		}
+	}
+	if p.Log.Level != "" {
+		if _, err := parseLogLevel(p.Log.Level); err != nil {
+			return err
+		}
+	}
+	if p.Log.Format != "" {
+		if err := validateLogFormat(p.Log.Format); err != nil {
+			return err
+		}
+	}
+	switch p.Output.Format {
+	case "", "json", "yaml", "jsonl":
+	default:
+		return fmt.Errorf("unknown output format %q", p.Output.Format)
+	}
+	for _, r := range p.Redact {
+		if _, err := regexp.Compile(r.Pattern); err != nil {
+			return fmt.Errorf("redact rule %q: %w", r.Name, err)
+		}
+	}
+	return nil
+}
+
+// isCollector reports whether the collector is registered.
+func isCollector(name string) bool {
+	return slices.ContainsFunc(Collectors, func(c Collector) bool { return c.Name == name })
+}
+
+// Apply configures the inspector to run the profile collectors
+// with its selectors and redaction rules.
+func (p Profile) Apply(i *Inspector) {
+	i.Collectors = p.Collectors
+	i.LabelSelector = p.LabelSelector
+	i.FieldSelector = p.FieldSelector
+	i.Names = p.Names
+	i.Selectors = p.Selectors
+	i.Redact = p.Redact
+	i.Trim = p.Trim
+}
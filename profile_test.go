@@ -0,0 +1,178 @@
+package inspector_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/qba73/inspector"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	apiextfake "k8s.io/apiextensions-apiserver/pkg/client/clientset/clientset/fake"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	k8sruntime "k8s.io/apimachinery/pkg/runtime"
+)
+
+func TestBuiltinProfilesAreValid(t *testing.T) {
+	t.Parallel()
+
+	names := inspector.BuiltinProfiles()
+	want := []string{"full", "gateway", "ingress", "minimal"}
+	if !cmp.Equal(want, names) {
+		t.Fatal(cmp.Diff(want, names))
+	}
+	for _, name := range names {
+		p, err := inspector.LoadProfile(name)
+		if err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+		if p.Name != name {
+			t.Errorf("want profile name %s, got %s", name, p.Name)
+		}
+	}
+}
+
+func TestParseProfileRejectsInvalidProfiles(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		profile string
+	}{
+		{name: "unknown collector", profile: "name: x\ncollectors: [pods, gateways]\n"},
+		{name: "unknown field", profile: "name: x\ncolectors: [pods]\n"},
+		{name: "unknown output format", profile: "name: x\noutput:\n  format: xml\n"},
+		{name: "invalid redact pattern", profile: "name: x\nredact:\n  - name: token\n    pattern: '('\n"},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			_, err := inspector.ParseProfile([]byte(tc.profile))
+			if inspector.ExitCode(err) != inspector.ExitConfig {
+				t.Errorf("want config error, got %v", err)
+			}
+		})
+	}
+}
+
+func TestInspectorReportRunsOnlyProfileCollectors(t *testing.T) {
+	t.Parallel()
+
+	p, err := inspector.ParseProfile([]byte("name: pods\ncollectors: [pods, services]\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	i := &inspector.Inspector{K8sClient: newTestClientset(podDefaultNamespace, teaServiceDefaultNS)}
+	p.Apply(i)
+
+	report, err := i.Report(context.Background(), "default")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if report.Pods == nil || len(report.Pods.Items) != 1 {
+		t.Errorf("want pods collected, got %+v", report.Pods)
+	}
+	if report.Services == nil || len(report.Services.Items) != 1 {
+		t.Errorf("want services collected, got %+v", report.Services)
+	}
+	if report.Deployments != nil || report.Podlogs != nil || report.CRDs != nil {
+		t.Error("want collectors missing from the profile skipped")
+	}
+}
+
+func TestInspectorReportFiltersObjectsWithLabelSelector(t *testing.T) {
+	t.Parallel()
+
+	i := &inspector.Inspector{
+		K8sClient:     newTestClientset(kubeSystemNameSpace, clusterNode1, podDefaultNamespace, podWebDefaultNamespace),
+		CRDClient:     apiextfake.NewSimpleClientset(),
+		LabelSelector: "app=web",
+	}
+	report, err := i.Report(context.Background(), "default")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got []string
+	for _, pod := range report.Pods.Items {
+		got = append(got, pod.Name)
+	}
+	want := []string{"web"}
+	if !cmp.Equal(want, got) {
+		t.Error(cmp.Diff(want, got))
+	}
+}
+
+func TestInspectorReportRedactsLogsAndEnvironment(t *testing.T) {
+	t.Parallel()
+
+	i := &inspector.Inspector{
+		K8sClient:  newTestClientset(podDefaultNamespace),
+		Collectors: []string{"pods", "pod_logs"},
+		Redact: []inspector.RedactRule{
+			{Name: "logs", Pattern: "^fake"},
+			{Name: "pod name", Pattern: "^inspector$", Replacement: "***"},
+		},
+	}
+	report, err := i.Report(context.Background(), "default")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := report.Podlogs[0].Log; got != "REDACTED logs" {
+		t.Errorf("want redacted pod log, got %q", got)
+	}
+	env := report.Pods.Items[0].Spec.Containers[0].Env
+	want := []corev1.EnvVar{{Name: "POD_NAMESPACE", Value: "default"}, {Name: "POD_NAME", Value: "***"}}
+	if !cmp.Equal(want, env) {
+		t.Error(cmp.Diff(want, env))
+	}
+}
+
+func TestInspectorReportRedactsEnvironmentOfPodTemplates(t *testing.T) {
+	t.Parallel()
+
+	template := corev1.PodTemplateSpec{
+		Spec: corev1.PodSpec{Containers: []corev1.Container{{
+			Name: "web",
+			Env:  []corev1.EnvVar{{Name: "DB_PASSWORD", Value: "password=secret"}},
+		}}},
+	}
+	deployment := &appsv1.Deployment{
+		ObjectMeta: metav1.ObjectMeta{Name: "web", Namespace: "default"},
+		Spec:       appsv1.DeploymentSpec{Template: template},
+	}
+	revision := &appsv1.ControllerRevision{
+		ObjectMeta: metav1.ObjectMeta{Name: "db-1", Namespace: "default"},
+		Data: k8sruntime.RawExtension{
+			Raw: []byte(`{"spec":{"template":{"$patch":"replace","spec":{"containers":[{"name":"db","env":[{"name":"DB_PASSWORD","value":"password=secret"}]}]}}}}`),
+		},
+		Revision: 1,
+	}
+	i := &inspector.Inspector{
+		K8sClient:  newTestClientset(deployment, revision),
+		Collectors: []string{"deployments", "controller_revisions"},
+		Redact:     []inspector.RedactRule{{Name: "password", Pattern: "password=.*"}},
+	}
+	report, err := i.Report(context.Background(), "default")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []corev1.EnvVar{{Name: "DB_PASSWORD", Value: "REDACTED"}}
+	env := report.Deployments.Items[0].Spec.Template.Spec.Containers[0].Env
+	if !cmp.Equal(want, env) {
+		t.Error(cmp.Diff(want, env))
+	}
+	data := string(report.ControllerRevisions.Items[0].Data.Raw)
+	wantData := `{"spec":{"template":{"$patch":"replace","spec":{"containers":[{"env":[{"name":"DB_PASSWORD","value":"REDACTED"}],"name":"db"}]}}}}`
+	if data != wantData {
+		t.Errorf("want redacted revision data %s, got %s", wantData, data)
+	}
+}
+
+var podWebDefaultNamespace = &corev1.Pod{
+	ObjectMeta: metav1.ObjectMeta{
+		Name:      "web",
+		Namespace: "default",
+		Labels:    map[string]string{"app": "web"},
+	},
+}
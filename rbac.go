@@ -0,0 +1,272 @@
+package inspector
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+
+	authzv1 "k8s.io/api/authorization/v1"
+	corev1 "k8s.io/api/core/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// CheckControllerPermissions is the check reporting permissions
+// missing from an ingress controller service account.
+const CheckControllerPermissions = "ControllerPermissions"
+
+// ResourceAccess describes a verb on a resource a service account needs.
+type ResourceAccess struct {
+	Group      string `json:"group"`
+	Resource   string `json:"resource"`
+	Verb       string `json:"verb"`
+	Namespaced bool   `json:"namespaced"`
+}
+
+// IngressControllerAccess lists resources and verbs an Ingress Controller
+// needs to watch the cluster and publish its status.
+var IngressControllerAccess = []ResourceAccess{
+	{Resource: "configmaps", Verb: "list", Namespaced: true},
+	{Resource: "configmaps", Verb: "watch", Namespaced: true},
+	{Resource: "endpoints", Verb: "list", Namespaced: true},
+	{Resource: "endpoints", Verb: "watch", Namespaced: true},
+	{Resource: "events", Verb: "create", Namespaced: true},
+	{Resource: "pods", Verb: "list", Namespaced: true},
+	{Resource: "pods", Verb: "watch", Namespaced: true},
+	{Resource: "secrets", Verb: "list", Namespaced: true},
+	{Resource: "secrets", Verb: "watch", Namespaced: true},
+	{Resource: "services", Verb: "list", Namespaced: true},
+	{Resource: "services", Verb: "watch", Namespaced: true},
+	{Group: "coordination.k8s.io", Resource: "leases", Verb: "get", Namespaced: true},
+	{Group: "coordination.k8s.io", Resource: "leases", Verb: "update", Namespaced: true},
+	{Group: "discovery.k8s.io", Resource: "endpointslices", Verb: "list", Namespaced: true},
+	{Group: "discovery.k8s.io", Resource: "endpointslices", Verb: "watch", Namespaced: true},
+	{Group: "networking.k8s.io", Resource: "ingressclasses", Verb: "get"},
+	{Group: "networking.k8s.io", Resource: "ingresses", Verb: "list", Namespaced: true},
+	{Group: "networking.k8s.io", Resource: "ingresses", Verb: "watch", Namespaced: true},
+	{Group: "networking.k8s.io", Resource: "ingresses/status", Verb: "update", Namespaced: true},
+}
+
+// AccessCheck holds the result of a single access review.
+type AccessCheck struct {
+	ServiceAccount string         `json:"service_account"`
+	Access         ResourceAccess `json:"access"`
+	Allowed        bool           `json:"allowed"`
+	Reason         string         `json:"reason,omitempty"`
+}
+
+// ServiceAccounts returns a list of [service accounts] in a given namespace.
+//
+// [service accounts]: https://kubernetes.io/docs/concepts/security/service-accounts/
+func (i *Inspector) ServiceAccounts(ctx context.Context, namespace string) (*corev1.ServiceAccountList, error) {
+	serviceAccounts, err := listPages(ctx, i, "service_accounts", i.K8sClient.CoreV1().ServiceAccounts(namespace).List)
+	if err != nil {
+		return nil, err
+	}
+	return serviceAccounts, nil
+}
+
+// Roles returns a list of [roles] in a given namespace.
+//
+// [roles]: https://kubernetes.io/docs/reference/access-authn-authz/rbac/#role-and-clusterrole
+func (i *Inspector) Roles(ctx context.Context, namespace string) (*rbacv1.RoleList, error) {
+	roles, err := listPages(ctx, i, "roles", i.K8sClient.RbacV1().Roles(namespace).List)
+	if err != nil {
+		return nil, err
+	}
+	return roles, nil
+}
+
+// RoleBindings returns a list of [role bindings] in a given namespace.
+//
+// [role bindings]: https://kubernetes.io/docs/reference/access-authn-authz/rbac/#rolebinding-and-clusterrolebinding
+func (i *Inspector) RoleBindings(ctx context.Context, namespace string) (*rbacv1.RoleBindingList, error) {
+	roleBindings, err := listPages(ctx, i, "role_bindings", i.K8sClient.RbacV1().RoleBindings(namespace).List)
+	if err != nil {
+		return nil, err
+	}
+	return roleBindings, nil
+}
+
+// ClusterRoleBindings returns a list of [cluster role bindings]
+// granting permissions to service accounts of a given namespace,
+// directly or through the groups they belong to.
+//
+// [cluster role bindings]: https://kubernetes.io/docs/reference/access-authn-authz/rbac/#rolebinding-and-clusterrolebinding
+func (i *Inspector) ClusterRoleBindings(ctx context.Context, namespace string) (*rbacv1.ClusterRoleBindingList, error) {
+	bindings, err := listPages(ctx, i, "cluster_role_bindings", i.K8sClient.RbacV1().ClusterRoleBindings().List)
+	if err != nil {
+		return nil, err
+	}
+	related := &rbacv1.ClusterRoleBindingList{TypeMeta: bindings.TypeMeta, ListMeta: bindings.ListMeta}
+	for _, b := range bindings.Items {
+		for _, s := range b.Subjects {
+			if grantsServiceAccounts(s, namespace) {
+				related.Items = append(related.Items, b)
+				break
+			}
+		}
+	}
+	return related, nil
+}
+
+// serviceAccountGroups returns groups service accounts
+// of the namespace are authenticated with.
+func serviceAccountGroups(namespace string) []string {
+	return []string{"system:serviceaccounts", "system:serviceaccounts:" + namespace, "system:authenticated"}
+}
+
+// grantsServiceAccounts reports whether the binding subject refers to
+// service accounts of the namespace or to a group they belong to.
+func grantsServiceAccounts(s rbacv1.Subject, namespace string) bool {
+	switch s.Kind {
+	case rbacv1.ServiceAccountKind:
+		return s.Namespace == namespace
+	case rbacv1.UserKind:
+		return strings.HasPrefix(s.Name, "system:serviceaccount:"+namespace+":")
+	case rbacv1.GroupKind:
+		return slices.Contains(serviceAccountGroups(namespace), s.Name)
+	}
+	return false
+}
+
+// ClusterRoles returns a list of [cluster roles] referenced by
+// the given role bindings and cluster role bindings.
+//
+// [cluster roles]: https://kubernetes.io/docs/reference/access-authn-authz/rbac/#role-and-clusterrole
+func (i *Inspector) ClusterRoles(ctx context.Context, roleBindings *rbacv1.RoleBindingList, clusterRoleBindings *rbacv1.ClusterRoleBindingList) (*rbacv1.ClusterRoleList, error) {
+	roles, err := listPages(ctx, i, "cluster_roles", i.K8sClient.RbacV1().ClusterRoles().List)
+	if err != nil {
+		return nil, err
+	}
+	referenced := map[string]bool{}
+	if roleBindings != nil {
+		for _, b := range roleBindings.Items {
+			if b.RoleRef.Kind == "ClusterRole" {
+				referenced[b.RoleRef.Name] = true
+			}
+		}
+	}
+	if clusterRoleBindings != nil {
+		for _, b := range clusterRoleBindings.Items {
+			referenced[b.RoleRef.Name] = true
+		}
+	}
+	related := &rbacv1.ClusterRoleList{TypeMeta: roles.TypeMeta, ListMeta: roles.ListMeta}
+	for _, r := range roles.Items {
+		if referenced[r.Name] {
+			related.Items = append(related.Items, r)
+		}
+	}
+	return related, nil
+}
+
+// CheckServiceAccountAccess verifies with [SubjectAccessReviews] whether
+// the service account in a given namespace is allowed to perform
+// the requested verbs on resources.
+//
+// [SubjectAccessReviews]: https://kubernetes.io/docs/reference/access-authn-authz/authorization/#checking-api-access
+func (i *Inspector) CheckServiceAccountAccess(ctx context.Context, namespace, serviceAccount string, access []ResourceAccess) ([]AccessCheck, error) {
+	user := fmt.Sprintf("system:serviceaccount:%s:%s", namespace, serviceAccount)
+	checks := make([]AccessCheck, 0, len(access))
+	for _, a := range access {
+		attrs := resourceAttributes(a, namespace)
+		review := &authzv1.SubjectAccessReview{
+			Spec: authzv1.SubjectAccessReviewSpec{
+				User:               user,
+				Groups:             serviceAccountGroups(namespace),
+				ResourceAttributes: &attrs,
+			},
+		}
+		res, err := retry(ctx, i, func(ctx context.Context) (*authzv1.SubjectAccessReview, error) {
+			return i.K8sClient.AuthorizationV1().SubjectAccessReviews().Create(ctx, review, metav1.CreateOptions{})
+		})
+		if err != nil {
+			return nil, err
+		}
+		checks = append(checks, AccessCheck{
+			ServiceAccount: serviceAccount,
+			Access:         a,
+			Allowed:        res.Status.Allowed,
+			Reason:         res.Status.Reason,
+		})
+	}
+	return checks, nil
+}
+
+// resourceAttributes converts resource access to access review attributes.
+// Subresources are given in the resource/subresource form.
+func resourceAttributes(a ResourceAccess, namespace string) authzv1.ResourceAttributes {
+	resource, subresource, _ := strings.Cut(a.Resource, "/")
+	attrs := authzv1.ResourceAttributes{
+		Group:       a.Group,
+		Resource:    resource,
+		Subresource: subresource,
+		Verb:        a.Verb,
+	}
+	if a.Namespaced {
+		attrs.Namespace = namespace
+	}
+	return attrs
+}
+
+// IngressControllerServiceAccounts returns names of service accounts
+// used by Ingress Controller pods, recognised by their container images.
+func IngressControllerServiceAccounts(pods *corev1.PodList) []string {
+	if pods == nil {
+		return nil
+	}
+	seen := map[string]bool{}
+	names := []string{}
+	for _, pod := range pods.Items {
+		if !runsIngressController(pod) {
+			continue
+		}
+		sa := pod.Spec.ServiceAccountName
+		if sa == "" {
+			sa = "default"
+		}
+		if !seen[sa] {
+			seen[sa] = true
+			names = append(names, sa)
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+// runsIngressController reports whether any pod container
+// runs an Ingress Controller image.
+func runsIngressController(pod corev1.Pod) bool {
+	for _, c := range pod.Spec.Containers {
+		if strings.Contains(c.Image, "ingress") {
+			return true
+		}
+	}
+	return false
+}
+
+// AnalyzeAccess returns findings for denied access checks.
+func AnalyzeAccess(namespace string, checks []AccessCheck) []Finding {
+	findings := []Finding{}
+	for _, c := range checks {
+		if c.Allowed {
+			continue
+		}
+		resource := c.Access.Resource
+		if c.Access.Group != "" {
+			resource = c.Access.Resource + "." + c.Access.Group
+		}
+		findings = append(findings, Finding{
+			Check:    CheckControllerPermissions,
+			Severity: SeverityCritical,
+			Object:   ObjectRef{Kind: "ServiceAccount", Namespace: namespace, Name: c.ServiceAccount},
+			Message:  fmt.Sprintf("service account cannot %s %s", c.Access.Verb, resource),
+		})
+	}
+	sortFindings(findings)
+	return findings
+}
@@ -0,0 +1,190 @@
+package inspector_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/qba73/inspector"
+
+	authzv1 "k8s.io/api/authorization/v1"
+	corev1 "k8s.io/api/core/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	k8sruntime "k8s.io/apimachinery/pkg/runtime"
+	k8stesting "k8s.io/client-go/testing"
+)
+
+func TestInspectorListsClusterRoleBindingsForSubjectsInAGivenNamespace(t *testing.T) {
+	t.Parallel()
+
+	groupBinding := func(name, group string) *rbacv1.ClusterRoleBinding {
+		return &rbacv1.ClusterRoleBinding{
+			ObjectMeta: metav1.ObjectMeta{Name: name},
+			RoleRef:    rbacv1.RoleRef{Kind: "ClusterRole", Name: "view"},
+			Subjects:   []rbacv1.Subject{{Kind: "Group", Name: group}},
+		}
+	}
+	i := newTestInspector(
+		nginxIngressClusterRoleBinding,
+		otherClusterRoleBinding,
+		groupBinding("authenticated", "system:authenticated"),
+		groupBinding("namespace-service-accounts", "system:serviceaccounts:nginx-ingress"),
+		groupBinding("other-service-accounts", "system:serviceaccounts:web"),
+	)
+	got, err := i.ClusterRoleBindings(context.Background(), "nginx-ingress")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var names []string
+	for _, b := range got.Items {
+		names = append(names, b.Name)
+	}
+	want := []string{"authenticated", "namespace-service-accounts", "nginx-ingress"}
+	if !cmp.Equal(want, names) {
+		t.Error(cmp.Diff(want, names))
+	}
+}
+
+func TestInspectorListsClusterRolesReferencedByBindings(t *testing.T) {
+	t.Parallel()
+
+	i := newTestInspector(nginxIngressClusterRole, otherClusterRole)
+	bindings := &rbacv1.ClusterRoleBindingList{Items: []rbacv1.ClusterRoleBinding{*nginxIngressClusterRoleBinding}}
+	got, err := i.ClusterRoles(context.Background(), nil, bindings)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got.Items) != 1 || got.Items[0].Name != "nginx-ingress" {
+		t.Errorf("want cluster role nginx-ingress, got %+v", got.Items)
+	}
+}
+
+func TestInspectorListsServiceAccountsRolesAndRoleBindings(t *testing.T) {
+	t.Parallel()
+
+	i := newTestInspector(
+		&corev1.ServiceAccount{ObjectMeta: metav1.ObjectMeta{Name: "nginx-ingress", Namespace: "nginx-ingress"}},
+		&rbacv1.Role{ObjectMeta: metav1.ObjectMeta{Name: "nginx-ingress", Namespace: "nginx-ingress"}},
+		&rbacv1.RoleBinding{ObjectMeta: metav1.ObjectMeta{Name: "nginx-ingress", Namespace: "nginx-ingress"}},
+	)
+	ctx := context.Background()
+	sas, err := i.ServiceAccounts(ctx, "nginx-ingress")
+	if err != nil {
+		t.Fatal(err)
+	}
+	roles, err := i.Roles(ctx, "nginx-ingress")
+	if err != nil {
+		t.Fatal(err)
+	}
+	bindings, err := i.RoleBindings(ctx, "nginx-ingress")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(sas.Items) != 1 || len(roles.Items) != 1 || len(bindings.Items) != 1 {
+		t.Errorf("want one of each object, got %d service accounts, %d roles, %d role bindings", len(sas.Items), len(roles.Items), len(bindings.Items))
+	}
+}
+
+func TestInspectorChecksServiceAccountAccessWithSubjectAccessReviews(t *testing.T) {
+	t.Parallel()
+
+	client := newTestClientset()
+	var reviews []authzv1.SubjectAccessReview
+	client.PrependReactor("create", "subjectaccessreviews", func(action k8stesting.Action) (bool, k8sruntime.Object, error) {
+		review := action.(k8stesting.CreateAction).GetObject().(*authzv1.SubjectAccessReview)
+		reviews = append(reviews, *review)
+		review.Status.Allowed = review.Spec.ResourceAttributes.Verb == "list"
+		return true, review, nil
+	})
+	i := &inspector.Inspector{K8sClient: client}
+
+	access := []inspector.ResourceAccess{
+		{Group: "networking.k8s.io", Resource: "ingresses", Verb: "list", Namespaced: true},
+		{Group: "networking.k8s.io", Resource: "ingresses/status", Verb: "update", Namespaced: true},
+	}
+	got, err := i.CheckServiceAccountAccess(context.Background(), "nginx-ingress", "nginx-ingress", access)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []inspector.AccessCheck{
+		{ServiceAccount: "nginx-ingress", Access: access[0], Allowed: true},
+		{ServiceAccount: "nginx-ingress", Access: access[1], Allowed: false},
+	}
+	if !cmp.Equal(want, got) {
+		t.Error(cmp.Diff(want, got))
+	}
+	if reviews[0].Spec.User != "system:serviceaccount:nginx-ingress:nginx-ingress" {
+		t.Errorf("unexpected review user %s", reviews[0].Spec.User)
+	}
+	wantGroups := []string{"system:serviceaccounts", "system:serviceaccounts:nginx-ingress", "system:authenticated"}
+	if !cmp.Equal(wantGroups, reviews[0].Spec.Groups) {
+		t.Error(cmp.Diff(wantGroups, reviews[0].Spec.Groups))
+	}
+	if reviews[1].Spec.ResourceAttributes.Subresource != "status" {
+		t.Errorf("want subresource status, got %q", reviews[1].Spec.ResourceAttributes.Subresource)
+	}
+}
+
+func TestIngressControllerServiceAccountsAreDetectedFromPodImages(t *testing.T) {
+	t.Parallel()
+
+	pods := &corev1.PodList{Items: []corev1.Pod{
+		{Spec: corev1.PodSpec{ServiceAccountName: "nginx-ingress", Containers: []corev1.Container{{Image: "nginx/nginx-ingress:3.4.0"}}}},
+		{Spec: corev1.PodSpec{ServiceAccountName: "nginx-ingress", Containers: []corev1.Container{{Image: "nginx/nginx-ingress:3.4.0"}}}},
+		{Spec: corev1.PodSpec{ServiceAccountName: "coffee", Containers: []corev1.Container{{Image: "nginxdemos/nginx-hello"}}}},
+	}}
+	got := inspector.IngressControllerServiceAccounts(pods)
+	want := []string{"nginx-ingress"}
+	if !cmp.Equal(want, got) {
+		t.Error(cmp.Diff(want, got))
+	}
+}
+
+func TestAnalyzeAccessReportsDeniedPermissions(t *testing.T) {
+	t.Parallel()
+
+	checks := []inspector.AccessCheck{
+		{ServiceAccount: "nginx-ingress", Access: inspector.ResourceAccess{Resource: "secrets", Verb: "list"}, Allowed: true},
+		{ServiceAccount: "nginx-ingress", Access: inspector.ResourceAccess{Group: "discovery.k8s.io", Resource: "endpointslices", Verb: "watch"}},
+	}
+	got := inspector.AnalyzeAccess("nginx-ingress", checks)
+	want := []inspector.Finding{
+		{
+			Check:    inspector.CheckControllerPermissions,
+			Severity: inspector.SeverityCritical,
+			Object:   inspector.ObjectRef{Kind: "ServiceAccount", Namespace: "nginx-ingress", Name: "nginx-ingress"},
+			Message:  "service account cannot watch endpointslices.discovery.k8s.io",
+		},
+	}
+	if !cmp.Equal(want, got) {
+		t.Error(cmp.Diff(want, got))
+	}
+}
+
+// RBAC objects used for testing.
+var (
+	nginxIngressClusterRole = &rbacv1.ClusterRole{
+		ObjectMeta: metav1.ObjectMeta{Name: "nginx-ingress"},
+	}
+
+	otherClusterRole = &rbacv1.ClusterRole{
+		ObjectMeta: metav1.ObjectMeta{Name: "cluster-admin"},
+	}
+
+	nginxIngressClusterRoleBinding = &rbacv1.ClusterRoleBinding{
+		ObjectMeta: metav1.ObjectMeta{Name: "nginx-ingress"},
+		RoleRef:    rbacv1.RoleRef{Kind: "ClusterRole", Name: "nginx-ingress"},
+		Subjects: []rbacv1.Subject{
+			{Kind: "ServiceAccount", Namespace: "nginx-ingress", Name: "nginx-ingress"},
+		},
+	}
+
+	otherClusterRoleBinding = &rbacv1.ClusterRoleBinding{
+		ObjectMeta: metav1.ObjectMeta{Name: "admins"},
+		RoleRef:    rbacv1.RoleRef{Kind: "ClusterRole", Name: "cluster-admin"},
+		Subjects: []rbacv1.Subject{
+			{Kind: "Group", Name: "system:masters"},
+		},
+	}
+)
@@ -0,0 +1,189 @@
+package inspector
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"slices"
+
+	appsv1 "k8s.io/api/apps/v1"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+// RedactRule replaces text matching a regular expression
+// in pod logs, ConfigMap data and container environment values
+// of pods and of pod templates of workloads and their revisions.
+type RedactRule struct {
+	Name    string `json:"name"`
+	Pattern string `json:"pattern"`
+	// Replacement may refer to pattern groups, like ${1}.
+	// Matches are replaced with REDACTED when empty.
+	Replacement string `json:"replacement,omitempty"`
+}
+
+// redactReport applies redaction rules to data collected in the report.
+func redactReport(rep *Report, rules []RedactRule) error {
+	redact, err := newRedactor(rules)
+	if err != nil || redact == nil {
+		return err
+	}
+	for n := range rep.Podlogs {
+		rep.Podlogs[n].Log = redact(rep.Podlogs[n].Log)
+	}
+	if rep.ConfigMaps != nil {
+		for n := range rep.ConfigMaps.Items {
+			redactObject(&rep.ConfigMaps.Items[n], redact)
+		}
+	}
+	if rep.Pods != nil {
+		for n := range rep.Pods.Items {
+			redactObject(&rep.Pods.Items[n], redact)
+		}
+	}
+	if rep.Deployments != nil {
+		for n := range rep.Deployments.Items {
+			redactObject(&rep.Deployments.Items[n], redact)
+		}
+	}
+	if rep.ReplicaSets != nil {
+		for n := range rep.ReplicaSets.Items {
+			redactObject(&rep.ReplicaSets.Items[n], redact)
+		}
+	}
+	if rep.StatefulSets != nil {
+		for n := range rep.StatefulSets.Items {
+			redactObject(&rep.StatefulSets.Items[n], redact)
+		}
+	}
+	if rep.DaemonSets != nil {
+		for n := range rep.DaemonSets.Items {
+			redactObject(&rep.DaemonSets.Items[n], redact)
+		}
+	}
+	if rep.ControllerRevisions != nil {
+		for n := range rep.ControllerRevisions.Items {
+			redactObject(&rep.ControllerRevisions.Items[n], redact)
+		}
+	}
+	if rep.Jobs != nil {
+		for n := range rep.Jobs.Items {
+			redactObject(&rep.Jobs.Items[n], redact)
+		}
+	}
+	if rep.CronJobs != nil {
+		for n := range rep.CronJobs.Items {
+			redactObject(&rep.CronJobs.Items[n], redact)
+		}
+	}
+	return nil
+}
+
+// newRedactor returns a function applying redaction rules to text.
+// It returns nil when there are no rules.
+func newRedactor(rules []RedactRule) (func(string) string, error) {
+	if len(rules) == 0 {
+		return nil, nil
+	}
+	patterns := make([]*regexp.Regexp, len(rules))
+	for n, r := range rules {
+		re, err := regexp.Compile(r.Pattern)
+		if err != nil {
+			return nil, &ConfigError{Err: fmt.Errorf("redact rule %q: %w", r.Name, err)}
+		}
+		patterns[n] = re
+	}
+	return func(s string) string {
+		for n, re := range patterns {
+			replacement := rules[n].Replacement
+			if replacement == "" {
+				replacement = "REDACTED"
+			}
+			s = re.ReplaceAllString(s, replacement)
+		}
+		return s
+	}, nil
+}
+
+// redactObject redacts data of a ConfigMap, container environment
+// values of a pod or of pod templates of workloads, and pod templates
+// stored in controller revisions. Other objects are left as they are.
+func redactObject(obj runtime.Object, redact func(string) string) {
+	switch o := obj.(type) {
+	case *corev1.ConfigMap:
+		for k, v := range o.Data {
+			o.Data[k] = redact(v)
+		}
+	case *corev1.Pod:
+		redactPodSpec(&o.Spec, redact)
+	case *appsv1.Deployment:
+		redactPodSpec(&o.Spec.Template.Spec, redact)
+	case *appsv1.ReplicaSet:
+		redactPodSpec(&o.Spec.Template.Spec, redact)
+	case *appsv1.StatefulSet:
+		redactPodSpec(&o.Spec.Template.Spec, redact)
+	case *appsv1.DaemonSet:
+		redactPodSpec(&o.Spec.Template.Spec, redact)
+	case *batchv1.Job:
+		redactPodSpec(&o.Spec.Template.Spec, redact)
+	case *batchv1.CronJob:
+		redactPodSpec(&o.Spec.JobTemplate.Spec.Template.Spec, redact)
+	case *appsv1.ControllerRevision:
+		redactRevisionData(o, redact)
+	}
+}
+
+// redactPodSpec redacts environment values of containers of the pod spec.
+func redactPodSpec(spec *corev1.PodSpec, redact func(string) string) {
+	for _, c := range slices.Concat(spec.InitContainers, spec.Containers) {
+		for e := range c.Env {
+			c.Env[e].Value = redact(c.Env[e].Value)
+		}
+	}
+	for _, c := range spec.EphemeralContainers {
+		for e := range c.Env {
+			c.Env[e].Value = redact(c.Env[e].Value)
+		}
+	}
+}
+
+// redactRevisionData redacts environment values of containers in
+// the pod template patch stored in a controller revision. Data that
+// is not JSON is left as it is.
+func redactRevisionData(cr *appsv1.ControllerRevision, redact func(string) string) {
+	var data any
+	if err := json.Unmarshal(cr.Data.Raw, &data); err != nil {
+		return
+	}
+	redactEnvValues(data, redact)
+	b, err := json.Marshal(data)
+	if err != nil {
+		return
+	}
+	cr.Data.Raw = b
+}
+
+// redactEnvValues redacts values of env lists found anywhere in decoded JSON.
+func redactEnvValues(v any, redact func(string) string) {
+	switch v := v.(type) {
+	case map[string]any:
+		for key, value := range v {
+			if env, ok := value.([]any); ok && key == "env" {
+				for _, e := range env {
+					if e, ok := e.(map[string]any); ok {
+						if s, ok := e["value"].(string); ok {
+							e["value"] = redact(s)
+						}
+					}
+				}
+				continue
+			}
+			redactEnvValues(value, redact)
+		}
+	case []any:
+		for _, item := range v {
+			redactEnvValues(item, redact)
+		}
+	}
+}
@@ -0,0 +1,131 @@
+package inspector
+
+import (
+	"context"
+	"errors"
+	"io"
+	"math/rand/v2"
+	"net"
+	"syscall"
+	"time"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+)
+
+// RetryPolicy configures retries of API calls failing with
+// retryable errors: throttling, server errors, timeouts
+// and dropped connections.
+type RetryPolicy struct {
+	// MaxRetries is the number of retries after the first attempt.
+	// Calls are not retried when zero.
+	MaxRetries int
+	// InitialBackoff is the delay before the first retry. Delays
+	// double with each retry up to MaxBackoff, with random jitter.
+	InitialBackoff time.Duration
+	MaxBackoff     time.Duration
+}
+
+// DefaultRetryPolicy is the retry policy used by the CLI.
+var DefaultRetryPolicy = RetryPolicy{
+	MaxRetries:     3,
+	InitialBackoff: 200 * time.Millisecond,
+	MaxBackoff:     5 * time.Second,
+}
+
+// backoff returns the delay before the retry following the attempt.
+// The delay is picked at random from the upper half of the exponential
+// backoff, and is not shorter than the delay suggested by the API server.
+func (p RetryPolicy) backoff(attempt int, err error) time.Duration {
+	delay := p.InitialBackoff << attempt
+	if delay > p.MaxBackoff || delay <= 0 {
+		delay = p.MaxBackoff
+	}
+	if delay > 0 {
+		delay = delay/2 + rand.N(delay/2+1)
+	}
+	if seconds, ok := apierrors.SuggestsClientDelay(err); ok {
+		delay = max(delay, time.Duration(seconds)*time.Second)
+	}
+	return delay
+}
+
+// retryKey is the context key of the retry scope of a collector.
+type retryKey struct{}
+
+// retryScope counts retries of API calls made by a collector.
+type retryScope struct {
+	collector string
+	retries   int
+}
+
+// withRetryScope returns a context counting retries of the collector calls.
+func withRetryScope(ctx context.Context, collector string) (context.Context, *retryScope) {
+	scope := &retryScope{collector: collector}
+	return context.WithValue(ctx, retryKey{}, scope), scope
+}
+
+// retry calls the API, retrying retryable failures according to
+// the inspector retry policy. Each attempt is bounded by the inspector
+// timeout.
+func retry[T any](ctx context.Context, i *Inspector, call func(context.Context) (T, error)) (T, error) {
+	return retryWithTimeout(ctx, i, i.Timeout, call)
+}
+
+// retryWithTimeout is like retry, with attempts bounded by the given
+// timeout. Attempts have no timeout when it is zero, so long reads,
+// like reads of pod logs, are not cut.
+func retryWithTimeout[T any](ctx context.Context, i *Inspector, timeout time.Duration, call func(context.Context) (T, error)) (T, error) {
+	scope, _ := ctx.Value(retryKey{}).(*retryScope)
+	for attempt := 0; ; attempt++ {
+		callCtx, cancel := withCallTimeout(ctx, timeout)
+		v, err := call(callCtx)
+		cancel()
+		if err == nil || attempt >= i.Retry.MaxRetries || !isRetryable(err) || ctx.Err() != nil {
+			return v, err
+		}
+		delay := i.Retry.backoff(attempt, err)
+		collector := ""
+		if scope != nil {
+			scope.retries++
+			collector = scope.collector
+		}
+		i.logger().Warn("API call failed, retrying", "collector", collector, "retry", attempt+1, "delay", delay, "error", err)
+		timer := time.NewTimer(delay)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return v, err
+		case <-timer.C:
+		}
+	}
+}
+
+// withCallTimeout returns a context bounding an API call with the timeout.
+// The context is not bounded when the timeout is zero.
+func withCallTimeout(ctx context.Context, timeout time.Duration) (context.Context, context.CancelFunc) {
+	if timeout <= 0 {
+		return ctx, func() {}
+	}
+	return context.WithTimeout(ctx, timeout)
+}
+
+// isRetryable reports whether a failed API call may succeed when retried.
+func isRetryable(err error) bool {
+	if errors.Is(err, context.Canceled) {
+		return false
+	}
+	if apierrors.IsTooManyRequests(err) || apierrors.IsServerTimeout(err) || apierrors.IsTimeout(err) ||
+		apierrors.IsServiceUnavailable(err) || apierrors.IsInternalError(err) || apierrors.IsUnexpectedServerError(err) {
+		return true
+	}
+	var status apierrors.APIStatus
+	if errors.As(err, &status) {
+		return status.Status().Code >= 500
+	}
+	if errors.Is(err, syscall.ECONNRESET) || errors.Is(err, syscall.ECONNREFUSED) ||
+		errors.Is(err, io.ErrUnexpectedEOF) || errors.Is(err, context.DeadlineExceeded) {
+		return true
+	}
+	var netErr net.Error
+	return errors.As(err, &netErr) && netErr.Timeout()
+}
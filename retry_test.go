@@ -0,0 +1,132 @@
+package inspector_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/qba73/inspector"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	k8sruntime "k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/rest"
+	k8stesting "k8s.io/client-go/testing"
+)
+
+var fastRetries = inspector.RetryPolicy{MaxRetries: 3, InitialBackoff: time.Millisecond, MaxBackoff: 2 * time.Millisecond}
+
+func TestInspectorReportRetriesTransientErrorsAndRecordsRetries(t *testing.T) {
+	t.Parallel()
+
+	client := newTestClientset(teaServiceDefaultNS)
+	calls := failingCalls(&client.Fake, "services", 2, apierrors.NewServiceUnavailable("etcd leader changed"))
+	i := &inspector.Inspector{K8sClient: client, Collectors: []string{"services"}, Retry: fastRetries}
+
+	report, err := i.Report(context.Background(), "default")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if report.Services == nil || len(report.Services.Items) != 1 {
+		t.Errorf("want services collected after retries, got %+v", report.Services)
+	}
+	if *calls != 3 {
+		t.Errorf("want 3 calls, got %d", *calls)
+	}
+	want := map[string]int{"services": 2}
+	if !cmp.Equal(want, report.Metadata.Retries) {
+		t.Error(cmp.Diff(want, report.Metadata.Retries))
+	}
+}
+
+func TestInspectorGivesUpAfterMaxRetries(t *testing.T) {
+	t.Parallel()
+
+	client := newTestClientset()
+	calls := failingCalls(&client.Fake, "services", 10, apierrors.NewTooManyRequests("slow down", 0))
+	i := &inspector.Inspector{K8sClient: client, Retry: fastRetries}
+
+	_, err := i.Services(context.Background(), "default")
+	if !apierrors.IsTooManyRequests(err) {
+		t.Errorf("want too many requests error, got %v", err)
+	}
+	if *calls != 4 {
+		t.Errorf("want first call and 3 retries, got %d calls", *calls)
+	}
+}
+
+func TestInspectorDoesNotRetryPermanentErrors(t *testing.T) {
+	t.Parallel()
+
+	client := newTestClientset()
+	forbidden := apierrors.NewForbidden(schema.GroupResource{Resource: "services"}, "", errors.New("denied"))
+	calls := failingCalls(&client.Fake, "services", 10, forbidden)
+	i := &inspector.Inspector{K8sClient: client, Retry: fastRetries}
+
+	if _, err := i.Services(context.Background(), "default"); !apierrors.IsForbidden(err) {
+		t.Errorf("want forbidden error, got %v", err)
+	}
+	if *calls != 1 {
+		t.Errorf("want a single call, got %d", *calls)
+	}
+}
+
+// failingCalls makes the first n list calls of the resource fail with err
+// and returns the number of calls made.
+func failingCalls(client *k8stesting.Fake, resource string, n int, err error) *int {
+	calls := 0
+	client.PrependReactor("list", resource, func(action k8stesting.Action) (bool, k8sruntime.Object, error) {
+		calls++
+		if calls <= n {
+			return true, nil, err
+		}
+		return false, nil, nil
+	})
+	return &calls
+}
+
+func TestInspectorTimeoutBoundsAPICallsButNotLogReads(t *testing.T) {
+	t.Parallel()
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch {
+		case strings.HasSuffix(r.URL.Path, "/services"):
+			time.Sleep(200 * time.Millisecond)
+			fmt.Fprint(w, `{"kind":"ServiceList","apiVersion":"v1","items":[]}`)
+		case strings.HasSuffix(r.URL.Path, "/pods"):
+			fmt.Fprint(w, `{"kind":"PodList","apiVersion":"v1","items":[{"metadata":{"name":"web","namespace":"default"},"spec":{"containers":[{"name":"web"}]}}]}`)
+		case strings.HasSuffix(r.URL.Path, "/pods/web/log"):
+			w.Header().Set("Content-Type", "text/plain")
+			fmt.Fprint(w, "starting\n")
+			w.(http.Flusher).Flush()
+			time.Sleep(200 * time.Millisecond)
+			fmt.Fprint(w, "ready\n")
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer srv.Close()
+	i, err := inspector.NewInspector(&rest.Config{Host: srv.URL})
+	if err != nil {
+		t.Fatal(err)
+	}
+	i.Timeout = 50 * time.Millisecond
+
+	if _, err := i.Services(context.Background(), "default"); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("want services call cut after the timeout, got %v", err)
+	}
+	logs, err := i.Podlogs(context.Background(), "default")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(logs) != 1 || logs[0].Log != "starting\nready\n" {
+		t.Errorf("want whole log read past the timeout, got %+v", logs)
+	}
+}
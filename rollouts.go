@@ -0,0 +1,237 @@
+package inspector
+
+import (
+	"cmp"
+	"context"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// Annotations recording rollout revisions.
+const (
+	deploymentRevisionAnnotation = "deployment.kubernetes.io/revision"
+	changeCauseAnnotation        = "kubernetes.io/change-cause"
+)
+
+// RolloutHistory lists revisions of a Deployment or a StatefulSet,
+// oldest first, like kubectl rollout history.
+type RolloutHistory struct {
+	Kind      string `json:"kind"`
+	Namespace string `json:"namespace"`
+	Name      string `json:"name"`
+	// CurrentRevision is the revision of the workload pod template.
+	CurrentRevision int64      `json:"current_revision,omitempty"`
+	Revisions       []Revision `json:"revisions"`
+}
+
+// Revision describes a pod template rolled out by a workload. Revisions
+// of Deployments are read from ReplicaSets, revisions of StatefulSets
+// from ControllerRevisions.
+type Revision struct {
+	Revision int64 `json:"revision"`
+	// Name is the name of the ReplicaSet or ControllerRevision.
+	Name              string                 `json:"name"`
+	ChangeCause       string                 `json:"change_cause,omitempty"`
+	CreationTimestamp time.Time              `json:"creation_timestamp,omitzero"`
+	Template          corev1.PodTemplateSpec `json:"template"`
+	// TemplateError reports why the template could not be read.
+	TemplateError string `json:"template_error,omitempty"`
+	// Changes lists template fields changed since the previous revision.
+	Changes []TemplateChange `json:"changes,omitempty"`
+}
+
+// TemplateChange describes a pod template field changed by a revision.
+// Old is null for added fields and New is null for removed fields.
+type TemplateChange struct {
+	Path string `json:"path"`
+	Old  any    `json:"old"`
+	New  any    `json:"new"`
+}
+
+// ControllerRevisions returns a list of [controller revisions] in a given namespace.
+//
+// [controller revisions]: https://kubernetes.io/docs/reference/kubernetes-api/workload-resources/controller-revision-v1/
+func (i *Inspector) ControllerRevisions(ctx context.Context, namespace string) (*appsv1.ControllerRevisionList, error) {
+	revisions, err := listPages(ctx, i, "controller_revisions", i.K8sClient.AppsV1().ControllerRevisions(namespace).List)
+	if err != nil {
+		return nil, err
+	}
+	return revisions, nil
+}
+
+// AnalyzeRollouts reconstructs rollout histories of Deployments from
+// the ReplicaSets they own and of StatefulSets from their ControllerRevisions.
+// Each revision lists pod template changes since the previous revision.
+// Templates and changed values are copied as they are, so reports build
+// histories from ReplicaSets and ControllerRevisions already redacted.
+func AnalyzeRollouts(deployments *appsv1.DeploymentList, statefulSets *appsv1.StatefulSetList, replicaSets *appsv1.ReplicaSetList, controllerRevisions *appsv1.ControllerRevisionList) []RolloutHistory {
+	histories := []RolloutHistory{}
+	if deployments != nil && replicaSets != nil {
+		for _, d := range deployments.Items {
+			h := RolloutHistory{Kind: "Deployment", Namespace: d.Namespace, Name: d.Name, Revisions: []Revision{}}
+			h.CurrentRevision, _ = strconv.ParseInt(d.Annotations[deploymentRevisionAnnotation], 10, 64)
+			for _, rs := range replicaSets.Items {
+				if rs.Namespace != d.Namespace || !controlledBy(rs.ObjectMeta, "Deployment", d.Name) {
+					continue
+				}
+				number, err := strconv.ParseInt(rs.Annotations[deploymentRevisionAnnotation], 10, 64)
+				if err != nil {
+					continue
+				}
+				template := *rs.Spec.Template.DeepCopy()
+				delete(template.Labels, appsv1.DefaultDeploymentUniqueLabelKey)
+				h.Revisions = append(h.Revisions, Revision{
+					Revision:          number,
+					Name:              rs.Name,
+					ChangeCause:       rs.Annotations[changeCauseAnnotation],
+					CreationTimestamp: rs.CreationTimestamp.UTC(),
+					Template:          template,
+				})
+			}
+			histories = append(histories, h)
+		}
+	}
+	if statefulSets != nil && controllerRevisions != nil {
+		for _, s := range statefulSets.Items {
+			h := RolloutHistory{Kind: "StatefulSet", Namespace: s.Namespace, Name: s.Name, Revisions: []Revision{}}
+			for _, cr := range controllerRevisions.Items {
+				if cr.Namespace != s.Namespace || !controlledBy(cr.ObjectMeta, "StatefulSet", s.Name) {
+					continue
+				}
+				if cr.Name == s.Status.UpdateRevision {
+					h.CurrentRevision = cr.Revision
+				}
+				h.Revisions = append(h.Revisions, statefulSetRevision(cr))
+			}
+			histories = append(histories, h)
+		}
+	}
+	for n := range histories {
+		h := &histories[n]
+		slices.SortFunc(h.Revisions, func(a, b Revision) int {
+			return cmp.Compare(a.Revision, b.Revision)
+		})
+		for r := 1; r < len(h.Revisions); r++ {
+			h.Revisions[r].Changes = TemplateChanges(h.Revisions[r-1].Template, h.Revisions[r].Template)
+		}
+	}
+	slices.SortFunc(histories, func(a, b RolloutHistory) int {
+		return cmp.Or(cmp.Compare(a.Kind, b.Kind), cmp.Compare(a.Namespace, b.Namespace), cmp.Compare(a.Name, b.Name))
+	})
+	return histories
+}
+
+// statefulSetRevision returns the revision stored in a ControllerRevision.
+// StatefulSet revisions hold a patch replacing the pod template.
+func statefulSetRevision(cr appsv1.ControllerRevision) Revision {
+	r := Revision{
+		Revision:          cr.Revision,
+		Name:              cr.Name,
+		ChangeCause:       cr.Annotations[changeCauseAnnotation],
+		CreationTimestamp: cr.CreationTimestamp.UTC(),
+	}
+	var patch struct {
+		Spec struct {
+			Template corev1.PodTemplateSpec `json:"template"`
+		} `json:"spec"`
+	}
+	if err := json.Unmarshal(cr.Data.Raw, &patch); err != nil {
+		r.TemplateError = fmt.Sprintf("decoding revision data: %v", err)
+		return r
+	}
+	r.Template = patch.Spec.Template
+	return r
+}
+
+// controlledBy reports whether the object is controlled by the named owner.
+func controlledBy(m metav1.ObjectMeta, kind, name string) bool {
+	owner := metav1.GetControllerOfNoCopy(&m)
+	return owner != nil && owner.Kind == kind && owner.Name == name
+}
+
+// TemplateChanges returns fields that differ between two pod templates,
+// sorted by path. Elements of lists of named objects, like containers,
+// env variables and volumes, are matched by name.
+func TemplateChanges(older, newer corev1.PodTemplateSpec) []TemplateChange {
+	oldFields, newFields := map[string]any{}, map[string]any{}
+	flattenJSON(older, "", oldFields)
+	flattenJSON(newer, "", newFields)
+	var changes []TemplateChange
+	for path, oldValue := range oldFields {
+		newValue, ok := newFields[path]
+		if !ok {
+			changes = append(changes, TemplateChange{Path: path, Old: oldValue})
+			continue
+		}
+		if !reflect.DeepEqual(oldValue, newValue) {
+			changes = append(changes, TemplateChange{Path: path, Old: oldValue, New: newValue})
+		}
+	}
+	for path, newValue := range newFields {
+		if _, ok := oldFields[path]; !ok {
+			changes = append(changes, TemplateChange{Path: path, New: newValue})
+		}
+	}
+	sort.Slice(changes, func(a, b int) bool {
+		return changes[a].Path < changes[b].Path
+	})
+	return changes
+}
+
+// flattenJSON stores scalar fields of the JSON encoding of v in fields,
+// keyed by their paths.
+func flattenJSON(v any, path string, fields map[string]any) {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return
+	}
+	var decoded any
+	if err := json.Unmarshal(b, &decoded); err != nil {
+		return
+	}
+	flattenValue(decoded, path, fields)
+}
+
+func flattenValue(v any, path string, fields map[string]any) {
+	switch v := v.(type) {
+	case map[string]any:
+		for key, value := range v {
+			flattenValue(value, fieldPath(path, key), fields)
+		}
+	case []any:
+		for n, item := range v {
+			key := strconv.Itoa(n)
+			if obj, ok := item.(map[string]any); ok {
+				if name, ok := obj["name"].(string); ok && name != "" {
+					key = name
+				}
+			}
+			flattenValue(item, path+"["+key+"]", fields)
+		}
+	case nil:
+	default:
+		fields[path] = v
+	}
+}
+
+// fieldPath appends the key to the path, quoting keys
+// containing dots, like annotation names.
+func fieldPath(path, key string) string {
+	if strings.ContainsAny(key, "./") {
+		return fmt.Sprintf("%s[%q]", path, key)
+	}
+	if path == "" {
+		return key
+	}
+	return path + "." + key
+}
@@ -0,0 +1,171 @@
+package inspector
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+	"time"
+
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+)
+
+//go:generate sh -c "go run ./cmd/inspector schema > schema/report-v1.json"
+
+// SchemaID identifies the JSON Schema of reports of the current major
+// schema version. The schema is published in the schema directory.
+const SchemaID = "https://raw.githubusercontent.com/qba73/inspector/main/schema/report-v1.json"
+
+// ReportSchema returns the JSON Schema describing reports written with
+// [SchemaVersion]. Objects allow properties not listed in the schema,
+// so reports of newer minor versions validate against older schemas.
+func ReportSchema() ([]byte, error) {
+	g := schemaGenerator{defs: map[string]any{}}
+	s := g.structSchema(reflect.TypeFor[Report]())
+	s["$schema"] = "https://json-schema.org/draft/2020-12/schema"
+	s["$id"] = SchemaID
+	s["title"] = "inspector report " + SchemaVersion
+	s["$defs"] = g.defs
+	return json.MarshalIndent(s, "", "  ")
+}
+
+// schemaGenerator builds JSON Schemas of Go types encoded with encoding/json.
+// Named struct types are described once in defs and referenced.
+type schemaGenerator struct {
+	defs map[string]any
+}
+
+var jsonMarshalerType = reflect.TypeFor[json.Marshaler]()
+
+// knownSchemas describes types encoded with custom JSON marshalers.
+var knownSchemas = map[reflect.Type]func() map[string]any{
+	reflect.TypeFor[time.Time]():          dateTimeSchema,
+	reflect.TypeFor[metav1.Time]():        nullableDateTimeSchema,
+	reflect.TypeFor[metav1.MicroTime]():   nullableDateTimeSchema,
+	reflect.TypeFor[metav1.Duration]():    func() map[string]any { return map[string]any{"type": "string"} },
+	reflect.TypeFor[resource.Quantity]():  func() map[string]any { return map[string]any{"type": []string{"string", "number"}} },
+	reflect.TypeFor[intstr.IntOrString](): func() map[string]any { return map[string]any{"type": []string{"string", "integer"}} },
+	reflect.TypeFor[CollectorError](): func() map[string]any {
+		return map[string]any{
+			"type":     "object",
+			"required": []string{"collector", "resource", "kind", "error"},
+			"properties": map[string]any{
+				"collector": map[string]any{"type": "string"},
+				"resource":  map[string]any{"type": "string"},
+				"kind":      map[string]any{"type": "string"},
+				"error":     map[string]any{"type": "string"},
+			},
+		}
+	},
+}
+
+func dateTimeSchema() map[string]any {
+	return map[string]any{"type": "string", "format": "date-time"}
+}
+
+func nullableDateTimeSchema() map[string]any {
+	return map[string]any{"type": []string{"string", "null"}, "format": "date-time"}
+}
+
+// schema returns the JSON Schema of values of type t.
+func (g *schemaGenerator) schema(t reflect.Type) map[string]any {
+	if known, ok := knownSchemas[t]; ok {
+		return known()
+	}
+	if t.Kind() != reflect.Pointer && (t.Implements(jsonMarshalerType) || reflect.PointerTo(t).Implements(jsonMarshalerType)) {
+		// Values with unknown custom encodings are not described.
+		return map[string]any{}
+	}
+	switch t.Kind() {
+	case reflect.Pointer:
+		return nullable(g.schema(t.Elem()))
+	case reflect.Struct:
+		if t.Name() == "" {
+			return g.structSchema(t)
+		}
+		name := schemaName(t)
+		if _, ok := g.defs[name]; !ok {
+			g.defs[name] = nil // Placeholder ending recursion.
+			g.defs[name] = g.structSchema(t)
+		}
+		return map[string]any{"$ref": "#/$defs/" + name}
+	case reflect.Slice:
+		if t.Elem().Kind() == reflect.Uint8 {
+			return map[string]any{"type": []string{"string", "null"}, "contentEncoding": "base64"}
+		}
+		return map[string]any{"type": []string{"array", "null"}, "items": g.schema(t.Elem())}
+	case reflect.Array:
+		return map[string]any{"type": "array", "items": g.schema(t.Elem())}
+	case reflect.Map:
+		return map[string]any{"type": []string{"object", "null"}, "additionalProperties": g.schema(t.Elem())}
+	case reflect.String:
+		return map[string]any{"type": "string"}
+	case reflect.Bool:
+		return map[string]any{"type": "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return map[string]any{"type": "integer"}
+	case reflect.Float32, reflect.Float64:
+		return map[string]any{"type": "number"}
+	default:
+		return map[string]any{}
+	}
+}
+
+// structSchema returns the JSON Schema of a struct. Fields of embedded
+// structs without JSON names are promoted, as encoding/json does.
+func (g *schemaGenerator) structSchema(t reflect.Type) map[string]any {
+	properties := map[string]any{}
+	required := []string{}
+	g.addFields(t, properties, &required)
+	s := map[string]any{"type": "object", "properties": properties}
+	if len(required) > 0 {
+		s["required"] = required
+	}
+	return s
+}
+
+func (g *schemaGenerator) addFields(t reflect.Type, properties map[string]any, required *[]string) {
+	for n := range t.NumField() {
+		f := t.Field(n)
+		tag := f.Tag.Get("json")
+		if tag == "-" {
+			continue
+		}
+		name, opts, _ := strings.Cut(tag, ",")
+		ft := f.Type
+		if f.Anonymous && name == "" {
+			if ft.Kind() == reflect.Pointer {
+				ft = ft.Elem()
+			}
+			if ft.Kind() == reflect.Struct {
+				g.addFields(ft, properties, required)
+				continue
+			}
+		}
+		if !f.IsExported() {
+			continue
+		}
+		if name == "" {
+			name = f.Name
+		}
+		properties[name] = g.schema(f.Type)
+		if !strings.Contains(opts, "omitempty") && !strings.Contains(opts, "omitzero") {
+			*required = append(*required, name)
+		}
+	}
+}
+
+// nullable returns the schema allowing null values in addition to s.
+func nullable(s map[string]any) map[string]any {
+	if len(s) == 0 {
+		return s
+	}
+	return map[string]any{"anyOf": []any{s, map[string]any{"type": "null"}}}
+}
+
+// schemaName returns the name of the definition of a named type.
+func schemaName(t reflect.Type) string {
+	return strings.ReplaceAll(t.PkgPath(), "/", ".") + "." + t.Name()
+}
@@ -0,0 +1,80 @@
+package inspector_test
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/qba73/inspector"
+)
+
+func TestPublishedReportSchemaIsUpToDate(t *testing.T) {
+	t.Parallel()
+
+	want, err := inspector.ReportSchema()
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := os.ReadFile("schema/report-v1.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(want)+"\n" != string(got) {
+		t.Error("schema/report-v1.json is out of date, run go generate")
+	}
+}
+
+func TestReportSchemaDescribesReportFields(t *testing.T) {
+	t.Parallel()
+
+	b, err := inspector.ReportSchema()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var schema struct {
+		ID         string                     `json:"$id"`
+		Properties map[string]json.RawMessage `json:"properties"`
+		Required   []string                   `json:"required"`
+	}
+	if err := json.Unmarshal(b, &schema); err != nil {
+		t.Fatal(err)
+	}
+	if schema.ID != inspector.SchemaID {
+		t.Errorf("want schema id %s, got %s", inspector.SchemaID, schema.ID)
+	}
+
+	i := &inspector.Inspector{K8sClient: newTestClientset(teaServiceDefaultNS), Collectors: []string{"services"}}
+	report, err := i.Report(context.Background(), "default")
+	if err != nil {
+		t.Fatal(err)
+	}
+	rb, err := json.Marshal(report)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(rb, &fields); err != nil {
+		t.Fatal(err)
+	}
+	for name := range fields {
+		if _, ok := schema.Properties[name]; !ok {
+			t.Errorf("report field %s missing in schema", name)
+		}
+	}
+	for _, name := range schema.Required {
+		if _, ok := fields[name]; !ok {
+			t.Errorf("required field %s missing in report", name)
+		}
+	}
+	var raw map[string]any
+	if err := json.Unmarshal(b, &raw); err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]any{"$ref": "#/$defs/k8s.io.api.core.v1.ServiceList"}
+	got := raw["properties"].(map[string]any)["services"].(map[string]any)["anyOf"].([]any)[0]
+	if !cmp.Equal(want, got) {
+		t.Error(cmp.Diff(want, got))
+	}
+}
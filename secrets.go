@@ -0,0 +1,214 @@
+package inspector
+
+import (
+	"bytes"
+	"cmp"
+	"context"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"slices"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+)
+
+// SecretInfo holds metadata of a Secret. Secret values and
+// annotations, which may hold values last applied by kubectl,
+// are never collected.
+type SecretInfo struct {
+	Namespace         string            `json:"namespace"`
+	Name              string            `json:"name"`
+	Type              corev1.SecretType `json:"type"`
+	Labels            map[string]string `json:"labels,omitempty"`
+	CreationTimestamp time.Time         `json:"creation_timestamp,omitzero"`
+	Keys              []SecretKey       `json:"keys"`
+	// Certificates describes the certificate chain
+	// in the tls.crt key of kubernetes.io/tls Secrets.
+	Certificates []CertificateInfo `json:"certificates,omitempty"`
+	// CertificateError reports why the certificate chain could not be read.
+	CertificateError string `json:"certificate_error,omitempty"`
+	// ChainIssues lists certificates of the chain that are not issued
+	// by the next certificate, and issuers missing from the chain.
+	ChainIssues []string `json:"chain_issues,omitempty"`
+	// KeyError reports a tls.key that cannot be read
+	// or does not match the certificate.
+	KeyError string `json:"key_error,omitempty"`
+}
+
+// SecretKey describes a key of a Secret and the size of its value.
+type SecretKey struct {
+	Name string `json:"name"`
+	Size int    `json:"size"`
+}
+
+// CertificateInfo describes an X.509 certificate.
+type CertificateInfo struct {
+	Subject     string    `json:"subject"`
+	Issuer      string    `json:"issuer"`
+	DNSNames    []string  `json:"dns_names,omitempty"`
+	IPAddresses []string  `json:"ip_addresses,omitempty"`
+	NotBefore   time.Time `json:"not_before"`
+	NotAfter    time.Time `json:"not_after"`
+	// KeyType is the public key algorithm and size, like RSA 2048 or ECDSA P-256.
+	KeyType string `json:"key_type"`
+	IsCA    bool   `json:"is_ca,omitempty"`
+}
+
+// Secrets returns metadata of [secrets] in a given namespace.
+// Certificates of TLS secrets are parsed, values are dropped.
+//
+// [secrets]: https://kubernetes.io/docs/concepts/configuration/secret/
+func (i *Inspector) Secrets(ctx context.Context, namespace string) ([]SecretInfo, error) {
+	secrets, err := listPages(ctx, i, "secrets", i.K8sClient.CoreV1().Secrets(namespace).List)
+	if err != nil {
+		return nil, err
+	}
+	infos := []SecretInfo{}
+	for _, s := range secrets.Items {
+		if !i.matchesNames("secrets", s.Name) {
+			continue
+		}
+		infos = append(infos, secretInfo(s))
+	}
+	return infos, nil
+}
+
+// secretInfo returns metadata of the secret.
+func secretInfo(s corev1.Secret) SecretInfo {
+	info := SecretInfo{
+		Namespace:         s.Namespace,
+		Name:              s.Name,
+		Type:              s.Type,
+		Labels:            s.Labels,
+		CreationTimestamp: s.CreationTimestamp.UTC(),
+		Keys:              []SecretKey{},
+	}
+	for name, value := range s.Data {
+		info.Keys = append(info.Keys, SecretKey{Name: name, Size: len(value)})
+	}
+	slices.SortFunc(info.Keys, func(a, b SecretKey) int {
+		return cmp.Compare(a.Name, b.Name)
+	})
+	if s.Type == corev1.SecretTypeTLS {
+		certPEM := s.Data[corev1.TLSCertKey]
+		certs, err := parseCertificates(certPEM)
+		if err != nil {
+			info.CertificateError = err.Error()
+		}
+		for _, cert := range certs {
+			info.Certificates = append(info.Certificates, certificateInfo(cert))
+		}
+		if err == nil {
+			info.ChainIssues = chainIssues(certs)
+			info.KeyError = keyPairError(certPEM, s.Data[corev1.TLSPrivateKeyKey])
+		}
+	}
+	return info
+}
+
+// ParseCertificates describes certificates in PEM encoded data.
+// Blocks other than certificates are skipped.
+func ParseCertificates(data []byte) ([]CertificateInfo, error) {
+	certs, err := parseCertificates(data)
+	var infos []CertificateInfo
+	for _, cert := range certs {
+		infos = append(infos, certificateInfo(cert))
+	}
+	return infos, err
+}
+
+// parseCertificates parses certificates in PEM encoded data.
+// It returns certificates parsed before a failure together with the error.
+func parseCertificates(data []byte) ([]*x509.Certificate, error) {
+	var certs []*x509.Certificate
+	for len(data) > 0 {
+		var block *pem.Block
+		block, data = pem.Decode(data)
+		if block == nil {
+			break
+		}
+		if block.Type != "CERTIFICATE" {
+			continue
+		}
+		cert, err := x509.ParseCertificate(block.Bytes)
+		if err != nil {
+			return certs, fmt.Errorf("parsing certificate %d: %w", len(certs)+1, err)
+		}
+		certs = append(certs, cert)
+	}
+	if len(certs) == 0 {
+		return nil, errors.New("no PEM encoded certificate found")
+	}
+	return certs, nil
+}
+
+// chainIssues checks that each certificate is issued by the next one,
+// and that the chain ends with a CA or a self-signed certificate.
+func chainIssues(certs []*x509.Certificate) []string {
+	var issues []string
+	for n := 0; n+1 < len(certs); n++ {
+		if err := certs[n].CheckSignatureFrom(certs[n+1]); err != nil {
+			issues = append(issues, fmt.Sprintf("certificate %d (%s) is not issued by certificate %d (%s): %v", n+1, certs[n].Subject, n+2, certs[n+1].Subject, err))
+		}
+	}
+	last := certs[len(certs)-1]
+	if !last.IsCA && !isSelfSigned(last) {
+		issues = append(issues, fmt.Sprintf("issuer %s of certificate %d (%s) is missing from the chain", last.Issuer, len(certs), last.Subject))
+	}
+	return issues
+}
+
+// isSelfSigned reports whether the certificate is signed by its own key.
+func isSelfSigned(cert *x509.Certificate) bool {
+	return bytes.Equal(cert.RawIssuer, cert.RawSubject) &&
+		cert.CheckSignature(cert.SignatureAlgorithm, cert.RawTBSCertificate, cert.Signature) == nil
+}
+
+// keyPairError returns why the private key cannot be
+// used with the certificate, or an empty string.
+func keyPairError(certPEM, keyPEM []byte) string {
+	if len(keyPEM) == 0 {
+		return corev1.TLSPrivateKeyKey + " is missing"
+	}
+	if _, err := tls.X509KeyPair(certPEM, keyPEM); err != nil {
+		return err.Error()
+	}
+	return ""
+}
+
+// certificateInfo describes the certificate.
+func certificateInfo(cert *x509.Certificate) CertificateInfo {
+	info := CertificateInfo{
+		Subject:   cert.Subject.String(),
+		Issuer:    cert.Issuer.String(),
+		DNSNames:  cert.DNSNames,
+		NotBefore: cert.NotBefore.UTC(),
+		NotAfter:  cert.NotAfter.UTC(),
+		KeyType:   publicKeyType(cert.PublicKey),
+		IsCA:      cert.IsCA,
+	}
+	for _, ip := range cert.IPAddresses {
+		info.IPAddresses = append(info.IPAddresses, ip.String())
+	}
+	return info
+}
+
+// publicKeyType returns the algorithm and size of the public key.
+func publicKeyType(key any) string {
+	switch k := key.(type) {
+	case *rsa.PublicKey:
+		return fmt.Sprintf("RSA %d", k.N.BitLen())
+	case *ecdsa.PublicKey:
+		return "ECDSA " + k.Curve.Params().Name
+	case ed25519.PublicKey:
+		return "Ed25519"
+	default:
+		return fmt.Sprintf("%T", key)
+	}
+}
@@ -0,0 +1,176 @@
+package inspector_test
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/json"
+	"encoding/pem"
+	"math/big"
+	"net"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/qba73/inspector"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	k8sruntime "k8s.io/apimachinery/pkg/runtime"
+)
+
+func TestInspectorCollectsSecretMetadataWithoutValues(t *testing.T) {
+	t.Parallel()
+
+	i := &inspector.Inspector{K8sClient: newTestClientset(basicAuthSecret), Collectors: []string{"secrets"}}
+	report, err := i.Report(context.Background(), "default")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []inspector.SecretInfo{{
+		Namespace: "default",
+		Name:      "registry-auth",
+		Type:      corev1.SecretTypeBasicAuth,
+		Labels:    map[string]string{"app": "web"},
+		Keys: []inspector.SecretKey{
+			{Name: "password", Size: 15},
+			{Name: "username", Size: 5},
+		},
+	}}
+	if !cmp.Equal(want, report.Secrets) {
+		t.Error(cmp.Diff(want, report.Secrets))
+	}
+	b, err := json.Marshal(report)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, value := range []string{"s3cr3t-p4ssw0rd", "admin", "password=s3cr3t"} {
+		if strings.Contains(string(b), value) {
+			t.Errorf("report contains secret value %q", value)
+		}
+	}
+}
+
+func TestInspectorParsesCertificatesOfTLSSecrets(t *testing.T) {
+	t.Parallel()
+
+	notBefore := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	notAfter := time.Date(2027, 1, 1, 0, 0, 0, 0, time.UTC)
+	secret := tlsSecret("shop-tls", selfSignedCertificate(t, "shop.example.com", notBefore, notAfter))
+	i := &inspector.Inspector{K8sClient: newTestClientset(secret)}
+
+	secrets, err := i.Secrets(context.Background(), "default")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(secrets) != 1 {
+		t.Fatalf("want 1 secret, got %d", len(secrets))
+	}
+	want := []inspector.CertificateInfo{{
+		Subject:     "CN=shop.example.com,O=Shop",
+		Issuer:      "CN=shop.example.com,O=Shop",
+		DNSNames:    []string{"shop.example.com", "www.shop.example.com"},
+		IPAddresses: []string{"10.0.0.1"},
+		NotBefore:   notBefore,
+		NotAfter:    notAfter,
+		KeyType:     "ECDSA P-256",
+	}}
+	if !cmp.Equal(want, secrets[0].Certificates) {
+		t.Error(cmp.Diff(want, secrets[0].Certificates))
+	}
+	if secrets[0].CertificateError != "" {
+		t.Errorf("want no certificate error, got %q", secrets[0].CertificateError)
+	}
+}
+
+func TestInspectorReportsUnreadableCertificatesOfTLSSecrets(t *testing.T) {
+	t.Parallel()
+
+	i := &inspector.Inspector{K8sClient: newTestClientset(tlsSecret("broken-tls", []byte("not a certificate")))}
+	secrets, err := i.Secrets(context.Background(), "default")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(secrets) != 1 || secrets[0].CertificateError == "" || secrets[0].Certificates != nil {
+		t.Errorf("want certificate error, got %+v", secrets)
+	}
+}
+
+func TestInspectorNeverStreamsSecrets(t *testing.T) {
+	t.Parallel()
+
+	var streamed []string
+	i := &inspector.Inspector{
+		K8sClient:  newTestClientset(basicAuthSecret),
+		Collectors: []string{"secrets"},
+		PageHandler: func(collector string, page k8sruntime.Object) error {
+			streamed = append(streamed, collector)
+			return nil
+		},
+	}
+	report, err := i.Report(context.Background(), "default")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(streamed) != 0 {
+		t.Errorf("want no streamed pages, got %v", streamed)
+	}
+	if len(report.Secrets) != 1 {
+		t.Errorf("want secret metadata in the report, got %+v", report.Secrets)
+	}
+}
+
+// selfSignedCertificate returns a PEM encoded self-signed certificate.
+func selfSignedCertificate(t *testing.T, commonName string, notBefore, notAfter time.Time) []byte {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: commonName, Organization: []string{"Shop"}},
+		DNSNames:     []string{commonName, "www." + commonName},
+		IPAddresses:  []net.IP{net.ParseIP("10.0.0.1")},
+		NotBefore:    notBefore,
+		NotAfter:     notAfter,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})
+}
+
+// tlsSecret returns a TLS secret holding the certificate.
+func tlsSecret(name string, cert []byte) *corev1.Secret {
+	return &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "default"},
+		Type:       corev1.SecretTypeTLS,
+		Data: map[string][]byte{
+			corev1.TLSCertKey:       cert,
+			corev1.TLSPrivateKeyKey: []byte("private key"),
+		},
+	}
+}
+
+var basicAuthSecret = &corev1.Secret{
+	ObjectMeta: metav1.ObjectMeta{
+		Name:      "registry-auth",
+		Namespace: "default",
+		Labels:    map[string]string{"app": "web"},
+		Annotations: map[string]string{
+			"kubectl.kubernetes.io/last-applied-configuration": `{"stringData":{"password=s3cr3t"}}`,
+		},
+	},
+	Type: corev1.SecretTypeBasicAuth,
+	Data: map[string][]byte{
+		"username": []byte("admin"),
+		"password": []byte("s3cr3t-p4ssw0rd"),
+	},
+}
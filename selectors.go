@@ -0,0 +1,116 @@
+package inspector
+
+import (
+	"fmt"
+	"path"
+	"slices"
+
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+// Selector filters objects listed by a collector.
+type Selector struct {
+	LabelSelector string `json:"label_selector,omitempty"`
+	FieldSelector string `json:"field_selector,omitempty"`
+	// Names lists glob patterns, like nginx-*, matched against
+	// object names. Objects matching any pattern are kept.
+	Names []string `json:"names,omitempty"`
+}
+
+// validate reports malformed name patterns.
+func (s Selector) validate() error {
+	for _, pattern := range s.Names {
+		if _, err := path.Match(pattern, ""); err != nil {
+			return fmt.Errorf("name pattern %q: %w", pattern, err)
+		}
+	}
+	return nil
+}
+
+// selector returns the selector of the named collector. Selectors set for
+// the collector override the inspector selectors, which apply only to
+// namespaced collectors other than events.
+func (i *Inspector) selector(collector string) Selector {
+	var s Selector
+	if filteredByDefault(collector) {
+		s = Selector{LabelSelector: i.LabelSelector, FieldSelector: i.FieldSelector, Names: i.Names}
+	}
+	cs, ok := i.Selectors[collector]
+	if !ok {
+		return s
+	}
+	if cs.LabelSelector != "" {
+		s.LabelSelector = cs.LabelSelector
+	}
+	if cs.FieldSelector != "" {
+		s.FieldSelector = cs.FieldSelector
+	}
+	if len(cs.Names) > 0 {
+		s.Names = cs.Names
+	}
+	return s
+}
+
+// filteredByDefault reports whether inspector selectors apply to
+// the collector. Events are not filtered, as they are named after
+// and labelled unlike the objects they describe.
+func filteredByDefault(collector string) bool {
+	if collector == "events" || collector == "events_v1" {
+		return false
+	}
+	for _, c := range Collectors {
+		if c.Name == collector {
+			return len(c.Access) > 0 && c.Access[0].Namespaced
+		}
+	}
+	return false
+}
+
+// listOptions returns options filtering objects listed by the collector.
+func (i *Inspector) listOptions(collector string) metav1.ListOptions {
+	s := i.selector(collector)
+	return metav1.ListOptions{LabelSelector: s.LabelSelector, FieldSelector: s.FieldSelector}
+}
+
+// matchesNames reports whether the object name matches
+// name patterns of the collector.
+func (i *Inspector) matchesNames(collector, name string) bool {
+	return matchName(i.selector(collector).Names, name)
+}
+
+// matchName reports whether name matches any of the patterns.
+// Any name matches an empty list of patterns.
+func matchName(patterns []string, name string) bool {
+	if len(patterns) == 0 {
+		return true
+	}
+	return slices.ContainsFunc(patterns, func(pattern string) bool {
+		ok, _ := path.Match(pattern, name)
+		return ok
+	})
+}
+
+// filterNames removes items with names not matching
+// the patterns from a K8s list.
+func filterNames(list runtime.Object, patterns []string) error {
+	if len(patterns) == 0 {
+		return nil
+	}
+	items, err := meta.ExtractList(list)
+	if err != nil {
+		return err
+	}
+	kept := items[:0]
+	for _, item := range items {
+		obj, err := meta.Accessor(item)
+		if err != nil {
+			return err
+		}
+		if matchName(patterns, obj.GetName()) {
+			kept = append(kept, item)
+		}
+	}
+	return meta.SetList(list, kept)
+}
@@ -0,0 +1,85 @@
+package inspector_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/qba73/inspector"
+
+	corev1 "k8s.io/api/core/v1"
+	apiextv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	apiextfake "k8s.io/apiextensions-apiserver/pkg/client/clientset/clientset/fake"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestInspectorReportFiltersObjectsWithNameGlobs(t *testing.T) {
+	t.Parallel()
+
+	i := &inspector.Inspector{
+		K8sClient:  newTestClientset(teaServiceDefaultNS, coffeeServiceDefaultNS, eventTeaService),
+		Collectors: []string{"services", "events"},
+		Names:      []string{"*-tea"},
+	}
+	report, err := i.Report(context.Background(), "default")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got []string
+	for _, svc := range report.Services.Items {
+		got = append(got, svc.Name)
+	}
+	want := []string{"service-tea"}
+	if !cmp.Equal(want, got) {
+		t.Error(cmp.Diff(want, got))
+	}
+	if len(report.Events.Items) != 1 {
+		t.Errorf("want events not filtered by name globs, got %d events", len(report.Events.Items))
+	}
+}
+
+func TestInspectorReportAppliesCollectorSelectors(t *testing.T) {
+	t.Parallel()
+
+	i := &inspector.Inspector{
+		K8sClient:  newTestClientset(teaServiceDefaultNS, coffeeServiceDefaultNS),
+		CRDClient:  apiextfake.NewSimpleClientset(crdGateways, crdVirtualServers),
+		Collectors: []string{"services", "crds"},
+		Names:      []string{"*-tea"},
+		Selectors: map[string]inspector.Selector{
+			"services": {Names: []string{"*-coffee"}},
+			"crds":     {Names: []string{"*.gateway.networking.k8s.io"}},
+		},
+	}
+	report, err := i.Report(context.Background(), "default")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got []string
+	for _, svc := range report.Services.Items {
+		got = append(got, svc.Name)
+	}
+	for _, crd := range report.CRDs.Items {
+		got = append(got, crd.Name)
+	}
+	want := []string{"service-coffee", "gateways.gateway.networking.k8s.io"}
+	if !cmp.Equal(want, got) {
+		t.Error(cmp.Diff(want, got))
+	}
+}
+
+var (
+	eventTeaService = &corev1.Event{
+		ObjectMeta:     metav1.ObjectMeta{Name: "service-tea.17a", Namespace: "default"},
+		InvolvedObject: corev1.ObjectReference{Kind: "Service", Namespace: "default", Name: "service-tea"},
+		Reason:         "Created",
+	}
+
+	crdGateways = &apiextv1.CustomResourceDefinition{
+		ObjectMeta: metav1.ObjectMeta{Name: "gateways.gateway.networking.k8s.io"},
+	}
+
+	crdVirtualServers = &apiextv1.CustomResourceDefinition{
+		ObjectMeta: metav1.ObjectMeta{Name: "virtualservers.k8s.nginx.org"},
+	}
+)
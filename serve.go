@@ -0,0 +1,436 @@
+package inspector
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"maps"
+	"net"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+)
+
+// DefaultRefreshInterval is the interval at which the server
+// collects reports of its namespaces in the background.
+const DefaultRefreshInterval = 5 * time.Minute
+
+// MaxRequestedReports is the number of latest reports the server keeps
+// of namespaces requested with AnyNamespace, beyond its Namespaces.
+// Reports collected least recently are evicted first.
+const MaxRequestedReports = 10
+
+// Server serves reports, findings and Prometheus metrics over HTTP:
+//
+//   - GET /report?namespace=ns collects a report and returns it
+//   - GET /report/latest?namespace=ns returns the latest collected report
+//   - GET /findings?namespace=ns returns findings of the latest report,
+//     collecting one when none was collected yet
+//   - GET /metrics returns gauges derived from the latest reports
+//   - GET /healthz reports the server is running
+//
+// The namespace defaults to the first of Namespaces. Requests for other
+// namespaces are rejected unless AnyNamespace is set.
+type Server struct {
+	Inspector *Inspector
+	// Namespaces are collected in the background.
+	Namespaces []string
+	// AnyNamespace allows requests for namespaces not in Namespaces.
+	AnyNamespace bool
+	// Interval is the interval of background collections,
+	// 0 disables them so reports are collected only on request.
+	Interval time.Duration
+
+	// collecting serializes collections, so concurrent
+	// requests do not multiply the load on the API server.
+	collecting sync.Mutex
+	mu         sync.RWMutex
+	latest     map[string]Report
+	// unhealthy counts unhealthy pods of latest reports, counted
+	// before pod status is trimmed.
+	unhealthy map[string]int
+	// requested lists namespaces not in Namespaces
+	// with latest reports, least recently collected first.
+	requested []string
+}
+
+// Handler returns the HTTP handler of the server.
+func (s *Server) Handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /report", s.handleReport)
+	mux.HandleFunc("GET /report/latest", s.handleLatest)
+	mux.HandleFunc("GET /findings", s.handleFindings)
+	mux.HandleFunc("GET /metrics", s.handleMetrics)
+	mux.HandleFunc("GET /healthz", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintln(w, "ok")
+	})
+	return mux
+}
+
+// Run serves HTTP requests on addr and collects reports of the server
+// namespaces every Interval until ctx is canceled.
+func (s *Server) Run(ctx context.Context, addr string) error {
+	ln, err := net.Listen("tcp", addr)
+	if err != nil {
+		return &ConfigError{Err: err}
+	}
+	return s.Serve(ctx, ln)
+}
+
+// Serve serves HTTP requests accepted by ln and collects reports
+// of the server namespaces every Interval until ctx is canceled
+// or serving fails.
+func (s *Server) Serve(ctx context.Context, ln net.Listener) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	srv := &http.Server{
+		Handler:           s.Handler(),
+		ReadHeaderTimeout: 10 * time.Second,
+		BaseContext:       func(net.Listener) context.Context { return ctx },
+	}
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		s.refresh(ctx)
+	}()
+	go func() {
+		<-ctx.Done()
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+		srv.Shutdown(shutdownCtx)
+	}()
+	s.Inspector.logger().Info("server started", "addr", ln.Addr().String(), "namespaces", strings.Join(s.Namespaces, ","))
+	err := srv.Serve(ln)
+	// Stop background collections when serving failed.
+	cancel()
+	<-done
+	if errors.Is(err, http.ErrServerClosed) {
+		return nil
+	}
+	return err
+}
+
+// refresh collects reports of the server namespaces every Interval.
+func (s *Server) refresh(ctx context.Context) {
+	if s.Interval <= 0 {
+		return
+	}
+	ticker := time.NewTicker(s.Interval)
+	defer ticker.Stop()
+	for {
+		for _, ns := range s.Namespaces {
+			if _, err := s.Collect(ctx, ns); err != nil && ctx.Err() == nil {
+				s.Inspector.logger().Error("collection failed", "namespace", ns, "kind", ErrorKind(err), "error", err.Error())
+			}
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Collect collects a report of the namespace and stores it as the latest
+// report of the namespace. Reports of partial collections are stored
+// and returned along with the [PartialCollectionError]. Of namespaces
+// not in Namespaces, the latest MaxRequestedReports reports are kept.
+func (s *Server) Collect(ctx context.Context, namespace string) (Report, error) {
+	s.collecting.Lock()
+	defer s.collecting.Unlock()
+	untrimmed := *s.Inspector
+	// Objects are trimmed once pods are counted for metrics.
+	untrimmed.Trim = TrimOptions{KeepManagedFields: true, KeepLastApplied: true}
+	report, err := untrimmed.Report(ctx, namespace)
+	var partial *PartialCollectionError
+	if err != nil && !errors.As(err, &partial) {
+		return Report{}, err
+	}
+	unhealthy := unhealthyPods(report.Pods)
+	if trimErr := trimReport(&report, s.Inspector.Trim); trimErr != nil {
+		return Report{}, trimErr
+	}
+	report.Metadata.Options = s.Inspector.effectiveOptions()
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.latest == nil {
+		s.latest = map[string]Report{}
+		s.unhealthy = map[string]int{}
+	}
+	s.latest[namespace] = report
+	s.unhealthy[namespace] = unhealthy
+	if !slices.Contains(s.Namespaces, namespace) {
+		s.requested = append(slices.DeleteFunc(s.requested, func(ns string) bool { return ns == namespace }), namespace)
+		if len(s.requested) > MaxRequestedReports {
+			delete(s.latest, s.requested[0])
+			delete(s.unhealthy, s.requested[0])
+			s.requested = slices.Delete(s.requested, 0, 1)
+		}
+	}
+	return report, err
+}
+
+// Latest returns the latest report collected from the namespace.
+func (s *Server) Latest(namespace string) (Report, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	report, ok := s.latest[namespace]
+	return report, ok
+}
+
+// namespace returns the namespace requested in the query. Without
+// AnyNamespace, it writes an error for namespaces the server does not
+// serve and reports false.
+func (s *Server) namespace(w http.ResponseWriter, r *http.Request) (string, bool) {
+	served := s.Namespaces
+	if len(served) == 0 {
+		served = []string{"default"}
+	}
+	ns := r.URL.Query().Get("namespace")
+	if ns == "" {
+		return served[0], true
+	}
+	if !s.AnyNamespace && !slices.Contains(served, ns) {
+		http.Error(w, fmt.Sprintf("namespace %q is not served", ns), http.StatusForbidden)
+		return "", false
+	}
+	return ns, true
+}
+
+func (s *Server) handleReport(w http.ResponseWriter, r *http.Request) {
+	ns, ok := s.namespace(w, r)
+	if !ok {
+		return
+	}
+	report, err := s.Collect(r.Context(), ns)
+	var partial *PartialCollectionError
+	if err != nil && !errors.As(err, &partial) {
+		s.writeError(w, err)
+		return
+	}
+	s.writeJSON(w, report)
+}
+
+func (s *Server) handleLatest(w http.ResponseWriter, r *http.Request) {
+	ns, ok := s.namespace(w, r)
+	if !ok {
+		return
+	}
+	report, ok := s.Latest(ns)
+	if !ok {
+		http.Error(w, fmt.Sprintf("no report collected from namespace %q", ns), http.StatusNotFound)
+		return
+	}
+	s.writeJSON(w, report)
+}
+
+func (s *Server) handleFindings(w http.ResponseWriter, r *http.Request) {
+	ns, ok := s.namespace(w, r)
+	if !ok {
+		return
+	}
+	report, ok := s.Latest(ns)
+	if !ok {
+		var err error
+		report, err = s.Collect(r.Context(), ns)
+		var partial *PartialCollectionError
+		if err != nil && !errors.As(err, &partial) {
+			s.writeError(w, err)
+			return
+		}
+	}
+	findings := report.Findings
+	if findings == nil {
+		findings = []Finding{}
+	}
+	s.writeJSON(w, findings)
+}
+
+func (s *Server) handleMetrics(w http.ResponseWriter, r *http.Request) {
+	s.mu.RLock()
+	reports := make([]Report, 0, len(s.latest))
+	for _, report := range s.latest {
+		reports = append(reports, report)
+	}
+	unhealthy := maps.Clone(s.unhealthy)
+	s.mu.RUnlock()
+	slices.SortFunc(reports, func(a, b Report) int {
+		return strings.Compare(a.Namespace, b.Namespace)
+	})
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	writeMetrics(w, reports, func(rep Report) int { return unhealthy[rep.Namespace] })
+}
+
+func (s *Server) writeJSON(w http.ResponseWriter, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	if err := writeJSON(w, v); err != nil {
+		s.Inspector.logger().Warn("writing response failed", "error", err.Error())
+	}
+}
+
+// writeError writes err with the HTTP status matching its kind.
+func (s *Server) writeError(w http.ResponseWriter, err error) {
+	status := http.StatusInternalServerError
+	switch ErrorKind(err) {
+	case KindConfig:
+		status = http.StatusBadRequest
+	case KindAuth:
+		status = http.StatusForbidden
+	case KindConnectivity:
+		status = http.StatusBadGateway
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	writeJSON(w, map[string]string{"error": err.Error(), "kind": ErrorKind(err)})
+}
+
+// Ingress misconfiguration reasons reported in metrics
+// in addition to TLS check names.
+const (
+	ReasonServiceMissing   = "ServiceMissing"
+	ReasonNoReadyEndpoints = "NoReadyEndpoints"
+)
+
+// ingressChecks are checks of findings counted as ingress misconfigurations.
+var ingressChecks = []string{
+	CheckTLSSecretMissing,
+	CheckTLSSecretInvalid,
+	CheckTLSHostMismatch,
+	CheckTLSKeyMismatch,
+}
+
+// metric is a Prometheus gauge with its samples.
+type metric struct {
+	name    string
+	help    string
+	samples []sample
+}
+
+type sample struct {
+	labels []string // label names and values in pairs
+	value  float64
+}
+
+// WriteMetrics writes gauges derived from the reports
+// in the Prometheus text exposition format. Unhealthy pods
+// are counted from pod status, so reports trimmed of status
+// count every pod as unhealthy.
+func WriteMetrics(w io.Writer, reports []Report) error {
+	return writeMetrics(w, reports, func(rep Report) int { return unhealthyPods(rep.Pods) })
+}
+
+// writeMetrics writes gauges derived from the reports,
+// with unhealthy pods of a report counted by unhealthy.
+func writeMetrics(w io.Writer, reports []Report, unhealthy func(Report) int) error {
+	metrics := []metric{
+		{name: "inspector_report_timestamp_seconds", help: "Time the latest report of the namespace was collected."},
+		{name: "inspector_collector_errors", help: "Collectors that failed in the latest report."},
+		{name: "inspector_findings", help: "Findings of analyzers by check and severity."},
+		{name: "inspector_unhealthy_pods", help: "Pods that failed, are pending or have containers that are not ready."},
+		{name: "inspector_expiring_certificates", help: "Certificates expiring within the expiry window."},
+		{name: "inspector_expired_certificates", help: "Certificates that expired."},
+		{name: "inspector_ingress_misconfigurations", help: "Ingress backends and TLS configurations that cannot serve traffic, by reason."},
+	}
+	for _, rep := range reports {
+		ns := []string{"namespace", rep.Namespace}
+		if !rep.Metadata.FinishedAt.IsZero() {
+			metrics[0].samples = append(metrics[0].samples, sample{ns, float64(rep.Metadata.FinishedAt.Unix())})
+		}
+		metrics[1].samples = append(metrics[1].samples, sample{ns, float64(len(rep.Errors))})
+
+		type findingKey struct{ check, severity string }
+		findings := map[findingKey]int{}
+		checks := map[string]int{}
+		for _, f := range rep.Findings {
+			findings[findingKey{f.Check, f.Severity}]++
+			checks[f.Check]++
+		}
+		keys := make([]findingKey, 0, len(findings))
+		for k := range findings {
+			keys = append(keys, k)
+		}
+		slices.SortFunc(keys, func(a, b findingKey) int {
+			return strings.Compare(a.check+"/"+a.severity, b.check+"/"+b.severity)
+		})
+		for _, k := range keys {
+			labels := append(slices.Clone(ns), "check", k.check, "severity", k.severity)
+			metrics[2].samples = append(metrics[2].samples, sample{labels, float64(findings[k])})
+		}
+
+		metrics[3].samples = append(metrics[3].samples, sample{ns, float64(unhealthy(rep))})
+		metrics[4].samples = append(metrics[4].samples, sample{ns, float64(checks[CheckCertificateExpiring])})
+		metrics[5].samples = append(metrics[5].samples, sample{ns, float64(checks[CheckCertificateExpired])})
+
+		reasons := map[string]int{}
+		for _, b := range rep.Backends.Ingresses {
+			switch {
+			case !b.ServiceFound:
+				reasons[ReasonServiceMissing]++
+			case b.Endpoints.Ready == 0:
+				reasons[ReasonNoReadyEndpoints]++
+			}
+		}
+		for _, check := range ingressChecks {
+			reasons[check] = checks[check]
+		}
+		for _, reason := range append([]string{ReasonNoReadyEndpoints, ReasonServiceMissing}, ingressChecks...) {
+			labels := append(slices.Clone(ns), "reason", reason)
+			metrics[6].samples = append(metrics[6].samples, sample{labels, float64(reasons[reason])})
+		}
+	}
+
+	var b strings.Builder
+	for _, m := range metrics {
+		fmt.Fprintf(&b, "# HELP %s %s\n# TYPE %s gauge\n", m.name, m.help, m.name)
+		for _, s := range m.samples {
+			b.WriteString(m.name)
+			if len(s.labels) > 0 {
+				b.WriteByte('{')
+				for i := 0; i < len(s.labels); i += 2 {
+					if i > 0 {
+						b.WriteByte(',')
+					}
+					fmt.Fprintf(&b, "%s=\"%s\"", s.labels[i], labelValueEscaper.Replace(s.labels[i+1]))
+				}
+				b.WriteByte('}')
+			}
+			fmt.Fprintf(&b, " %s\n", strconv.FormatFloat(s.value, 'f', -1, 64))
+		}
+	}
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// labelValueEscaper escapes label values as the Prometheus text format expects.
+var labelValueEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+// unhealthyPods returns the number of pods that failed, are pending
+// or run containers that are not ready. Succeeded pods are healthy.
+func unhealthyPods(pods *corev1.PodList) int {
+	if pods == nil {
+		return 0
+	}
+	n := 0
+	for _, pod := range pods.Items {
+		switch pod.Status.Phase {
+		case corev1.PodSucceeded:
+			continue
+		case corev1.PodRunning:
+			for _, cs := range pod.Status.ContainerStatuses {
+				if !cs.Ready {
+					n++
+					break
+				}
+			}
+		default:
+			n++
+		}
+	}
+	return n
+}
@@ -0,0 +1,351 @@
+package inspector_test
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/qba73/inspector"
+
+	corev1 "k8s.io/api/core/v1"
+	netv1 "k8s.io/api/networking/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestServerReportCollectsNamespaceOnRequest(t *testing.T) {
+	t.Parallel()
+
+	s := newTestServer()
+	srv := httptest.NewServer(s.Handler())
+	defer srv.Close()
+
+	if got := getStatus(t, srv.URL+"/report/latest?namespace=shop"); got != http.StatusNotFound {
+		t.Errorf("want status %d before a report is collected, got %d", http.StatusNotFound, got)
+	}
+	var report inspector.Report
+	getJSON(t, srv.URL+"/report?namespace=shop", &report)
+	if report.Namespace != "shop" || report.Ingresses == nil || len(report.Ingresses.Items) != 1 {
+		t.Errorf("want report of the shop namespace with its ingress, got %+v", report)
+	}
+	var latest inspector.Report
+	getJSON(t, srv.URL+"/report/latest?namespace=shop", &latest)
+	if !latest.Metadata.FinishedAt.Equal(report.Metadata.FinishedAt) {
+		t.Errorf("want latest report collected at %v, got %v", report.Metadata.FinishedAt, latest.Metadata.FinishedAt)
+	}
+}
+
+func TestServerRejectsNamespacesItDoesNotServe(t *testing.T) {
+	t.Parallel()
+
+	s := newTestServer()
+	srv := httptest.NewServer(s.Handler())
+	defer srv.Close()
+
+	for _, path := range []string{"/report", "/report/latest", "/findings"} {
+		if got := getStatus(t, srv.URL+path+"?namespace=kube-system"); got != http.StatusForbidden {
+			t.Errorf("%s: want status %d, got %d", path, http.StatusForbidden, got)
+		}
+	}
+	if _, ok := s.Latest("kube-system"); ok {
+		t.Error("want no report of the kube-system namespace collected")
+	}
+}
+
+func TestServerKeepsLatestReportsOfRecentlyRequestedNamespaces(t *testing.T) {
+	t.Parallel()
+
+	s := newTestServer()
+	s.AnyNamespace = true
+	srv := httptest.NewServer(s.Handler())
+	defer srv.Close()
+
+	for n := range inspector.MaxRequestedReports + 1 {
+		var report inspector.Report
+		getJSON(t, srv.URL+"/report?namespace=ns-"+strconv.Itoa(n), &report)
+	}
+	if _, ok := s.Latest("ns-0"); ok {
+		t.Error("want report of the least recently requested namespace evicted")
+	}
+	for _, ns := range []string{"ns-1", "ns-" + strconv.Itoa(inspector.MaxRequestedReports)} {
+		if _, ok := s.Latest(ns); !ok {
+			t.Errorf("want report of %s kept", ns)
+		}
+	}
+	if _, err := s.Collect(context.Background(), "shop"); err != nil {
+		t.Fatal(err)
+	}
+	var report inspector.Report
+	getJSON(t, srv.URL+"/report?namespace=ns-0", &report)
+	if _, ok := s.Latest("shop"); !ok {
+		t.Error("want report of a served namespace never evicted")
+	}
+}
+
+func TestServerFindingsCollectsReportOfDefaultNamespace(t *testing.T) {
+	t.Parallel()
+
+	s := newTestServer()
+	srv := httptest.NewServer(s.Handler())
+	defer srv.Close()
+
+	var findings []inspector.Finding
+	getJSON(t, srv.URL+"/findings", &findings)
+	if len(findings) != 1 || findings[0].Check != inspector.CheckTLSSecretMissing {
+		t.Errorf("want missing TLS secret finding, got %+v", findings)
+	}
+	if _, ok := s.Latest("shop"); !ok {
+		t.Error("want report of the shop namespace stored as latest")
+	}
+}
+
+func TestServerMetricsPublishGaugesOfLatestReports(t *testing.T) {
+	t.Parallel()
+
+	s := newTestServer()
+	if _, err := s.Collect(context.Background(), "shop"); err != nil {
+		t.Fatal(err)
+	}
+	srv := httptest.NewServer(s.Handler())
+	defer srv.Close()
+
+	resp, err := http.Get(srv.URL + "/metrics")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	if ct := resp.Header.Get("Content-Type"); !strings.HasPrefix(ct, "text/plain; version=0.0.4") {
+		t.Errorf("want Prometheus text format, got %q", ct)
+	}
+	b, err := io.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{
+		`inspector_findings{namespace="shop",check="TLSSecretMissing",severity="critical"} 1`,
+		`inspector_ingress_misconfigurations{namespace="shop",reason="ServiceMissing"} 1`,
+		`inspector_ingress_misconfigurations{namespace="shop",reason="TLSSecretMissing"} 1`,
+	} {
+		if !strings.Contains(string(b), want) {
+			t.Errorf("want %s in metrics, got:\n%s", want, b)
+		}
+	}
+}
+
+func TestServerMetricsCountUnhealthyPodsBeforeTrimmingStatus(t *testing.T) {
+	t.Parallel()
+
+	pod := func(name string, status corev1.PodStatus) *corev1.Pod {
+		return &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "shop"}, Status: status}
+	}
+	trim, err := inspector.ParseTrimOptions("status")
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := &inspector.Server{
+		Inspector: &inspector.Inspector{
+			K8sClient: newTestClientset(
+				pod("web", corev1.PodStatus{Phase: corev1.PodRunning, ContainerStatuses: []corev1.ContainerStatus{{Name: "web", Ready: true}}}),
+				pod("db", corev1.PodStatus{Phase: corev1.PodPending}),
+			),
+			Collectors: []string{"pods"},
+			Trim:       trim,
+		},
+		Namespaces: []string{"shop"},
+	}
+	report, err := s.Collect(context.Background(), "shop")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, p := range report.Pods.Items {
+		if p.Status.Phase != "" {
+			t.Errorf("want status of pod %s trimmed, got %+v", p.Name, p.Status)
+		}
+	}
+	if got := report.Metadata.Options["trim"]; got != trim.String() {
+		t.Errorf("want trim option %q in metadata, got %q", trim.String(), got)
+	}
+	srv := httptest.NewServer(s.Handler())
+	defer srv.Close()
+
+	resp, err := http.Get(srv.URL + "/metrics")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	b, err := io.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := `inspector_unhealthy_pods{namespace="shop"} 1`; !strings.Contains(string(b), want) {
+		t.Errorf("want %s in metrics, got:\n%s", want, b)
+	}
+}
+
+func TestWriteMetricsDerivesGaugesFromFindings(t *testing.T) {
+	t.Parallel()
+
+	report := inspector.Report{
+		Namespace: "shop",
+		Metadata:  inspector.Metadata{FinishedAt: time.Date(2026, 6, 1, 9, 0, 0, 0, time.UTC)},
+		Pods: &corev1.PodList{Items: []corev1.Pod{
+			{Status: corev1.PodStatus{Phase: corev1.PodRunning, ContainerStatuses: []corev1.ContainerStatus{{Ready: true}}}},
+			{Status: corev1.PodStatus{Phase: corev1.PodRunning, ContainerStatuses: []corev1.ContainerStatus{{Ready: true}, {Ready: false}}}},
+			{Status: corev1.PodStatus{Phase: corev1.PodPending}},
+			{Status: corev1.PodStatus{Phase: corev1.PodSucceeded}},
+		}},
+		Backends: inspector.NetworkAnalysis{Ingresses: []inspector.IngressBackend{
+			{Service: "web", ServiceFound: true, Endpoints: inspector.EndpointCounts{Ready: 2}},
+			{Service: "api", ServiceFound: true},
+		}},
+		Findings: []inspector.Finding{
+			{Check: inspector.CheckCertificateExpiring, Severity: inspector.SeverityWarning},
+			{Check: inspector.CheckCertificateExpiring, Severity: inspector.SeverityWarning},
+			{Check: inspector.CheckTLSHostMismatch, Severity: inspector.SeverityWarning},
+		},
+		Errors: []*inspector.CollectorError{{Collector: "events"}},
+	}
+	var b strings.Builder
+	if err := inspector.WriteMetrics(&b, []inspector.Report{report}); err != nil {
+		t.Fatal(err)
+	}
+	want := `# HELP inspector_report_timestamp_seconds Time the latest report of the namespace was collected.
+# TYPE inspector_report_timestamp_seconds gauge
+inspector_report_timestamp_seconds{namespace="shop"} 1780304400
+# HELP inspector_collector_errors Collectors that failed in the latest report.
+# TYPE inspector_collector_errors gauge
+inspector_collector_errors{namespace="shop"} 1
+# HELP inspector_findings Findings of analyzers by check and severity.
+# TYPE inspector_findings gauge
+inspector_findings{namespace="shop",check="CertificateExpiring",severity="warning"} 2
+inspector_findings{namespace="shop",check="TLSHostMismatch",severity="warning"} 1
+# HELP inspector_unhealthy_pods Pods that failed, are pending or have containers that are not ready.
+# TYPE inspector_unhealthy_pods gauge
+inspector_unhealthy_pods{namespace="shop"} 2
+# HELP inspector_expiring_certificates Certificates expiring within the expiry window.
+# TYPE inspector_expiring_certificates gauge
+inspector_expiring_certificates{namespace="shop"} 2
+# HELP inspector_expired_certificates Certificates that expired.
+# TYPE inspector_expired_certificates gauge
+inspector_expired_certificates{namespace="shop"} 0
+# HELP inspector_ingress_misconfigurations Ingress backends and TLS configurations that cannot serve traffic, by reason.
+# TYPE inspector_ingress_misconfigurations gauge
+inspector_ingress_misconfigurations{namespace="shop",reason="NoReadyEndpoints"} 1
+inspector_ingress_misconfigurations{namespace="shop",reason="ServiceMissing"} 0
+inspector_ingress_misconfigurations{namespace="shop",reason="TLSSecretMissing"} 0
+inspector_ingress_misconfigurations{namespace="shop",reason="TLSSecretInvalid"} 0
+inspector_ingress_misconfigurations{namespace="shop",reason="TLSHostMismatch"} 1
+inspector_ingress_misconfigurations{namespace="shop",reason="TLSKeyMismatch"} 0
+`
+	if !cmp.Equal(want, b.String()) {
+		t.Error(cmp.Diff(want, b.String()))
+	}
+}
+
+func TestServerServeCollectsNamespacesInBackground(t *testing.T) {
+	t.Parallel()
+
+	s := newTestServer()
+	s.Interval = time.Hour
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	served := make(chan error)
+	go func() {
+		served <- s.Serve(ctx, ln)
+	}()
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		if _, ok := s.Latest("shop"); ok {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("want report of the shop namespace collected in the background")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	if got := getStatus(t, "http://"+ln.Addr().String()+"/healthz"); got != http.StatusOK {
+		t.Errorf("want status %d, got %d", http.StatusOK, got)
+	}
+	cancel()
+	if err := <-served; err != nil {
+		t.Errorf("want server stopped without error, got %v", err)
+	}
+}
+
+func TestServerServeReturnsErrorWhenServingFails(t *testing.T) {
+	t.Parallel()
+
+	s := newTestServer()
+	s.Interval = time.Hour
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	ln.Close()
+	served := make(chan error)
+	go func() {
+		served <- s.Serve(context.Background(), ln)
+	}()
+	select {
+	case err := <-served:
+		if err == nil {
+			t.Error("want error of a closed listener")
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("want serve returned when serving failed")
+	}
+}
+
+// newTestServer returns a server of the shop namespace holding
+// an ingress with a missing TLS secret and backend service.
+func newTestServer() *inspector.Server {
+	ing := &netv1.Ingress{
+		ObjectMeta: metav1.ObjectMeta{Name: "web", Namespace: "shop"},
+		Spec: netv1.IngressSpec{
+			TLS:            []netv1.IngressTLS{{Hosts: []string{"shop.example.com"}, SecretName: "web-tls"}},
+			DefaultBackend: &netv1.IngressBackend{Service: &netv1.IngressServiceBackend{Name: "web"}},
+		},
+	}
+	return &inspector.Server{
+		Inspector: &inspector.Inspector{
+			K8sClient:  newTestClientset(ing),
+			Collectors: []string{"ingresses", "secrets", "services"},
+		},
+		Namespaces: []string{"shop"},
+	}
+}
+
+func getStatus(t *testing.T, url string) int {
+	t.Helper()
+	resp, err := http.Get(url)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	return resp.StatusCode
+}
+
+func getJSON(t *testing.T, url string, v any) {
+	t.Helper()
+	resp, err := http.Get(url)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("GET %s: want status %d, got %d", url, http.StatusOK, resp.StatusCode)
+	}
+	if err := json.NewDecoder(resp.Body).Decode(v); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,383 @@
+package inspector
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"hash"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"slices"
+	"strings"
+	"time"
+)
+
+// Sink stores reports. A report is written to the writer
+// returned by Create and stored when the writer is closed.
+// Writers implementing [Aborter] discard the report instead
+// when aborted.
+type Sink interface {
+	Create(ctx context.Context, destination string) (io.WriteCloser, error)
+}
+
+// Aborter is implemented by writers of sinks that can discard
+// a partly written report, so failed writes store nothing.
+type Aborter interface {
+	// Abort discards the report and releases the writer.
+	Abort() error
+}
+
+// abortWriter discards the report written to w.
+// Writers not implementing [Aborter] are closed.
+func abortWriter(w io.WriteCloser) error {
+	if a, ok := w.(Aborter); ok {
+		return a.Abort()
+	}
+	return w.Close()
+}
+
+// NewSink returns the sink storing reports at the destination:
+//
+//   - empty or - writes reports to w
+//   - s3://bucket/key uploads reports to an S3 compatible bucket
+//     configured with AWS environment variables, see [S3SinkFromEnv]
+//   - an http:// or https:// URL uploads reports with PUT requests,
+//     for example to pre-signed URLs of object storage
+//   - a file path or a file:// URL writes reports to local files
+func NewSink(destination string, w io.Writer) (Sink, error) {
+	switch {
+	case destination == "" || destination == "-":
+		return WriterSink{W: w}, nil
+	case strings.HasPrefix(destination, "s3://"):
+		return S3SinkFromEnv()
+	case strings.HasPrefix(destination, "http://"), strings.HasPrefix(destination, "https://"):
+		return HTTPSink{}, nil
+	default:
+		return FileSink{}, nil
+	}
+}
+
+// WriterSink writes reports to W.
+type WriterSink struct {
+	W io.Writer
+}
+
+// Create returns a writer of W. Closing it does not close W.
+func (s WriterSink) Create(context.Context, string) (io.WriteCloser, error) {
+	return nopWriteCloser{s.W}, nil
+}
+
+type nopWriteCloser struct {
+	io.Writer
+}
+
+func (nopWriteCloser) Close() error { return nil }
+
+// FileSink writes reports to local files.
+type FileSink struct{}
+
+// Create creates the file at the destination path,
+// which may be given as a file:// URL. Aborting the
+// writer removes the file.
+func (FileSink) Create(_ context.Context, destination string) (io.WriteCloser, error) {
+	f, err := os.Create(strings.TrimPrefix(destination, "file://"))
+	if err != nil {
+		return nil, &ConfigError{Err: err}
+	}
+	return fileWriter{f}, nil
+}
+
+// fileWriter writes a report to a local file.
+type fileWriter struct {
+	*os.File
+}
+
+// Abort closes and removes the partly written file.
+func (w fileWriter) Abort() error {
+	return errors.Join(w.File.Close(), os.Remove(w.Name()))
+}
+
+// HTTPSink uploads reports to URLs with PUT requests.
+// Pre-signed URLs of S3, GCS or Azure Blob Storage let
+// inspector upload reports without storage credentials.
+type HTTPSink struct {
+	// Client sends upload requests, http.DefaultClient when nil.
+	Client *http.Client
+}
+
+// Create returns a writer of the report uploaded to
+// the destination URL when the writer is closed.
+func (s HTTPSink) Create(ctx context.Context, destination string) (io.WriteCloser, error) {
+	if _, err := url.Parse(destination); err != nil {
+		return nil, &ConfigError{Err: fmt.Errorf("upload URL: %w", err)}
+	}
+	return newUploadWriter(func(body io.Reader, size int64, _ string) error {
+		req, err := http.NewRequestWithContext(ctx, http.MethodPut, destination, body)
+		if err != nil {
+			return err
+		}
+		req.ContentLength = size
+		return upload(s.Client, req)
+	})
+}
+
+// S3Sink uploads reports to S3 compatible object storage, like AWS S3
+// or MinIO. Requests are signed with AWS Signature Version 4.
+type S3Sink struct {
+	// Endpoint is the URL of the storage API, with buckets addressed
+	// in the URL path. When empty, reports are uploaded to the AWS S3
+	// endpoint of the region, with buckets addressed in the host name.
+	Endpoint string
+	// Region is the signing region, us-east-1 when empty.
+	Region string
+
+	AccessKeyID     string
+	SecretAccessKey string
+	// SessionToken is set for temporary credentials.
+	SessionToken string
+
+	// Client sends upload requests, http.DefaultClient when nil.
+	Client *http.Client
+	// Now returns the signing time, time.Now when nil.
+	Now func() time.Time
+}
+
+// S3SinkFromEnv returns an S3 sink configured with environment variables
+// used by AWS tools: AWS_ENDPOINT_URL_S3 or AWS_ENDPOINT_URL, AWS_REGION
+// or AWS_DEFAULT_REGION, AWS_ACCESS_KEY_ID, AWS_SECRET_ACCESS_KEY and
+// AWS_SESSION_TOKEN.
+func S3SinkFromEnv() (S3Sink, error) {
+	s := S3Sink{
+		Endpoint:        firstEnv("AWS_ENDPOINT_URL_S3", "AWS_ENDPOINT_URL"),
+		Region:          firstEnv("AWS_REGION", "AWS_DEFAULT_REGION"),
+		AccessKeyID:     os.Getenv("AWS_ACCESS_KEY_ID"),
+		SecretAccessKey: os.Getenv("AWS_SECRET_ACCESS_KEY"),
+		SessionToken:    os.Getenv("AWS_SESSION_TOKEN"),
+	}
+	if s.AccessKeyID == "" || s.SecretAccessKey == "" {
+		return S3Sink{}, &ConfigError{Err: errors.New("s3 destination needs AWS_ACCESS_KEY_ID and AWS_SECRET_ACCESS_KEY")}
+	}
+	return s, nil
+}
+
+// firstEnv returns the first non-empty environment variable.
+func firstEnv(names ...string) string {
+	for _, name := range names {
+		if v := os.Getenv(name); v != "" {
+			return v
+		}
+	}
+	return ""
+}
+
+// Create returns a writer of the report uploaded to
+// the s3://bucket/key destination when the writer is closed.
+func (s S3Sink) Create(ctx context.Context, destination string) (io.WriteCloser, error) {
+	u, err := s.objectURL(destination)
+	if err != nil {
+		return nil, &ConfigError{Err: err}
+	}
+	return newUploadWriter(func(body io.Reader, size int64, payloadHash string) error {
+		req, err := http.NewRequestWithContext(ctx, http.MethodPut, u.String(), body)
+		if err != nil {
+			return err
+		}
+		req.ContentLength = size
+		now := time.Now
+		if s.Now != nil {
+			now = s.Now
+		}
+		s.sign(req, payloadHash, now())
+		return upload(s.Client, req)
+	})
+}
+
+// objectURL returns the URL of the object at the s3://bucket/key destination.
+func (s S3Sink) objectURL(destination string) (*url.URL, error) {
+	dst, err := url.Parse(destination)
+	if err != nil {
+		return nil, fmt.Errorf("s3 destination: %w", err)
+	}
+	bucket, key := dst.Host, strings.TrimPrefix(dst.Path, "/")
+	if dst.Scheme != "s3" || bucket == "" || key == "" {
+		return nil, fmt.Errorf("s3 destination %q, want s3://bucket/key", destination)
+	}
+	if s.Endpoint == "" {
+		return &url.URL{
+			Scheme:  "https",
+			Host:    fmt.Sprintf("%s.s3.%s.amazonaws.com", bucket, s.region()),
+			Path:    "/" + key,
+			RawPath: "/" + s3EscapePath(key),
+		}, nil
+	}
+	u, err := url.Parse(s.Endpoint)
+	if err != nil {
+		return nil, fmt.Errorf("s3 endpoint: %w", err)
+	}
+	base := strings.TrimSuffix(u.Path, "/")
+	u.Path = base + "/" + bucket + "/" + key
+	u.RawPath = s3EscapePath(base + "/" + bucket + "/" + key)
+	return u, nil
+}
+
+func (s S3Sink) region() string {
+	if s.Region == "" {
+		return "us-east-1"
+	}
+	return s.Region
+}
+
+// sign adds AWS Signature Version 4 headers to the request.
+// The host and all headers of the request are signed.
+func (s S3Sink) sign(req *http.Request, payloadHash string, now time.Time) {
+	now = now.UTC()
+	date := now.Format("20060102T150405Z")
+	scope := fmt.Sprintf("%s/%s/s3/aws4_request", now.Format("20060102"), s.region())
+	req.Header.Set("X-Amz-Date", date)
+	req.Header.Set("X-Amz-Content-Sha256", payloadHash)
+	if s.SessionToken != "" {
+		req.Header.Set("X-Amz-Security-Token", s.SessionToken)
+	}
+
+	headers := map[string]string{"host": req.URL.Host}
+	for name, values := range req.Header {
+		headers[strings.ToLower(name)] = strings.Join(values, ",")
+	}
+	names := make([]string, 0, len(headers))
+	for name := range headers {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+	var canonicalHeaders strings.Builder
+	for _, name := range names {
+		fmt.Fprintf(&canonicalHeaders, "%s:%s\n", name, strings.TrimSpace(headers[name]))
+	}
+	signedHeaders := strings.Join(names, ";")
+	canonicalRequest := strings.Join([]string{
+		req.Method,
+		req.URL.EscapedPath(),
+		req.URL.Query().Encode(),
+		canonicalHeaders.String(),
+		signedHeaders,
+		payloadHash,
+	}, "\n")
+	stringToSign := strings.Join([]string{"AWS4-HMAC-SHA256", date, scope, hexSHA256([]byte(canonicalRequest))}, "\n")
+
+	key := hmacSHA256([]byte("AWS4"+s.SecretAccessKey), now.Format("20060102"))
+	for _, part := range []string{s.region(), "s3", "aws4_request"} {
+		key = hmacSHA256(key, part)
+	}
+	signature := hex.EncodeToString(hmacSHA256(key, stringToSign))
+	req.Header.Set("Authorization", fmt.Sprintf("AWS4-HMAC-SHA256 Credential=%s/%s, SignedHeaders=%s, Signature=%s", s.AccessKeyID, scope, signedHeaders, signature))
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	h := hmac.New(sha256.New, key)
+	h.Write([]byte(data))
+	return h.Sum(nil)
+}
+
+func hexSHA256(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+// s3EscapePath escapes the object path as S3 signatures expect:
+// all bytes except unreserved characters and slashes are percent encoded.
+func s3EscapePath(path string) string {
+	var b strings.Builder
+	for _, c := range []byte(path) {
+		switch {
+		case 'a' <= c && c <= 'z', 'A' <= c && c <= 'Z', '0' <= c && c <= '9',
+			c == '-', c == '_', c == '.', c == '~', c == '/':
+			b.WriteByte(c)
+		default:
+			fmt.Fprintf(&b, "%%%02X", c)
+		}
+	}
+	return b.String()
+}
+
+// UploadError reports an upload rejected by the storage server.
+type UploadError struct {
+	// URL is the upload URL without the query, which may hold signatures.
+	URL        string
+	StatusCode int
+	Message    string
+}
+
+func (e *UploadError) Error() string {
+	msg := fmt.Sprintf("uploading report to %s: %s", e.URL, http.StatusText(e.StatusCode))
+	if e.Message != "" {
+		msg += ": " + e.Message
+	}
+	return msg
+}
+
+// upload sends the upload request and checks the response status.
+func upload(client *http.Client, req *http.Request) error {
+	if client == nil {
+		client = http.DefaultClient
+	}
+	target := *req.URL
+	target.RawQuery = ""
+	resp, err := client.Do(req)
+	if err != nil {
+		var urlErr *url.Error
+		if errors.As(err, &urlErr) {
+			// Drop the query from the URL in the error.
+			urlErr.URL = target.String()
+		}
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+		return nil
+	}
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+	return &UploadError{URL: target.String(), StatusCode: resp.StatusCode, Message: strings.TrimSpace(string(body))}
+}
+
+// uploadWriter spools a report to a temporary file and uploads it
+// when closed, so large reports are not held in memory.
+type uploadWriter struct {
+	f      *os.File
+	hash   hash.Hash
+	size   int64
+	upload func(body io.Reader, size int64, payloadHash string) error
+}
+
+func newUploadWriter(upload func(body io.Reader, size int64, payloadHash string) error) (*uploadWriter, error) {
+	f, err := os.CreateTemp("", "inspector-report-*")
+	if err != nil {
+		return nil, err
+	}
+	return &uploadWriter{f: f, hash: sha256.New(), upload: upload}, nil
+}
+
+func (w *uploadWriter) Write(p []byte) (int, error) {
+	n, err := w.f.Write(p)
+	w.hash.Write(p[:n])
+	w.size += int64(n)
+	return n, err
+}
+
+// Abort removes the temporary file without uploading the report.
+func (w *uploadWriter) Abort() error {
+	return errors.Join(w.f.Close(), os.Remove(w.f.Name()))
+}
+
+// Close uploads the report and removes the temporary file.
+func (w *uploadWriter) Close() error {
+	defer os.Remove(w.f.Name())
+	defer w.f.Close()
+	if _, err := w.f.Seek(0, io.SeekStart); err != nil {
+		return err
+	}
+	return w.upload(w.f, w.size, hex.EncodeToString(w.hash.Sum(nil)))
+}
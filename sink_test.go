@@ -0,0 +1,268 @@
+package inspector_test
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"io"
+	"io/fs"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/qba73/inspector"
+)
+
+func TestNewSinkSelectsSinkByDestination(t *testing.T) {
+	t.Setenv("AWS_ACCESS_KEY_ID", "AKIDEXAMPLE")
+	t.Setenv("AWS_SECRET_ACCESS_KEY", "secret")
+	t.Setenv("AWS_REGION", "eu-west-1")
+	t.Setenv("AWS_ENDPOINT_URL_S3", "http://minio:9000")
+
+	tests := []struct {
+		destination string
+		want        inspector.Sink
+	}{
+		{destination: "", want: inspector.WriterSink{W: os.Stdout}},
+		{destination: "-", want: inspector.WriterSink{W: os.Stdout}},
+		{destination: "report.json", want: inspector.FileSink{}},
+		{destination: "file:///tmp/report.json", want: inspector.FileSink{}},
+		{destination: "https://storage.example.com/upload?sig=abc", want: inspector.HTTPSink{}},
+		{
+			destination: "s3://diagnostics/report.json",
+			want: inspector.S3Sink{
+				Endpoint:        "http://minio:9000",
+				Region:          "eu-west-1",
+				AccessKeyID:     "AKIDEXAMPLE",
+				SecretAccessKey: "secret",
+			},
+		},
+	}
+	for _, tc := range tests {
+		got, err := inspector.NewSink(tc.destination, os.Stdout)
+		if err != nil {
+			t.Fatalf("%q: %v", tc.destination, err)
+		}
+		if !reflect.DeepEqual(tc.want, got) {
+			t.Errorf("%q: want %#v, got %#v", tc.destination, tc.want, got)
+		}
+	}
+}
+
+func TestNewSinkRejectsS3DestinationWithoutCredentials(t *testing.T) {
+	t.Setenv("AWS_ACCESS_KEY_ID", "")
+	t.Setenv("AWS_SECRET_ACCESS_KEY", "")
+
+	_, err := inspector.NewSink("s3://diagnostics/report.json", os.Stdout)
+	if inspector.ExitCode(err) != inspector.ExitConfig {
+		t.Errorf("want configuration error, got %v", err)
+	}
+}
+
+func TestFileSinkWritesReportToFile(t *testing.T) {
+	t.Parallel()
+
+	path := filepath.Join(t.TempDir(), "report.json")
+	storeReport(t, inspector.FileSink{}, "file://"+path, "{}\n")
+	got, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(got) != "{}\n" {
+		t.Errorf("want report in file, got %q", got)
+	}
+}
+
+func TestFileSinkRemovesAbortedReport(t *testing.T) {
+	t.Parallel()
+
+	path := filepath.Join(t.TempDir(), "report.json")
+	abortReport(t, inspector.FileSink{}, path, `{"namespace":`)
+	if _, err := os.Stat(path); !errors.Is(err, fs.ErrNotExist) {
+		t.Errorf("want aborted report removed, got %v", err)
+	}
+}
+
+func TestHTTPSinkUploadsNothingWhenAborted(t *testing.T) {
+	t.Parallel()
+
+	var uploads atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		uploads.Add(1)
+	}))
+	defer srv.Close()
+
+	abortReport(t, inspector.HTTPSink{Client: srv.Client()}, srv.URL+"/reports/shop.json", `{"namespace":`)
+	if n := uploads.Load(); n != 0 {
+		t.Errorf("want aborted report not uploaded, got %d uploads", n)
+	}
+}
+
+func TestHTTPSinkUploadsReportWithPut(t *testing.T) {
+	t.Parallel()
+
+	var got uploadRequest
+	srv := httptest.NewServer(recordUpload(&got, http.StatusOK))
+	defer srv.Close()
+
+	storeReport(t, inspector.HTTPSink{Client: srv.Client()}, srv.URL+"/reports/shop.json?X-Amz-Signature=abc", `{"namespace":"shop"}`)
+	if got.method != http.MethodPut || got.path != "/reports/shop.json" || got.query != "X-Amz-Signature=abc" {
+		t.Errorf("want PUT /reports/shop.json?X-Amz-Signature=abc, got %s %s?%s", got.method, got.path, got.query)
+	}
+	if got.body != `{"namespace":"shop"}` || got.contentLength != int64(len(got.body)) {
+		t.Errorf("want report uploaded with its length, got %q of length %d", got.body, got.contentLength)
+	}
+}
+
+func TestHTTPSinkReportsRejectedUploadWithoutURLQuery(t *testing.T) {
+	t.Parallel()
+
+	var got uploadRequest
+	srv := httptest.NewServer(recordUpload(&got, http.StatusForbidden))
+	defer srv.Close()
+
+	w, err := inspector.HTTPSink{Client: srv.Client()}.Create(context.Background(), srv.URL+"/shop.json?X-Amz-Signature=secret")
+	if err != nil {
+		t.Fatal(err)
+	}
+	io.WriteString(w, "{}")
+	err = w.Close()
+	var uploadErr *inspector.UploadError
+	if !errors.As(err, &uploadErr) || uploadErr.StatusCode != http.StatusForbidden {
+		t.Fatalf("want upload error with status 403, got %v", err)
+	}
+	if inspector.ExitCode(err) != inspector.ExitAuth {
+		t.Errorf("want exit code %d, got %d", inspector.ExitAuth, inspector.ExitCode(err))
+	}
+	if strings.Contains(err.Error(), "secret") {
+		t.Errorf("want URL query left out of the error, got %q", err)
+	}
+}
+
+func TestS3SinkUploadsSignedReportToBucket(t *testing.T) {
+	t.Parallel()
+
+	var got uploadRequest
+	srv := httptest.NewServer(recordUpload(&got, http.StatusOK))
+	defer srv.Close()
+
+	// Requests to the MinIO endpoint go to the test server,
+	// so the signed host header is stable.
+	client := srv.Client()
+	client.Transport.(*http.Transport).DialContext = func(ctx context.Context, network, _ string) (net.Conn, error) {
+		return (&net.Dialer{}).DialContext(ctx, network, srv.Listener.Addr().String())
+	}
+	sink := inspector.S3Sink{
+		Endpoint:        "http://minio.test:9000",
+		Region:          "eu-west-1",
+		AccessKeyID:     "AKIDEXAMPLE",
+		SecretAccessKey: "wJalrXUtnFEMI/K7MDENG+bPxRfiCYEXAMPLEKEY",
+		SessionToken:    "session",
+		Client:          client,
+		Now: func() time.Time {
+			return time.Date(2026, 6, 1, 12, 0, 0, 0, time.UTC)
+		},
+	}
+	report := `{"namespace":"shop"}`
+	storeReport(t, sink, "s3://diagnostics/shop/report 1.json", report)
+
+	if got.method != http.MethodPut || got.rawPath != "/diagnostics/shop/report%201.json" {
+		t.Errorf("want PUT /diagnostics/shop/report%%201.json, got %s %s", got.method, got.rawPath)
+	}
+	if got.body != report {
+		t.Errorf("want report uploaded, got %q", got.body)
+	}
+	sum := sha256.Sum256([]byte(report))
+	if want := hex.EncodeToString(sum[:]); got.header.Get("X-Amz-Content-Sha256") != want {
+		t.Errorf("want payload hash %s, got %s", want, got.header.Get("X-Amz-Content-Sha256"))
+	}
+	if got.header.Get("X-Amz-Date") != "20260601T120000Z" || got.header.Get("X-Amz-Security-Token") != "session" {
+		t.Errorf("want signing date and session token headers, got %v", got.header)
+	}
+	wantAuth := "AWS4-HMAC-SHA256 Credential=AKIDEXAMPLE/20260601/eu-west-1/s3/aws4_request, " +
+		"SignedHeaders=host;x-amz-content-sha256;x-amz-date;x-amz-security-token, " +
+		"Signature=fbaf36ff04550c24c1f03487fd6679fa3bb97951b1b51cc2d904eea2de9b0222"
+	if got.header.Get("Authorization") != wantAuth {
+		t.Errorf("want authorization %q, got %q", wantAuth, got.header.Get("Authorization"))
+	}
+}
+
+func TestS3SinkRejectsDestinationWithoutKey(t *testing.T) {
+	t.Parallel()
+
+	_, err := inspector.S3Sink{AccessKeyID: "AKIDEXAMPLE", SecretAccessKey: "secret"}.Create(context.Background(), "s3://diagnostics")
+	if inspector.ExitCode(err) != inspector.ExitConfig {
+		t.Errorf("want configuration error, got %v", err)
+	}
+}
+
+// uploadRequest records an upload received by a test server.
+type uploadRequest struct {
+	method        string
+	path          string
+	rawPath       string
+	query         string
+	header        http.Header
+	contentLength int64
+	body          string
+}
+
+func recordUpload(got *uploadRequest, status int) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		*got = uploadRequest{
+			method:        r.Method,
+			path:          r.URL.Path,
+			rawPath:       r.URL.EscapedPath(),
+			query:         r.URL.RawQuery,
+			header:        r.Header,
+			contentLength: r.ContentLength,
+			body:          string(body),
+		}
+		w.WriteHeader(status)
+	}
+}
+
+// storeReport writes the report to the sink destination.
+func storeReport(t *testing.T, sink inspector.Sink, destination, report string) {
+	t.Helper()
+	w, err := sink.Create(context.Background(), destination)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := io.Copy(w, bytes.NewBufferString(report)); err != nil {
+		t.Fatal(err)
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// abortReport writes a part of the report to the sink destination
+// and aborts the writer.
+func abortReport(t *testing.T, sink inspector.Sink, destination, part string) {
+	t.Helper()
+	w, err := sink.Create(context.Background(), destination)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := io.WriteString(w, part); err != nil {
+		t.Fatal(err)
+	}
+	a, ok := w.(inspector.Aborter)
+	if !ok {
+		t.Fatalf("want writer of %T implementing Aborter", sink)
+	}
+	if err := a.Abort(); err != nil {
+		t.Fatal(err)
+	}
+}
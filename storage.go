@@ -0,0 +1,207 @@
+package inspector
+
+import (
+	"context"
+	"fmt"
+
+	corev1 "k8s.io/api/core/v1"
+	storagev1 "k8s.io/api/storage/v1"
+)
+
+// Storage checks.
+const (
+	CheckPVCPending   = "PVCPending"
+	CheckPVReleased   = "PVReleased"
+	CheckVolumeAttach = "VolumeAttachPending"
+)
+
+// Event reasons reported by kubelet and the attach/detach controller
+// when a volume cannot be attached or mounted.
+const (
+	reasonFailedAttach = "FailedAttachVolume"
+	reasonFailedMount  = "FailedMount"
+)
+
+// PersistentVolumeClaims returns a list of [persistent volume claims] in a given namespace.
+//
+// [persistent volume claims]: https://kubernetes.io/docs/concepts/storage/persistent-volumes/#persistentvolumeclaims
+func (i *Inspector) PersistentVolumeClaims(ctx context.Context, namespace string) (*corev1.PersistentVolumeClaimList, error) {
+	pvcs, err := listPages(ctx, i, "persistent_volume_claims", i.K8sClient.CoreV1().PersistentVolumeClaims(namespace).List)
+	if err != nil {
+		return nil, err
+	}
+	return pvcs, nil
+}
+
+// PersistentVolumes returns a list of [persistent volumes] claimed
+// from a given namespace. Released volumes still reference their former
+// claim and are included.
+//
+// [persistent volumes]: https://kubernetes.io/docs/concepts/storage/persistent-volumes/
+func (i *Inspector) PersistentVolumes(ctx context.Context, namespace string) (*corev1.PersistentVolumeList, error) {
+	pvs, err := listPages(ctx, i, "persistent_volumes", i.K8sClient.CoreV1().PersistentVolumes().List)
+	if err != nil {
+		return nil, err
+	}
+	claimed := &corev1.PersistentVolumeList{TypeMeta: pvs.TypeMeta, ListMeta: pvs.ListMeta}
+	for _, pv := range pvs.Items {
+		if pv.Spec.ClaimRef != nil && pv.Spec.ClaimRef.Namespace == namespace {
+			claimed.Items = append(claimed.Items, pv)
+		}
+	}
+	return claimed, nil
+}
+
+// StorageClasses returns a list of [storage classes] in a cluster.
+//
+// [storage classes]: https://kubernetes.io/docs/concepts/storage/storage-classes/
+func (i *Inspector) StorageClasses(ctx context.Context) (*storagev1.StorageClassList, error) {
+	classes, err := listPages(ctx, i, "storage_classes", i.K8sClient.StorageV1().StorageClasses().List)
+	if err != nil {
+		return nil, err
+	}
+	return classes, nil
+}
+
+// VolumeAttachments returns a list of [volume attachments] in a cluster.
+//
+// [volume attachments]: https://kubernetes.io/docs/reference/kubernetes-api/config-and-storage-resources/volume-attachment-v1/
+func (i *Inspector) VolumeAttachments(ctx context.Context) (*storagev1.VolumeAttachmentList, error) {
+	attachments, err := listPages(ctx, i, "volume_attachments", i.K8sClient.StorageV1().VolumeAttachments().List)
+	if err != nil {
+		return nil, err
+	}
+	return attachments, nil
+}
+
+// CSIDrivers returns a list of [CSI drivers] registered in a cluster.
+//
+// [CSI drivers]: https://kubernetes.io/docs/reference/kubernetes-api/config-and-storage-resources/csi-driver-v1/
+func (i *Inspector) CSIDrivers(ctx context.Context) (*storagev1.CSIDriverList, error) {
+	drivers, err := listPages(ctx, i, "csi_drivers", i.K8sClient.StorageV1().CSIDrivers().List)
+	if err != nil {
+		return nil, err
+	}
+	return drivers, nil
+}
+
+// AnalyzeStorage returns findings for Pending persistent volume claims,
+// Released persistent volumes and pods waiting for their volumes
+// to be attached to the node.
+func AnalyzeStorage(pvcs *corev1.PersistentVolumeClaimList, pvs *corev1.PersistentVolumeList, attachments *storagev1.VolumeAttachmentList, pods *corev1.PodList, events *corev1.EventList) []Finding {
+	findings := []Finding{}
+	claimToVolume := map[string]string{}
+	if pvcs != nil {
+		for _, pvc := range pvcs.Items {
+			claimToVolume[pvc.Name] = pvc.Spec.VolumeName
+			if pvc.Status.Phase != corev1.ClaimPending {
+				continue
+			}
+			class := "<default>"
+			if pvc.Spec.StorageClassName != nil {
+				class = *pvc.Spec.StorageClassName
+			}
+			findings = append(findings, Finding{
+				Check:    CheckPVCPending,
+				Severity: SeverityWarning,
+				Object:   ObjectRef{Kind: "PersistentVolumeClaim", Namespace: pvc.Namespace, Name: pvc.Name},
+				Message:  fmt.Sprintf("claim is Pending (storage class %s)", class),
+			})
+		}
+	}
+	if pvs != nil {
+		for _, pv := range pvs.Items {
+			if pv.Status.Phase != corev1.VolumeReleased {
+				continue
+			}
+			claim := ""
+			if pv.Spec.ClaimRef != nil {
+				claim = pv.Spec.ClaimRef.Namespace + "/" + pv.Spec.ClaimRef.Name
+			}
+			findings = append(findings, Finding{
+				Check:    CheckPVReleased,
+				Severity: SeverityWarning,
+				Object:   ObjectRef{Kind: "PersistentVolume", Name: pv.Name},
+				Message:  fmt.Sprintf("volume is Released from claim %s with reclaim policy %s", claim, pv.Spec.PersistentVolumeReclaimPolicy),
+			})
+		}
+	}
+	findings = append(findings, podsStuckOnAttach(claimToVolume, attachments, pods, events)...)
+	sortFindings(findings)
+	return findings
+}
+
+// podsStuckOnAttach returns findings for pods that are not running
+// because a volume they use is not attached to their node, either
+// according to the volume attachment status or to attach and mount
+// failure events recorded for the pod.
+func podsStuckOnAttach(claimToVolume map[string]string, attachments *storagev1.VolumeAttachmentList, pods *corev1.PodList, events *corev1.EventList) []Finding {
+	if pods == nil {
+		return nil
+	}
+	type attachKey struct{ volume, node string }
+	attached := map[attachKey]storagev1.VolumeAttachment{}
+	if attachments != nil {
+		for _, va := range attachments.Items {
+			if va.Spec.Source.PersistentVolumeName == nil {
+				continue
+			}
+			attached[attachKey{*va.Spec.Source.PersistentVolumeName, va.Spec.NodeName}] = va
+		}
+	}
+	failures := map[string]string{}
+	if events != nil {
+		for _, e := range events.Items {
+			if e.InvolvedObject.Kind != "Pod" {
+				continue
+			}
+			if e.Reason == reasonFailedAttach || e.Reason == reasonFailedMount {
+				failures[e.InvolvedObject.Name] = e.Message
+			}
+		}
+	}
+
+	findings := []Finding{}
+	for _, pod := range pods.Items {
+		if pod.Status.Phase != corev1.PodPending {
+			continue
+		}
+		ref := ObjectRef{Kind: "Pod", Namespace: pod.Namespace, Name: pod.Name}
+		reported := false
+		for _, v := range pod.Spec.Volumes {
+			if v.PersistentVolumeClaim == nil {
+				continue
+			}
+			pv := claimToVolume[v.PersistentVolumeClaim.ClaimName]
+			if pv == "" || pod.Spec.NodeName == "" {
+				continue
+			}
+			// Volumes without an attachment are not handled by
+			// an attacher and are mounted directly by kubelet.
+			va, ok := attached[attachKey{pv, pod.Spec.NodeName}]
+			if !ok || va.Status.Attached {
+				continue
+			}
+			msg := fmt.Sprintf("volume %s is not attached to node %s", pv, pod.Spec.NodeName)
+			if va.Status.AttachError != nil {
+				msg = fmt.Sprintf("%s: %s", msg, va.Status.AttachError.Message)
+			}
+			findings = append(findings, Finding{
+				Check:    CheckVolumeAttach,
+				Severity: SeverityCritical,
+				Object:   ref,
+				Message:  msg,
+			})
+			reported = true
+		}
+		if msg, ok := failures[pod.Name]; ok && !reported {
+			findings = append(findings, Finding{
+				Check:    CheckVolumeAttach,
+				Severity: SeverityCritical,
+				Object:   ref,
+				Message:  msg,
+			})
+		}
+	}
+	return findings
+}
@@ -0,0 +1,211 @@
+package inspector_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/qba73/inspector"
+
+	corev1 "k8s.io/api/core/v1"
+	storagev1 "k8s.io/api/storage/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestInspectorListsPersistentVolumeClaimsInAGivenNamespace(t *testing.T) {
+	t.Parallel()
+
+	i := newTestInspector(pendingClaim, boundClaim)
+	got, err := i.PersistentVolumeClaims(context.Background(), "db")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got.Items) != 2 {
+		t.Errorf("want 2 claims, got %d", len(got.Items))
+	}
+}
+
+func TestInspectorListsPersistentVolumesClaimedFromAGivenNamespace(t *testing.T) {
+	t.Parallel()
+
+	i := newTestInspector(boundVolume, releasedVolume, otherNamespaceVolume)
+	got, err := i.PersistentVolumes(context.Background(), "db")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var names []string
+	for _, pv := range got.Items {
+		names = append(names, pv.Name)
+	}
+	want := []string{"pv-data-0", "pv-released"}
+	if !cmp.Equal(want, names) {
+		t.Error(cmp.Diff(want, names))
+	}
+}
+
+func TestInspectorListsStorageClassesAndCSIDrivers(t *testing.T) {
+	t.Parallel()
+
+	i := newTestInspector(
+		&storagev1.StorageClass{ObjectMeta: metav1.ObjectMeta{Name: "standard"}, Provisioner: "ebs.csi.aws.com"},
+		&storagev1.CSIDriver{ObjectMeta: metav1.ObjectMeta{Name: "ebs.csi.aws.com"}},
+	)
+	classes, err := i.StorageClasses(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(classes.Items) != 1 {
+		t.Errorf("want 1 storage class, got %d", len(classes.Items))
+	}
+	drivers, err := i.CSIDrivers(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(drivers.Items) != 1 {
+		t.Errorf("want 1 CSI driver, got %d", len(drivers.Items))
+	}
+}
+
+func TestAnalyzeStorageReportsPendingClaimsAndReleasedVolumes(t *testing.T) {
+	t.Parallel()
+
+	got := inspector.AnalyzeStorage(
+		&corev1.PersistentVolumeClaimList{Items: []corev1.PersistentVolumeClaim{*pendingClaim, *boundClaim}},
+		&corev1.PersistentVolumeList{Items: []corev1.PersistentVolume{*boundVolume, *releasedVolume}},
+		nil,
+		nil,
+		nil,
+	)
+	want := []inspector.Finding{
+		{
+			Check:    inspector.CheckPVCPending,
+			Severity: inspector.SeverityWarning,
+			Object:   inspector.ObjectRef{Kind: "PersistentVolumeClaim", Namespace: "db", Name: "data-pending"},
+			Message:  "claim is Pending (storage class fast)",
+		},
+		{
+			Check:    inspector.CheckPVReleased,
+			Severity: inspector.SeverityWarning,
+			Object:   inspector.ObjectRef{Kind: "PersistentVolume", Name: "pv-released"},
+			Message:  "volume is Released from claim db/data-old with reclaim policy Retain",
+		},
+	}
+	if !cmp.Equal(want, got) {
+		t.Error(cmp.Diff(want, got))
+	}
+}
+
+func TestAnalyzeStorageReportsPodsStuckOnVolumeAttach(t *testing.T) {
+	t.Parallel()
+
+	got := inspector.AnalyzeStorage(
+		&corev1.PersistentVolumeClaimList{Items: []corev1.PersistentVolumeClaim{*boundClaim}},
+		nil,
+		&storagev1.VolumeAttachmentList{Items: []storagev1.VolumeAttachment{*failedAttachment}},
+		&corev1.PodList{Items: []corev1.Pod{*podWaitingForVolume}},
+		nil,
+	)
+	want := []inspector.Finding{
+		{
+			Check:    inspector.CheckVolumeAttach,
+			Severity: inspector.SeverityCritical,
+			Object:   inspector.ObjectRef{Kind: "Pod", Namespace: "db", Name: "db-0"},
+			Message:  "volume pv-data-0 is not attached to node node-1: volume is attached to another node",
+		},
+	}
+	if !cmp.Equal(want, got) {
+		t.Error(cmp.Diff(want, got))
+	}
+}
+
+func TestAnalyzeStorageReportsPodsWithFailedAttachEvents(t *testing.T) {
+	t.Parallel()
+
+	event := corev1.Event{
+		ObjectMeta:     metav1.ObjectMeta{Name: "db-0.attach", Namespace: "db"},
+		InvolvedObject: corev1.ObjectReference{Kind: "Pod", Namespace: "db", Name: "db-0"},
+		Reason:         "FailedAttachVolume",
+		Message:        "AttachVolume.Attach failed for volume \"pv-data-0\"",
+	}
+	got := inspector.AnalyzeStorage(
+		nil,
+		nil,
+		nil,
+		&corev1.PodList{Items: []corev1.Pod{*podWaitingForVolume}},
+		&corev1.EventList{Items: []corev1.Event{event}},
+	)
+	if len(got) != 1 || got[0].Message != event.Message {
+		t.Errorf("want a single finding with the event message, got %+v", got)
+	}
+}
+
+// Storage objects used for testing.
+var (
+	fastStorageClass = "fast"
+	volumeName       = "pv-data-0"
+
+	pendingClaim = &corev1.PersistentVolumeClaim{
+		ObjectMeta: metav1.ObjectMeta{Name: "data-pending", Namespace: "db"},
+		Spec:       corev1.PersistentVolumeClaimSpec{StorageClassName: &fastStorageClass},
+		Status:     corev1.PersistentVolumeClaimStatus{Phase: corev1.ClaimPending},
+	}
+
+	boundClaim = &corev1.PersistentVolumeClaim{
+		ObjectMeta: metav1.ObjectMeta{Name: "data-db-0", Namespace: "db"},
+		Spec:       corev1.PersistentVolumeClaimSpec{VolumeName: volumeName},
+		Status:     corev1.PersistentVolumeClaimStatus{Phase: corev1.ClaimBound},
+	}
+
+	boundVolume = &corev1.PersistentVolume{
+		ObjectMeta: metav1.ObjectMeta{Name: volumeName},
+		Spec: corev1.PersistentVolumeSpec{
+			ClaimRef: &corev1.ObjectReference{Namespace: "db", Name: "data-db-0"},
+		},
+		Status: corev1.PersistentVolumeStatus{Phase: corev1.VolumeBound},
+	}
+
+	releasedVolume = &corev1.PersistentVolume{
+		ObjectMeta: metav1.ObjectMeta{Name: "pv-released"},
+		Spec: corev1.PersistentVolumeSpec{
+			ClaimRef:                      &corev1.ObjectReference{Namespace: "db", Name: "data-old"},
+			PersistentVolumeReclaimPolicy: corev1.PersistentVolumeReclaimRetain,
+		},
+		Status: corev1.PersistentVolumeStatus{Phase: corev1.VolumeReleased},
+	}
+
+	otherNamespaceVolume = &corev1.PersistentVolume{
+		ObjectMeta: metav1.ObjectMeta{Name: "pv-other"},
+		Spec: corev1.PersistentVolumeSpec{
+			ClaimRef: &corev1.ObjectReference{Namespace: "web", Name: "data"},
+		},
+	}
+
+	failedAttachment = &storagev1.VolumeAttachment{
+		ObjectMeta: metav1.ObjectMeta{Name: "csi-123"},
+		Spec: storagev1.VolumeAttachmentSpec{
+			Attacher: "ebs.csi.aws.com",
+			NodeName: "node-1",
+			Source:   storagev1.VolumeAttachmentSource{PersistentVolumeName: &volumeName},
+		},
+		Status: storagev1.VolumeAttachmentStatus{
+			Attached:    false,
+			AttachError: &storagev1.VolumeError{Message: "volume is attached to another node"},
+		},
+	}
+
+	podWaitingForVolume = &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: "db-0", Namespace: "db"},
+		Spec: corev1.PodSpec{
+			NodeName: "node-1",
+			Volumes: []corev1.Volume{
+				{
+					Name: "data",
+					VolumeSource: corev1.VolumeSource{
+						PersistentVolumeClaim: &corev1.PersistentVolumeClaimVolumeSource{ClaimName: "data-db-0"},
+					},
+				},
+			},
+		},
+		Status: corev1.PodStatus{Phase: corev1.PodPending},
+	}
+)
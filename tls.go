@@ -0,0 +1,148 @@
+package inspector
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	netv1 "k8s.io/api/networking/v1"
+)
+
+// TLS analyzer checks.
+const (
+	CheckTLSSecretMissing       = "TLSSecretMissing"
+	CheckTLSSecretInvalid       = "TLSSecretInvalid"
+	CheckTLSHostMismatch        = "TLSHostMismatch"
+	CheckTLSKeyMismatch         = "TLSKeyMismatch"
+	CheckCertificateExpired     = "CertificateExpired"
+	CheckCertificateExpiring    = "CertificateExpiring"
+	CheckCertificateNotYetValid = "CertificateNotYetValid"
+	CheckCertificateChain       = "CertificateChainIncomplete"
+)
+
+// DefaultCertExpiryWindow is the window in which
+// expiring certificates are reported by default.
+const DefaultCertExpiryWindow = 30 * 24 * time.Hour
+
+// AnalyzeTLS returns findings for Ingress TLS sections referring to
+// missing or invalid Secrets, and hosts not matching the certificate
+// SANs. Certificates of all TLS Secrets are checked for expiry at now,
+// expiry within the window, incomplete chains and mismatched keys.
+// Secrets are reported missing only when secrets were collected.
+func AnalyzeTLS(ingresses *netv1.IngressList, secrets []SecretInfo, now time.Time, window time.Duration) []Finding {
+	return analyzeTLS(ingresses, secrets, secrets != nil, now, window)
+}
+
+// analyzeTLS returns findings of AnalyzeTLS. Secrets are reported missing
+// only when complete, that is when secrets were collected unfiltered.
+func analyzeTLS(ingresses *netv1.IngressList, secrets []SecretInfo, complete bool, now time.Time, window time.Duration) []Finding {
+	findings := []Finding{}
+	byName := make(map[ObjectRef]SecretInfo, len(secrets))
+	for _, s := range secrets {
+		byName[ObjectRef{Kind: "Secret", Namespace: s.Namespace, Name: s.Name}] = s
+		if s.Type == corev1.SecretTypeTLS {
+			findings = append(findings, analyzeCertificates(s, now, window)...)
+		}
+	}
+	if ingresses == nil {
+		sortFindings(findings)
+		return findings
+	}
+	for _, ing := range ingresses.Items {
+		ref := ObjectRef{Kind: "Ingress", Namespace: ing.Namespace, Name: ing.Name}
+		for _, tls := range ing.Spec.TLS {
+			if tls.SecretName == "" {
+				continue
+			}
+			secretRef := ObjectRef{Kind: "Secret", Namespace: ing.Namespace, Name: tls.SecretName}
+			s, ok := byName[secretRef]
+			switch {
+			case !ok && complete:
+				findings = append(findings, Finding{
+					Check:    CheckTLSSecretMissing,
+					Severity: SeverityCritical,
+					Object:   ref,
+					Message:  fmt.Sprintf("TLS secret %s not found", tls.SecretName),
+				})
+				continue
+			case !ok:
+				continue
+			case s.Type != corev1.SecretTypeTLS:
+				findings = append(findings, Finding{
+					Check:    CheckTLSSecretInvalid,
+					Severity: SeverityCritical,
+					Object:   ref,
+					Message:  fmt.Sprintf("TLS secret %s has type %s, want %s", tls.SecretName, s.Type, corev1.SecretTypeTLS),
+				})
+				continue
+			case len(s.Certificates) == 0:
+				continue
+			}
+			for _, host := range tls.Hosts {
+				if !matchesSAN(s.Certificates[0].DNSNames, host) {
+					findings = append(findings, Finding{
+						Check:    CheckTLSHostMismatch,
+						Severity: SeverityCritical,
+						Object:   ref,
+						Message:  fmt.Sprintf("host %s does not match certificate SANs of secret %s: %s", host, tls.SecretName, strings.Join(s.Certificates[0].DNSNames, ", ")),
+					})
+				}
+			}
+		}
+	}
+	sortFindings(findings)
+	return findings
+}
+
+// analyzeCertificates returns findings for the certificate chain of a TLS secret.
+func analyzeCertificates(s SecretInfo, now time.Time, window time.Duration) []Finding {
+	ref := ObjectRef{Kind: "Secret", Namespace: s.Namespace, Name: s.Name}
+	finding := func(check, severity, msg string) Finding {
+		return Finding{Check: check, Severity: severity, Object: ref, Message: msg}
+	}
+	if s.CertificateError != "" {
+		return []Finding{finding(CheckTLSSecretInvalid, SeverityCritical, s.CertificateError)}
+	}
+	var findings []Finding
+	for n, cert := range s.Certificates {
+		name := fmt.Sprintf("certificate %d (%s)", n+1, cert.Subject)
+		switch {
+		case now.After(cert.NotAfter):
+			findings = append(findings, finding(CheckCertificateExpired, SeverityCritical,
+				fmt.Sprintf("%s expired on %s", name, cert.NotAfter.Format(time.DateOnly))))
+		case now.Before(cert.NotBefore):
+			findings = append(findings, finding(CheckCertificateNotYetValid, SeverityCritical,
+				fmt.Sprintf("%s is not valid before %s", name, cert.NotBefore.Format(time.RFC3339))))
+		case cert.NotAfter.Sub(now) < window:
+			findings = append(findings, finding(CheckCertificateExpiring, SeverityWarning,
+				fmt.Sprintf("%s expires on %s", name, cert.NotAfter.Format(time.DateOnly))))
+		}
+	}
+	for _, issue := range s.ChainIssues {
+		findings = append(findings, finding(CheckCertificateChain, SeverityWarning, issue))
+	}
+	if s.KeyError != "" {
+		findings = append(findings, finding(CheckTLSKeyMismatch, SeverityCritical, s.KeyError))
+	}
+	return findings
+}
+
+// matchesSAN reports whether the host matches any of the DNS names,
+// which may hold a wildcard in the leftmost label.
+func matchesSAN(dnsNames []string, host string) bool {
+	host = strings.ToLower(strings.TrimSuffix(host, "."))
+	return slices.ContainsFunc(dnsNames, func(name string) bool {
+		name = strings.ToLower(strings.TrimSuffix(name, "."))
+		if name == host {
+			return true
+		}
+		suffix, ok := strings.CutPrefix(name, "*.")
+		if !ok {
+			return false
+		}
+		label, rest, found := strings.Cut(host, ".")
+		return found && label != "" && rest == suffix
+	})
+}
@@ -0,0 +1,363 @@
+package inspector_test
+
+import (
+	"bytes"
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/qba73/inspector"
+
+	corev1 "k8s.io/api/core/v1"
+	netv1 "k8s.io/api/networking/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	k8sruntime "k8s.io/apimachinery/pkg/runtime"
+)
+
+var (
+	tlsNow       = time.Date(2026, 6, 1, 0, 0, 0, 0, time.UTC)
+	tlsValidFrom = time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	tlsValidTo   = time.Date(2027, 1, 1, 0, 0, 0, 0, time.UTC)
+)
+
+func TestAnalyzeTLSAcceptsValidCertificateChains(t *testing.T) {
+	t.Parallel()
+
+	ca := newTestCA(t)
+	leaf := ca.issue(t, tlsValidFrom, tlsValidTo, "shop.example.com", "*.shop.example.com")
+	secrets := collectSecrets(t, tlsKeyPairSecret("shop-tls", leaf.chain(ca.intermediate), leaf.keyPEM))
+	ingresses := ingressList(tlsIngress("shop", "shop-tls", "shop.example.com", "api.shop.example.com", "SHOP.example.com"))
+
+	findings := inspector.AnalyzeTLS(ingresses, secrets, tlsNow, inspector.DefaultCertExpiryWindow)
+	if len(findings) != 0 {
+		t.Errorf("want no findings, got %+v", findings)
+	}
+}
+
+func TestAnalyzeTLSReportsHostsNotMatchingCertificateSANs(t *testing.T) {
+	t.Parallel()
+
+	ca := newTestCA(t)
+	leaf := ca.issue(t, tlsValidFrom, tlsValidTo, "*.shop.example.com")
+	secrets := collectSecrets(t, tlsKeyPairSecret("shop-tls", leaf.chain(ca.intermediate), leaf.keyPEM))
+	ingresses := ingressList(tlsIngress("shop", "shop-tls", "v1.api.shop.example.com", "shop.example.com"))
+
+	got := inspector.AnalyzeTLS(ingresses, secrets, tlsNow, inspector.DefaultCertExpiryWindow)
+	want := []inspector.Finding{
+		{
+			Check:    inspector.CheckTLSHostMismatch,
+			Severity: inspector.SeverityCritical,
+			Object:   inspector.ObjectRef{Kind: "Ingress", Namespace: "default", Name: "shop"},
+			Message:  "host v1.api.shop.example.com does not match certificate SANs of secret shop-tls: *.shop.example.com",
+		},
+		{
+			Check:    inspector.CheckTLSHostMismatch,
+			Severity: inspector.SeverityCritical,
+			Object:   inspector.ObjectRef{Kind: "Ingress", Namespace: "default", Name: "shop"},
+			Message:  "host shop.example.com does not match certificate SANs of secret shop-tls: *.shop.example.com",
+		},
+	}
+	if !cmp.Equal(want, got) {
+		t.Error(cmp.Diff(want, got))
+	}
+}
+
+func TestAnalyzeTLSReportsExpiredExpiringAndNotYetValidCertificates(t *testing.T) {
+	t.Parallel()
+
+	ca := newTestCA(t)
+	expired := ca.issue(t, tlsValidFrom, tlsNow.Add(-time.Hour), "expired.example.com")
+	expiring := ca.issue(t, tlsValidFrom, tlsNow.Add(10*24*time.Hour), "expiring.example.com")
+	future := ca.issue(t, tlsNow.Add(time.Hour), tlsValidTo, "future.example.com")
+	secrets := collectSecrets(t,
+		tlsKeyPairSecret("expired-tls", expired.chain(ca.intermediate), expired.keyPEM),
+		tlsKeyPairSecret("expiring-tls", expiring.chain(ca.intermediate), expiring.keyPEM),
+		tlsKeyPairSecret("future-tls", future.chain(ca.intermediate), future.keyPEM),
+	)
+
+	got := checks(inspector.AnalyzeTLS(nil, secrets, tlsNow, inspector.DefaultCertExpiryWindow))
+	want := []string{
+		"CertificateExpired Secret/default/expired-tls",
+		"CertificateExpiring Secret/default/expiring-tls",
+		"CertificateNotYetValid Secret/default/future-tls",
+	}
+	if !cmp.Equal(want, got) {
+		t.Error(cmp.Diff(want, got))
+	}
+
+	got = checks(inspector.AnalyzeTLS(nil, secrets, tlsNow, 24*time.Hour))
+	want = []string{
+		"CertificateExpired Secret/default/expired-tls",
+		"CertificateNotYetValid Secret/default/future-tls",
+	}
+	if !cmp.Equal(want, got) {
+		t.Errorf("want expiring certificate outside of the window ignored: %s", cmp.Diff(want, got))
+	}
+}
+
+func TestAnalyzeTLSReportsExpiringCertificatesUnchangedFromDayToDay(t *testing.T) {
+	t.Parallel()
+
+	ca := newTestCA(t)
+	expiring := ca.issue(t, tlsValidFrom, tlsNow.Add(10*24*time.Hour), "expiring.example.com")
+	secrets := collectSecrets(t, tlsKeyPairSecret("expiring-tls", expiring.chain(ca.intermediate), expiring.keyPEM))
+
+	today := inspector.AnalyzeTLS(nil, secrets, tlsNow, inspector.DefaultCertExpiryWindow)
+	tomorrow := inspector.AnalyzeTLS(nil, secrets, tlsNow.Add(24*time.Hour), inspector.DefaultCertExpiryWindow)
+	if len(today) != 1 {
+		t.Fatalf("want expiring certificate finding, got %+v", today)
+	}
+	if changes := inspector.DiffFindings(today, tomorrow, tlsNow.Add(24*time.Hour)); len(changes) != 0 {
+		t.Errorf("want no finding changes a day later, got %+v", changes)
+	}
+}
+
+func TestAnalyzeTLSReportsIncompleteChainsAndMismatchedKeys(t *testing.T) {
+	t.Parallel()
+
+	ca := newTestCA(t)
+	leaf := ca.issue(t, tlsValidFrom, tlsValidTo, "shop.example.com")
+	other := ca.issue(t, tlsValidFrom, tlsValidTo, "other.example.com")
+	secrets := collectSecrets(t,
+		tlsKeyPairSecret("leaf-only-tls", leaf.certPEM, leaf.keyPEM),
+		tlsKeyPairSecret("wrong-order-tls", bytes.Join([][]byte{ca.intermediate, leaf.certPEM}, nil), leaf.keyPEM),
+		tlsKeyPairSecret("wrong-key-tls", leaf.chain(ca.intermediate), other.keyPEM),
+	)
+
+	got := checks(inspector.AnalyzeTLS(nil, secrets, tlsNow, inspector.DefaultCertExpiryWindow))
+	want := []string{
+		"CertificateChainIncomplete Secret/default/leaf-only-tls",
+		"CertificateChainIncomplete Secret/default/wrong-order-tls",
+		"CertificateChainIncomplete Secret/default/wrong-order-tls",
+		"TLSKeyMismatch Secret/default/wrong-key-tls",
+		// The key is checked against the first certificate.
+		"TLSKeyMismatch Secret/default/wrong-order-tls",
+	}
+	if !cmp.Equal(want, got) {
+		t.Error(cmp.Diff(want, got))
+	}
+}
+
+func TestAnalyzeTLSReportsMissingAndInvalidSecrets(t *testing.T) {
+	t.Parallel()
+
+	opaque := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: "opaque", Namespace: "default"},
+		Type:       corev1.SecretTypeOpaque,
+		Data:       map[string][]byte{corev1.TLSCertKey: []byte("cert")},
+	}
+	secrets := collectSecrets(t, opaque, tlsSecret("broken-tls", []byte("not a certificate")))
+	ingresses := ingressList(
+		tlsIngress("missing", "gone-tls", "shop.example.com"),
+		tlsIngress("opaque", "opaque", "shop.example.com"),
+		tlsIngress("broken", "broken-tls", "shop.example.com"),
+	)
+
+	got := checks(inspector.AnalyzeTLS(ingresses, secrets, tlsNow, inspector.DefaultCertExpiryWindow))
+	want := []string{
+		"TLSSecretInvalid Ingress/default/opaque",
+		"TLSSecretInvalid Secret/default/broken-tls",
+		"TLSSecretMissing Ingress/default/missing",
+	}
+	if !cmp.Equal(want, got) {
+		t.Error(cmp.Diff(want, got))
+	}
+
+	if findings := inspector.AnalyzeTLS(ingresses, nil, tlsNow, inspector.DefaultCertExpiryWindow); len(findings) != 0 {
+		t.Errorf("want no findings when secrets were not collected, got %+v", findings)
+	}
+}
+
+func TestAnalyzeReportsNoMissingSecretsOfFilteredCollections(t *testing.T) {
+	t.Parallel()
+
+	rep := inspector.Report{
+		Secrets:   collectSecrets(t, tlsSecret("web-tls", nil)),
+		Ingresses: ingressList(tlsIngress("missing", "gone-tls", "shop.example.com")),
+	}
+	if got := checks(inspector.Analyze(rep).Findings); !slices.Contains(got, "TLSSecretMissing Ingress/default/missing") {
+		t.Errorf("want missing secret of unfiltered collection reported, got %v", got)
+	}
+	for _, opts := range []map[string]string{
+		{"label_selector": "app=web"},
+		{"names": "web-*"},
+		{"selectors.secrets.field_selector": "type=kubernetes.io/tls"},
+	} {
+		rep.Metadata.Options = opts
+		if got := checks(inspector.Analyze(rep).Findings); slices.Contains(got, "TLSSecretMissing Ingress/default/missing") {
+			t.Errorf("%v: want no missing secret reported, got %v", opts, got)
+		}
+	}
+}
+
+func TestFailOnReturnsFindingsOfSeverityOrHigher(t *testing.T) {
+	t.Parallel()
+
+	findings := []inspector.Finding{
+		{Check: inspector.CheckCertificateExpiring, Severity: inspector.SeverityWarning},
+		{Check: inspector.CheckCertificateExpired, Severity: inspector.SeverityCritical},
+	}
+	err := inspector.FailOn(findings, inspector.SeverityWarning)
+	if inspector.ExitCode(err) != inspector.ExitFindings {
+		t.Fatalf("want exit code %d, got %d: %v", inspector.ExitFindings, inspector.ExitCode(err), err)
+	}
+	if err := inspector.FailOn(findings[:1], inspector.SeverityCritical); err != nil {
+		t.Errorf("want no error without critical findings, got %v", err)
+	}
+	if err := inspector.FailOn(nil, "fatal"); inspector.ExitCode(err) != inspector.ExitConfig {
+		t.Errorf("want config error on unknown severity, got %v", err)
+	}
+}
+
+// testCA is a certificate authority issuing certificates
+// through an intermediate CA.
+type testCA struct {
+	intermediate    []byte
+	intermediateKey *ecdsa.PrivateKey
+	intermediateCrt *x509.Certificate
+}
+
+// testCertificate is a PEM encoded certificate and its key.
+type testCertificate struct {
+	certPEM []byte
+	keyPEM  []byte
+}
+
+// chain returns the certificate followed by the intermediates.
+func (c testCertificate) chain(intermediates ...[]byte) []byte {
+	return bytes.Join(append([][]byte{c.certPEM}, intermediates...), nil)
+}
+
+func newTestCA(t *testing.T) testCA {
+	t.Helper()
+
+	rootKey := newTestKey(t)
+	root := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "Test Root CA"},
+		NotBefore:             tlsValidFrom.AddDate(-1, 0, 0),
+		NotAfter:              tlsValidTo.AddDate(5, 0, 0),
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+		KeyUsage:              x509.KeyUsageCertSign,
+	}
+	rootDER := createCertificate(t, root, root, &rootKey.PublicKey, rootKey)
+	rootCrt, err := x509.ParseCertificate(rootDER)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	key := newTestKey(t)
+	intermediate := &x509.Certificate{
+		SerialNumber:          big.NewInt(2),
+		Subject:               pkix.Name{CommonName: "Test Intermediate CA"},
+		NotBefore:             tlsValidFrom.AddDate(-1, 0, 0),
+		NotAfter:              tlsValidTo.AddDate(4, 0, 0),
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+		KeyUsage:              x509.KeyUsageCertSign,
+	}
+	der := createCertificate(t, intermediate, rootCrt, &key.PublicKey, rootKey)
+	crt, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return testCA{
+		intermediate:    pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}),
+		intermediateKey: key,
+		intermediateCrt: crt,
+	}
+}
+
+// issue returns a certificate for the DNS names issued by the intermediate CA.
+func (ca testCA) issue(t *testing.T, notBefore, notAfter time.Time, dnsNames ...string) testCertificate {
+	t.Helper()
+
+	key := newTestKey(t)
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(time.Now().UnixNano()),
+		Subject:      pkix.Name{CommonName: dnsNames[0]},
+		DNSNames:     dnsNames,
+		NotBefore:    notBefore,
+		NotAfter:     notAfter,
+	}
+	der := createCertificate(t, template, ca.intermediateCrt, &key.PublicKey, ca.intermediateKey)
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return testCertificate{
+		certPEM: pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}),
+		keyPEM:  pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}),
+	}
+}
+
+func newTestKey(t *testing.T) *ecdsa.PrivateKey {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return key
+}
+
+func createCertificate(t *testing.T, template, parent *x509.Certificate, pub, priv any) []byte {
+	t.Helper()
+
+	der, err := x509.CreateCertificate(rand.Reader, template, parent, pub, priv)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return der
+}
+
+// tlsKeyPairSecret returns a TLS secret holding the certificate chain and key.
+func tlsKeyPairSecret(name string, chain, key []byte) *corev1.Secret {
+	s := tlsSecret(name, chain)
+	s.Data[corev1.TLSPrivateKeyKey] = key
+	return s
+}
+
+// collectSecrets returns metadata of the secrets collected from a cluster.
+func collectSecrets(t *testing.T, secrets ...k8sruntime.Object) []inspector.SecretInfo {
+	t.Helper()
+
+	i := &inspector.Inspector{K8sClient: newTestClientset(secrets...)}
+	infos, err := i.Secrets(context.Background(), "default")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return infos
+}
+
+func tlsIngress(name, secretName string, hosts ...string) netv1.Ingress {
+	return netv1.Ingress{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "default"},
+		Spec: netv1.IngressSpec{
+			TLS: []netv1.IngressTLS{{Hosts: hosts, SecretName: secretName}},
+		},
+	}
+}
+
+func ingressList(ingresses ...netv1.Ingress) *netv1.IngressList {
+	return &netv1.IngressList{Items: ingresses}
+}
+
+// checks returns findings in the check and object form.
+func checks(findings []inspector.Finding) []string {
+	got := []string{}
+	for _, f := range findings {
+		got = append(got, f.Check+" "+f.Object.String())
+	}
+	return got
+}
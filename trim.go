@@ -0,0 +1,202 @@
+package inspector
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	apiextv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/scheme"
+)
+
+// lastAppliedAnnotation holds the configuration last applied by kubectl apply.
+const lastAppliedAnnotation = "kubectl.kubernetes.io/last-applied-configuration"
+
+// TrimOptions selects fields removed from collected objects.
+// The zero value removes managed fields and the last applied
+// configuration annotation.
+type TrimOptions struct {
+	// KeepManagedFields keeps metadata.managedFields.
+	KeepManagedFields bool `json:"keep_managed_fields,omitempty"`
+	// KeepLastApplied keeps the kubectl last applied configuration annotation.
+	KeepLastApplied bool `json:"keep_last_applied,omitempty"`
+	// Status removes object status.
+	Status bool `json:"status,omitempty"`
+	// ResourceVersion removes metadata.resourceVersion.
+	ResourceVersion bool `json:"resource_version,omitempty"`
+	// Neat turns objects into clean manifests, like kubectl neat:
+	// it sets their kind and API version, and removes status and
+	// fields set by the API server and controllers.
+	Neat bool `json:"neat,omitempty"`
+}
+
+// trimFields lists names of fields removed by TrimOptions.
+var trimFields = []string{"managed-fields", "last-applied", "status", "resource-version"}
+
+// ParseTrimOptions parses a comma separated list of removed fields:
+// managed-fields, last-applied, status and resource-version.
+// An empty list or none keeps all fields.
+func ParseTrimOptions(s string) (TrimOptions, error) {
+	t := TrimOptions{KeepManagedFields: true, KeepLastApplied: true}
+	if s == "" || s == "none" {
+		return t, nil
+	}
+	for field := range strings.SplitSeq(s, ",") {
+		switch strings.TrimSpace(field) {
+		case "managed-fields":
+			t.KeepManagedFields = false
+		case "last-applied":
+			t.KeepLastApplied = false
+		case "status":
+			t.Status = true
+		case "resource-version":
+			t.ResourceVersion = true
+		default:
+			return TrimOptions{}, fmt.Errorf("unknown trimmed field %q, want one of %s", field, strings.Join(trimFields, ", "))
+		}
+	}
+	return t, nil
+}
+
+// String returns the comma separated list of removed fields
+// in the form accepted by ParseTrimOptions.
+func (t TrimOptions) String() string {
+	removed := []bool{!t.KeepManagedFields, !t.KeepLastApplied, t.Status, t.ResourceVersion}
+	fields := []string{}
+	for n, field := range trimFields {
+		if removed[n] || t.Neat {
+			fields = append(fields, field)
+		}
+	}
+	if len(fields) == 0 {
+		return "none"
+	}
+	return strings.Join(fields, ",")
+}
+
+// trimReport removes fields from objects of all lists collected in the report.
+func trimReport(rep *Report, t TrimOptions) error {
+	v := reflect.ValueOf(rep).Elem()
+	for n := range v.NumField() {
+		f := v.Field(n)
+		if f.Kind() != reflect.Pointer || f.IsNil() {
+			continue
+		}
+		list, ok := f.Interface().(runtime.Object)
+		if !ok || !meta.IsListType(list) {
+			continue
+		}
+		if err := trimList(list, t); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// trimList removes fields from objects of the list.
+func trimList(list runtime.Object, t TrimOptions) error {
+	if listMeta, err := meta.ListAccessor(list); err == nil && (t.ResourceVersion || t.Neat) {
+		listMeta.SetResourceVersion("")
+	}
+	return meta.EachListItem(list, func(obj runtime.Object) error {
+		return trimObject(obj, t)
+	})
+}
+
+// trimObject removes fields from the object.
+func trimObject(obj runtime.Object, t TrimOptions) error {
+	m, err := meta.Accessor(obj)
+	if err != nil {
+		return err
+	}
+	if !t.KeepManagedFields || t.Neat {
+		m.SetManagedFields(nil)
+	}
+	if annotations := m.GetAnnotations(); (!t.KeepLastApplied || t.Neat) && annotations[lastAppliedAnnotation] != "" {
+		delete(annotations, lastAppliedAnnotation)
+		m.SetAnnotations(annotations)
+	}
+	if t.ResourceVersion || t.Neat {
+		m.SetResourceVersion("")
+	}
+	if t.Status || t.Neat {
+		if status := reflect.ValueOf(obj).Elem().FieldByName("Status"); status.IsValid() && status.CanSet() {
+			status.SetZero()
+		}
+	}
+	if t.Neat {
+		neatObject(obj, m)
+	}
+	return nil
+}
+
+// neatScheme knows kinds and API versions of collected objects.
+var neatScheme = func() *runtime.Scheme {
+	s := runtime.NewScheme()
+	_ = scheme.AddToScheme(s)
+	_ = apiextv1.AddToScheme(s)
+	return s
+}()
+
+// neatObject removes fields set by the API server and controllers,
+// and sets the object kind and API version.
+func neatObject(obj runtime.Object, m metav1.Object) {
+	if gvks, _, err := neatScheme.ObjectKinds(obj); err == nil && len(gvks) > 0 {
+		obj.GetObjectKind().SetGroupVersionKind(gvks[0])
+	}
+	m.SetUID("")
+	m.SetGeneration(0)
+	m.SetCreationTimestamp(metav1.Time{})
+	m.SetSelfLink("")
+	if annotations := m.GetAnnotations(); annotations != nil {
+		delete(annotations, "deployment.kubernetes.io/revision")
+		m.SetAnnotations(annotations)
+	}
+
+	switch o := obj.(type) {
+	case *corev1.Pod:
+		o.Spec.NodeName = ""
+		o.Spec.Volumes = dropServiceAccountTokenVolumes(o.Spec.Volumes)
+		for n := range o.Spec.InitContainers {
+			o.Spec.InitContainers[n].VolumeMounts = dropServiceAccountTokenMounts(o.Spec.InitContainers[n].VolumeMounts)
+		}
+		for n := range o.Spec.Containers {
+			o.Spec.Containers[n].VolumeMounts = dropServiceAccountTokenMounts(o.Spec.Containers[n].VolumeMounts)
+		}
+	case *corev1.Service:
+		if o.Spec.ClusterIP != corev1.ClusterIPNone {
+			o.Spec.ClusterIP = ""
+			o.Spec.ClusterIPs = nil
+		}
+	}
+}
+
+// isServiceAccountTokenVolume reports whether the volume name
+// is the name of a service account token volume added by the API server.
+func isServiceAccountTokenVolume(name string) bool {
+	return strings.HasPrefix(name, "kube-api-access-")
+}
+
+func dropServiceAccountTokenVolumes(volumes []corev1.Volume) []corev1.Volume {
+	var kept []corev1.Volume
+	for _, v := range volumes {
+		if !isServiceAccountTokenVolume(v.Name) {
+			kept = append(kept, v)
+		}
+	}
+	return kept
+}
+
+func dropServiceAccountTokenMounts(mounts []corev1.VolumeMount) []corev1.VolumeMount {
+	var kept []corev1.VolumeMount
+	for _, m := range mounts {
+		if !isServiceAccountTokenVolume(m.Name) {
+			kept = append(kept, m)
+		}
+	}
+	return kept
+}
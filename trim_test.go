@@ -0,0 +1,189 @@
+package inspector_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/qba73/inspector"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	k8sruntime "k8s.io/apimachinery/pkg/runtime"
+)
+
+func TestInspectorReportRemovesManagedFieldsAndLastAppliedConfigByDefault(t *testing.T) {
+	t.Parallel()
+
+	i := &inspector.Inspector{K8sClient: newTestClientset(appliedConfigMap()), Collectors: []string{"config_maps"}}
+	report, err := i.Report(context.Background(), "default")
+	if err != nil {
+		t.Fatal(err)
+	}
+	cm := report.ConfigMaps.Items[0]
+	if cm.ManagedFields != nil {
+		t.Errorf("want managed fields removed, got %v", cm.ManagedFields)
+	}
+	want := map[string]string{"team": "web"}
+	if !cmp.Equal(want, cm.Annotations) {
+		t.Error(cmp.Diff(want, cm.Annotations))
+	}
+	if cm.ResourceVersion != "42" {
+		t.Errorf("want resource version kept, got %q", cm.ResourceVersion)
+	}
+}
+
+func TestInspectorReportKeepsFieldsSelectedByTrimOptions(t *testing.T) {
+	t.Parallel()
+
+	i := &inspector.Inspector{
+		K8sClient:  newTestClientset(appliedConfigMap()),
+		Collectors: []string{"config_maps"},
+		Trim:       inspector.TrimOptions{KeepManagedFields: true, KeepLastApplied: true, ResourceVersion: true},
+	}
+	report, err := i.Report(context.Background(), "default")
+	if err != nil {
+		t.Fatal(err)
+	}
+	cm := report.ConfigMaps.Items[0]
+	if len(cm.ManagedFields) != 1 || len(cm.Annotations) != 2 {
+		t.Errorf("want managed fields and annotations kept, got %v and %v", cm.ManagedFields, cm.Annotations)
+	}
+	if cm.ResourceVersion != "" {
+		t.Errorf("want resource version removed, got %q", cm.ResourceVersion)
+	}
+}
+
+func TestInspectorReportAnalyzesObjectsBeforeRemovingStatus(t *testing.T) {
+	t.Parallel()
+
+	i := &inspector.Inspector{
+		K8sClient:  newTestClientset(pendingClaim),
+		Collectors: []string{"persistent_volume_claims"},
+		Trim:       inspector.TrimOptions{Status: true},
+	}
+	report, err := i.Report(context.Background(), "db")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if phase := report.PersistentVolumeClaims.Items[0].Status.Phase; phase != "" {
+		t.Errorf("want status removed, got phase %q", phase)
+	}
+	if len(report.Findings) != 1 || report.Findings[0].Check != inspector.CheckPVCPending {
+		t.Errorf("want pending claim finding, got %+v", report.Findings)
+	}
+}
+
+func TestInspectorReportInNeatModeWritesCleanManifests(t *testing.T) {
+	t.Parallel()
+
+	i := &inspector.Inspector{
+		K8sClient:  newTestClientset(scheduledPod()),
+		Collectors: []string{"pods"},
+		Trim:       inspector.TrimOptions{Neat: true},
+	}
+	report, err := i.Report(context.Background(), "default")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := corev1.Pod{
+		TypeMeta:   metav1.TypeMeta{Kind: "Pod", APIVersion: "v1"},
+		ObjectMeta: metav1.ObjectMeta{Name: "web", Namespace: "default", Labels: map[string]string{"app": "web"}},
+		Spec: corev1.PodSpec{
+			Containers: []corev1.Container{{Name: "web", Image: "nginx"}},
+		},
+	}
+	if !cmp.Equal(want, report.Pods.Items[0]) {
+		t.Error(cmp.Diff(want, report.Pods.Items[0]))
+	}
+}
+
+func TestInspectorTrimsStreamedPages(t *testing.T) {
+	t.Parallel()
+
+	var streamed []corev1.ConfigMap
+	i := &inspector.Inspector{
+		K8sClient:  newTestClientset(appliedConfigMap()),
+		Collectors: []string{"config_maps"},
+		PageHandler: func(collector string, page k8sruntime.Object) error {
+			streamed = append(streamed, page.(*corev1.ConfigMapList).Items...)
+			return nil
+		},
+	}
+	if _, err := i.Report(context.Background(), "default"); err != nil {
+		t.Fatal(err)
+	}
+	if len(streamed) != 1 || streamed[0].ManagedFields != nil || len(streamed[0].Annotations) != 1 {
+		t.Errorf("want streamed config map trimmed, got %+v", streamed)
+	}
+}
+
+func TestParseTrimOptions(t *testing.T) {
+	t.Parallel()
+
+	tests := map[string]inspector.TrimOptions{
+		"managed-fields,last-applied":            {},
+		"none":                                   {KeepManagedFields: true, KeepLastApplied: true},
+		"":                                       {KeepManagedFields: true, KeepLastApplied: true},
+		"managed-fields,status,resource-version": {KeepLastApplied: true, Status: true, ResourceVersion: true},
+	}
+	for s, want := range tests {
+		got, err := inspector.ParseTrimOptions(s)
+		if err != nil {
+			t.Fatalf("%q: %v", s, err)
+		}
+		if !cmp.Equal(want, got) {
+			t.Errorf("%q: %s", s, cmp.Diff(want, got))
+		}
+		if s != "" && got.String() != s {
+			t.Errorf("want %q formatted back, got %q", s, got.String())
+		}
+	}
+	if _, err := inspector.ParseTrimOptions("spec"); err == nil {
+		t.Error("want error on unknown field")
+	}
+}
+
+// appliedConfigMap returns a config map created with kubectl apply.
+func appliedConfigMap() *corev1.ConfigMap {
+	return &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:            "web-config",
+			Namespace:       "default",
+			ResourceVersion: "42",
+			Annotations: map[string]string{
+				"kubectl.kubernetes.io/last-applied-configuration": `{"apiVersion":"v1","kind":"ConfigMap"}`,
+				"team": "web",
+			},
+			ManagedFields: []metav1.ManagedFieldsEntry{{Manager: "kubectl-client-side-apply", Operation: metav1.ManagedFieldsOperationUpdate}},
+		},
+		Data: map[string]string{"mode": "production"},
+	}
+}
+
+// scheduledPod returns a running pod with fields set by the cluster.
+func scheduledPod() *corev1.Pod {
+	return &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:              "web",
+			Namespace:         "default",
+			UID:               "6f8e1c2a",
+			ResourceVersion:   "7",
+			CreationTimestamp: metav1.Now(),
+			Labels:            map[string]string{"app": "web"},
+		},
+		Spec: corev1.PodSpec{
+			NodeName: "node-1",
+			Volumes: []corev1.Volume{{
+				Name:         "kube-api-access-x2k9p",
+				VolumeSource: corev1.VolumeSource{Projected: &corev1.ProjectedVolumeSource{}},
+			}},
+			Containers: []corev1.Container{{
+				Name:         "web",
+				Image:        "nginx",
+				VolumeMounts: []corev1.VolumeMount{{Name: "kube-api-access-x2k9p", MountPath: "/var/run/secrets/kubernetes.io/serviceaccount"}},
+			}},
+		},
+		Status: corev1.PodStatus{Phase: corev1.PodRunning, PodIP: "10.0.0.7"},
+	}
+}
@@ -0,0 +1,44 @@
+package inspector
+
+import (
+	"runtime"
+	"runtime/debug"
+)
+
+// Version and Commit are set at build time with:
+//
+//	go build -ldflags "-X github.com/qba73/inspector.Version=v1.2.3 -X github.com/qba73/inspector.Commit=abc123"
+var (
+	Version = ""
+	Commit  = ""
+)
+
+// VersionInfo describes the inspector build.
+type VersionInfo struct {
+	Version   string `json:"version"`
+	Commit    string `json:"commit"`
+	GoVersion string `json:"go_version"`
+}
+
+// BuildVersion returns the inspector version. Values not set at build time
+// are read from the module build info, falling back to "dev" and "unknown".
+func BuildVersion() VersionInfo {
+	v := VersionInfo{Version: Version, Commit: Commit, GoVersion: runtime.Version()}
+	if info, ok := debug.ReadBuildInfo(); ok {
+		if v.Version == "" && info.Main.Version != "" && info.Main.Version != "(devel)" {
+			v.Version = info.Main.Version
+		}
+		for _, s := range info.Settings {
+			if s.Key == "vcs.revision" && v.Commit == "" {
+				v.Commit = s.Value
+			}
+		}
+	}
+	if v.Version == "" {
+		v.Version = "dev"
+	}
+	if v.Commit == "" {
+		v.Commit = "unknown"
+	}
+	return v
+}
@@ -0,0 +1,485 @@
+package inspector
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"time"
+
+	appsv1 "k8s.io/api/apps/v1"
+	autoscalingv2 "k8s.io/api/autoscaling/v2"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	discoveryv1 "k8s.io/api/discovery/v1"
+	eventsv1 "k8s.io/api/events/v1"
+	netv1 "k8s.io/api/networking/v1"
+	policyv1 "k8s.io/api/policy/v1"
+	storagev1 "k8s.io/api/storage/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	appsinformers "k8s.io/client-go/informers/apps/v1"
+	autoscalinginformers "k8s.io/client-go/informers/autoscaling/v2"
+	batchinformers "k8s.io/client-go/informers/batch/v1"
+	coreinformers "k8s.io/client-go/informers/core/v1"
+	discoveryinformers "k8s.io/client-go/informers/discovery/v1"
+	eventsinformers "k8s.io/client-go/informers/events/v1"
+	netinformers "k8s.io/client-go/informers/networking/v1"
+	policyinformers "k8s.io/client-go/informers/policy/v1"
+	storageinformers "k8s.io/client-go/informers/storage/v1"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/tools/cache"
+)
+
+const (
+	// DefaultWatchResync is the interval at which watched objects
+	// are resynced and analyzers re-run, so findings depending on
+	// time, like expiring certificates, are updated.
+	DefaultWatchResync = 10 * time.Minute
+	// DefaultWatchDebounce is the quiet period after a change
+	// before analyzers re-run, so bursts of changes, like a rollout,
+	// are analyzed once.
+	DefaultWatchDebounce = time.Second
+)
+
+// Finding change statuses.
+const (
+	FindingAppeared = "appeared"
+	FindingResolved = "resolved"
+)
+
+// FindingChange reports a finding that appeared or resolved.
+type FindingChange struct {
+	Time    time.Time `json:"time"`
+	Status  string    `json:"status"`
+	Finding Finding   `json:"finding"`
+}
+
+// DiffFindings returns findings of newer missing in older as appeared
+// and findings of older missing in newer as resolved. Findings are the
+// same when their check, object and message are equal.
+func DiffFindings(older, newer []Finding, now time.Time) []FindingChange {
+	changes := []FindingChange{}
+	seen := map[Finding]bool{}
+	for _, f := range older {
+		seen[f] = true
+	}
+	current := map[Finding]bool{}
+	for _, f := range newer {
+		current[f] = true
+		if !seen[f] {
+			changes = append(changes, FindingChange{Time: now, Status: FindingAppeared, Finding: f})
+		}
+	}
+	for _, f := range older {
+		if !current[f] {
+			changes = append(changes, FindingChange{Time: now, Status: FindingResolved, Finding: f})
+		}
+	}
+	return changes
+}
+
+// watchedKind is a kind watched with an informer by the collector of the same name.
+type watchedKind struct {
+	collector string
+	// newInformer returns an informer of the kind. The namespace
+	// is ignored by informers of cluster scoped kinds.
+	newInformer func(client kubernetes.Interface, namespace string, resync time.Duration, tweak func(*metav1.ListOptions)) cache.SharedIndexInformer
+	// set stores objects of the informer store in the report.
+	set func(rep *Report, objs []any)
+}
+
+// watchedKinds lists kinds the watch mode keeps in its model,
+// the data analyzers read.
+var watchedKinds = []watchedKind{
+	{
+		collector: "pods",
+		newInformer: func(c kubernetes.Interface, ns string, resync time.Duration, tweak func(*metav1.ListOptions)) cache.SharedIndexInformer {
+			return coreinformers.NewFilteredPodInformer(c, ns, resync, cache.Indexers{}, tweak)
+		},
+		set: func(rep *Report, objs []any) { rep.Pods = &corev1.PodList{Items: storeItems[corev1.Pod](objs)} },
+	},
+	{
+		collector: "events",
+		newInformer: func(c kubernetes.Interface, ns string, resync time.Duration, tweak func(*metav1.ListOptions)) cache.SharedIndexInformer {
+			return coreinformers.NewFilteredEventInformer(c, ns, resync, cache.Indexers{}, tweak)
+		},
+		set: func(rep *Report, objs []any) { rep.Events = &corev1.EventList{Items: storeItems[corev1.Event](objs)} },
+	},
+	{
+		collector: "events_v1",
+		newInformer: func(c kubernetes.Interface, ns string, resync time.Duration, tweak func(*metav1.ListOptions)) cache.SharedIndexInformer {
+			return eventsinformers.NewFilteredEventInformer(c, ns, resync, cache.Indexers{}, tweak)
+		},
+		set: func(rep *Report, objs []any) {
+			rep.EventsV1 = &eventsv1.EventList{Items: storeItems[eventsv1.Event](objs)}
+		},
+	},
+	{
+		collector: "secrets",
+		newInformer: func(c kubernetes.Interface, ns string, resync time.Duration, tweak func(*metav1.ListOptions)) cache.SharedIndexInformer {
+			return coreinformers.NewFilteredSecretInformer(c, ns, resync, cache.Indexers{}, tweak)
+		},
+		set: func(rep *Report, objs []any) {
+			rep.Secrets = []SecretInfo{}
+			for _, s := range storeItems[corev1.Secret](objs) {
+				rep.Secrets = append(rep.Secrets, secretInfo(s))
+			}
+		},
+	},
+	{
+		collector: "services",
+		newInformer: func(c kubernetes.Interface, ns string, resync time.Duration, tweak func(*metav1.ListOptions)) cache.SharedIndexInformer {
+			return coreinformers.NewFilteredServiceInformer(c, ns, resync, cache.Indexers{}, tweak)
+		},
+		set: func(rep *Report, objs []any) {
+			rep.Services = &corev1.ServiceList{Items: storeItems[corev1.Service](objs)}
+		},
+	},
+	{
+		collector: "deployments",
+		newInformer: func(c kubernetes.Interface, ns string, resync time.Duration, tweak func(*metav1.ListOptions)) cache.SharedIndexInformer {
+			return appsinformers.NewFilteredDeploymentInformer(c, ns, resync, cache.Indexers{}, tweak)
+		},
+		set: func(rep *Report, objs []any) {
+			rep.Deployments = &appsv1.DeploymentList{Items: storeItems[appsv1.Deployment](objs)}
+		},
+	},
+	{
+		collector: "stateful_sets",
+		newInformer: func(c kubernetes.Interface, ns string, resync time.Duration, tweak func(*metav1.ListOptions)) cache.SharedIndexInformer {
+			return appsinformers.NewFilteredStatefulSetInformer(c, ns, resync, cache.Indexers{}, tweak)
+		},
+		set: func(rep *Report, objs []any) {
+			rep.StatefulSets = &appsv1.StatefulSetList{Items: storeItems[appsv1.StatefulSet](objs)}
+		},
+	},
+	{
+		collector: "replica_sets",
+		newInformer: func(c kubernetes.Interface, ns string, resync time.Duration, tweak func(*metav1.ListOptions)) cache.SharedIndexInformer {
+			return appsinformers.NewFilteredReplicaSetInformer(c, ns, resync, cache.Indexers{}, tweak)
+		},
+		set: func(rep *Report, objs []any) {
+			rep.ReplicaSets = &appsv1.ReplicaSetList{Items: storeItems[appsv1.ReplicaSet](objs)}
+		},
+	},
+	{
+		collector: "controller_revisions",
+		newInformer: func(c kubernetes.Interface, ns string, resync time.Duration, tweak func(*metav1.ListOptions)) cache.SharedIndexInformer {
+			return appsinformers.NewFilteredControllerRevisionInformer(c, ns, resync, cache.Indexers{}, tweak)
+		},
+		set: func(rep *Report, objs []any) {
+			rep.ControllerRevisions = &appsv1.ControllerRevisionList{Items: storeItems[appsv1.ControllerRevision](objs)}
+		},
+	},
+	{
+		collector: "daemon_sets",
+		newInformer: func(c kubernetes.Interface, ns string, resync time.Duration, tweak func(*metav1.ListOptions)) cache.SharedIndexInformer {
+			return appsinformers.NewFilteredDaemonSetInformer(c, ns, resync, cache.Indexers{}, tweak)
+		},
+		set: func(rep *Report, objs []any) {
+			rep.DaemonSets = &appsv1.DaemonSetList{Items: storeItems[appsv1.DaemonSet](objs)}
+		},
+	},
+	{
+		collector: "jobs",
+		newInformer: func(c kubernetes.Interface, ns string, resync time.Duration, tweak func(*metav1.ListOptions)) cache.SharedIndexInformer {
+			return batchinformers.NewFilteredJobInformer(c, ns, resync, cache.Indexers{}, tweak)
+		},
+		set: func(rep *Report, objs []any) { rep.Jobs = &batchv1.JobList{Items: storeItems[batchv1.Job](objs)} },
+	},
+	{
+		collector: "cron_jobs",
+		newInformer: func(c kubernetes.Interface, ns string, resync time.Duration, tweak func(*metav1.ListOptions)) cache.SharedIndexInformer {
+			return batchinformers.NewFilteredCronJobInformer(c, ns, resync, cache.Indexers{}, tweak)
+		},
+		set: func(rep *Report, objs []any) {
+			rep.CronJobs = &batchv1.CronJobList{Items: storeItems[batchv1.CronJob](objs)}
+		},
+	},
+	{
+		collector: "ingresses",
+		newInformer: func(c kubernetes.Interface, ns string, resync time.Duration, tweak func(*metav1.ListOptions)) cache.SharedIndexInformer {
+			return netinformers.NewFilteredIngressInformer(c, ns, resync, cache.Indexers{}, tweak)
+		},
+		set: func(rep *Report, objs []any) {
+			rep.Ingresses = &netv1.IngressList{Items: storeItems[netv1.Ingress](objs)}
+		},
+	},
+	{
+		collector: "endpoint_slices",
+		newInformer: func(c kubernetes.Interface, ns string, resync time.Duration, tweak func(*metav1.ListOptions)) cache.SharedIndexInformer {
+			return discoveryinformers.NewFilteredEndpointSliceInformer(c, ns, resync, cache.Indexers{}, tweak)
+		},
+		set: func(rep *Report, objs []any) {
+			rep.EndpointSlices = &discoveryv1.EndpointSliceList{Items: storeItems[discoveryv1.EndpointSlice](objs)}
+		},
+	},
+	{
+		collector: "endpoints",
+		newInformer: func(c kubernetes.Interface, ns string, resync time.Duration, tweak func(*metav1.ListOptions)) cache.SharedIndexInformer {
+			return coreinformers.NewFilteredEndpointsInformer(c, ns, resync, cache.Indexers{}, tweak)
+		},
+		set: func(rep *Report, objs []any) {
+			rep.Endpoints = &corev1.EndpointsList{Items: storeItems[corev1.Endpoints](objs)}
+		},
+	},
+	{
+		collector: "network_policies",
+		newInformer: func(c kubernetes.Interface, ns string, resync time.Duration, tweak func(*metav1.ListOptions)) cache.SharedIndexInformer {
+			return netinformers.NewFilteredNetworkPolicyInformer(c, ns, resync, cache.Indexers{}, tweak)
+		},
+		set: func(rep *Report, objs []any) {
+			rep.NetworkPolicies = &netv1.NetworkPolicyList{Items: storeItems[netv1.NetworkPolicy](objs)}
+		},
+	},
+	{
+		collector: "persistent_volume_claims",
+		newInformer: func(c kubernetes.Interface, ns string, resync time.Duration, tweak func(*metav1.ListOptions)) cache.SharedIndexInformer {
+			return coreinformers.NewFilteredPersistentVolumeClaimInformer(c, ns, resync, cache.Indexers{}, tweak)
+		},
+		set: func(rep *Report, objs []any) {
+			rep.PersistentVolumeClaims = &corev1.PersistentVolumeClaimList{Items: storeItems[corev1.PersistentVolumeClaim](objs)}
+		},
+	},
+	{
+		collector: "persistent_volumes",
+		newInformer: func(c kubernetes.Interface, _ string, resync time.Duration, tweak func(*metav1.ListOptions)) cache.SharedIndexInformer {
+			return coreinformers.NewFilteredPersistentVolumeInformer(c, resync, cache.Indexers{}, tweak)
+		},
+		// Volumes are listed cluster wide, the report keeps
+		// those claimed from the namespace like PersistentVolumes.
+		set: func(rep *Report, objs []any) {
+			pvs := storeItems[corev1.PersistentVolume](objs)
+			rep.PersistentVolumes = &corev1.PersistentVolumeList{Items: slices.DeleteFunc(pvs, func(pv corev1.PersistentVolume) bool {
+				return pv.Spec.ClaimRef == nil || pv.Spec.ClaimRef.Namespace != rep.Namespace
+			})}
+		},
+	},
+	{
+		collector: "volume_attachments",
+		newInformer: func(c kubernetes.Interface, _ string, resync time.Duration, tweak func(*metav1.ListOptions)) cache.SharedIndexInformer {
+			return storageinformers.NewFilteredVolumeAttachmentInformer(c, resync, cache.Indexers{}, tweak)
+		},
+		set: func(rep *Report, objs []any) {
+			rep.VolumeAttachments = &storagev1.VolumeAttachmentList{Items: storeItems[storagev1.VolumeAttachment](objs)}
+		},
+	},
+	{
+		collector: "horizontal_pod_autoscalers",
+		newInformer: func(c kubernetes.Interface, ns string, resync time.Duration, tweak func(*metav1.ListOptions)) cache.SharedIndexInformer {
+			return autoscalinginformers.NewFilteredHorizontalPodAutoscalerInformer(c, ns, resync, cache.Indexers{}, tweak)
+		},
+		set: func(rep *Report, objs []any) {
+			rep.HorizontalPodAutoscalers = &autoscalingv2.HorizontalPodAutoscalerList{Items: storeItems[autoscalingv2.HorizontalPodAutoscaler](objs)}
+		},
+	},
+	{
+		collector: "pod_disruption_budgets",
+		newInformer: func(c kubernetes.Interface, ns string, resync time.Duration, tweak func(*metav1.ListOptions)) cache.SharedIndexInformer {
+			return policyinformers.NewFilteredPodDisruptionBudgetInformer(c, ns, resync, cache.Indexers{}, tweak)
+		},
+		set: func(rep *Report, objs []any) {
+			rep.PodDisruptionBudgets = &policyv1.PodDisruptionBudgetList{Items: storeItems[policyv1.PodDisruptionBudget](objs)}
+		},
+	},
+}
+
+// WatchedCollectors returns names of collectors whose kinds
+// the watch mode keeps up to date with informers.
+func WatchedCollectors() []string {
+	names := make([]string, 0, len(watchedKinds))
+	for _, k := range watchedKinds {
+		names = append(names, k.collector)
+	}
+	return names
+}
+
+// storeItems returns deep copies of objects held in an informer store,
+// so redacting and trimming reports leaves the store intact.
+func storeItems[T any](objs []any) []T {
+	items := make([]T, 0, len(objs))
+	for _, o := range objs {
+		if obj, ok := o.(runtime.Object); ok {
+			o = obj.DeepCopyObject()
+		}
+		if item, ok := o.(*T); ok {
+			items = append(items, *item)
+		}
+	}
+	return items
+}
+
+// Watcher keeps an in-memory model of a namespace up to date with
+// informers watching the collected kinds and re-runs analyzers
+// when objects change. Kinds of collectors not listed in
+// [WatchedCollectors] are not watched.
+type Watcher struct {
+	Inspector *Inspector
+	Namespace string
+	// Resync is the interval at which objects are resynced and analyzers
+	// re-run, DefaultWatchResync when zero.
+	Resync time.Duration
+	// Debounce is the quiet period after a change before analyzers
+	// re-run, DefaultWatchDebounce when zero.
+	Debounce time.Duration
+	// OnUpdate, when set, is called with the report of the model
+	// each time analyzers re-run. The report holds only data of
+	// watched collectors.
+	OnUpdate func(Report) error
+}
+
+// watchedInformer is an informer of a watched kind.
+type watchedInformer struct {
+	kind     watchedKind
+	informer cache.SharedIndexInformer
+}
+
+// Watch watches objects of the namespace and calls handle with
+// findings that appear or resolve, starting with findings of
+// the objects found when the watch starts. It returns when ctx
+// is canceled, when handle fails or when watching is forbidden.
+func (w *Watcher) Watch(ctx context.Context, handle func(FindingChange) error) error {
+	i := w.Inspector
+	resync := w.Resync
+	if resync == 0 {
+		resync = DefaultWatchResync
+	}
+	debounce := w.Debounce
+	if debounce == 0 {
+		debounce = DefaultWatchDebounce
+	}
+	ctx, cancel := context.WithCancelCause(ctx)
+	defer cancel(nil)
+
+	changed := make(chan struct{}, 1)
+	notify := func() {
+		select {
+		case changed <- struct{}{}:
+		default:
+		}
+	}
+	handler := cache.ResourceEventHandlerFuncs{
+		AddFunc:    func(any) { notify() },
+		UpdateFunc: func(any, any) { notify() },
+		DeleteFunc: func(any) { notify() },
+	}
+
+	selected := i.selectedCollectors()
+	var informers []watchedInformer
+	var synced []cache.InformerSynced
+	for _, kind := range watchedKinds {
+		if selected != nil && !selected[kind.collector] {
+			continue
+		}
+		sel := i.selector(kind.collector)
+		informer := kind.newInformer(i.K8sClient, w.Namespace, resync, func(opts *metav1.ListOptions) {
+			opts.LabelSelector = sel.LabelSelector
+			opts.FieldSelector = sel.FieldSelector
+		})
+		collector := kind.collector
+		if err := informer.SetWatchErrorHandler(func(_ *cache.Reflector, err error) {
+			if apierrors.IsForbidden(err) || apierrors.IsUnauthorized(err) {
+				cancel(newCollectorError(collector, err))
+				return
+			}
+			i.logger().Warn("watch failed", "collector", collector, "error", err)
+		}); err != nil {
+			return err
+		}
+		if _, err := informer.AddEventHandler(handler); err != nil {
+			return err
+		}
+		informers = append(informers, watchedInformer{kind: kind, informer: informer})
+		synced = append(synced, informer.HasSynced)
+	}
+	if len(informers) == 0 {
+		return &ConfigError{Err: fmt.Errorf("no watched collectors selected, watch supports %v", WatchedCollectors())}
+	}
+	for _, inf := range informers {
+		go inf.informer.Run(ctx.Done())
+	}
+	i.logger().Info("watch started", "namespace", w.Namespace, "kinds", len(informers))
+	if !cache.WaitForCacheSync(ctx.Done(), synced...) {
+		return watchStopped(ctx)
+	}
+	i.logger().Info("watch synced", "namespace", w.Namespace)
+
+	var findings []Finding
+	for {
+		// Drop notifications of changes the model already holds.
+		select {
+		case <-changed:
+		default:
+		}
+		rep, err := w.model(informers)
+		if err != nil {
+			return err
+		}
+		if w.OnUpdate != nil {
+			if err := w.OnUpdate(rep); err != nil {
+				return err
+			}
+		}
+		for _, change := range DiffFindings(findings, rep.Findings, time.Now()) {
+			if err := handle(change); err != nil {
+				return err
+			}
+		}
+		findings = rep.Findings
+
+		select {
+		case <-ctx.Done():
+			return watchStopped(ctx)
+		case <-changed:
+		}
+		select {
+		case <-ctx.Done():
+			return watchStopped(ctx)
+		case <-time.After(debounce):
+		}
+	}
+}
+
+// watchStopped returns the error a watch stopped with,
+// nil when it was canceled by the caller.
+func watchStopped(ctx context.Context) error {
+	err := context.Cause(ctx)
+	if errors.Is(err, context.Canceled) {
+		return nil
+	}
+	return err
+}
+
+// model returns a report holding objects of the informer stores,
+// redacted and trimmed like collected reports, with analysis results.
+func (w *Watcher) model(informers []watchedInformer) (Report, error) {
+	rep := Report{
+		Metadata: Metadata{
+			SchemaVersion: SchemaVersion,
+			Inspector:     BuildVersion(),
+			FinishedAt:    time.Now(),
+			Options:       w.Inspector.effectiveOptions(),
+		},
+		Namespace: w.Namespace,
+	}
+	for _, inf := range informers {
+		var objs []any
+		for _, o := range inf.informer.GetStore().List() {
+			if m, ok := o.(metav1.Object); ok && !w.Inspector.matchesNames(inf.kind.collector, m.GetName()) {
+				continue
+			}
+			objs = append(objs, o)
+		}
+		inf.kind.set(&rep, objs)
+	}
+	if err := redactReport(&rep, w.Inspector.Redact); err != nil {
+		return Report{}, err
+	}
+	rep.SetAnalysis(AnalyzeWithOptions(rep, w.Inspector.analyzeOptions()))
+	if err := trimReport(&rep, w.Inspector.Trim); err != nil {
+		return Report{}, err
+	}
+	return rep, nil
+}
@@ -0,0 +1,152 @@
+package inspector_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/qba73/inspector"
+
+	netv1 "k8s.io/api/networking/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestWatcherReportsFindingsAsTheyAppearAndResolve(t *testing.T) {
+	t.Parallel()
+
+	client := newTestClientset(watchedIngress("web"))
+	reports := make(chan inspector.Report, 10)
+	w := &inspector.Watcher{
+		Inspector: &inspector.Inspector{
+			K8sClient:  client,
+			Collectors: []string{"ingresses", "secrets", "nodes"},
+		},
+		Namespace: "shop",
+		Debounce:  10 * time.Millisecond,
+		OnUpdate: func(rep inspector.Report) error {
+			reports <- rep
+			return nil
+		},
+	}
+	changes := make(chan inspector.FindingChange, 10)
+	ctx, cancel := context.WithCancel(context.Background())
+	watched := make(chan error)
+	go func() {
+		watched <- w.Watch(ctx, func(c inspector.FindingChange) error {
+			changes <- c
+			return nil
+		})
+	}()
+
+	got := <-changes
+	if got.Status != inspector.FindingAppeared || got.Finding.Object.Name != "web" || got.Finding.Check != inspector.CheckTLSSecretMissing {
+		t.Errorf("want missing TLS secret of web appeared, got %+v", got)
+	}
+	if rep := <-reports; rep.Ingresses == nil || len(rep.Ingresses.Items) != 1 || rep.ClusterNodes != nil {
+		t.Errorf("want report holding watched ingresses only, got %+v", rep)
+	}
+
+	if _, err := client.NetworkingV1().Ingresses("shop").Create(ctx, watchedIngress("api"), metav1.CreateOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	got = <-changes
+	if got.Status != inspector.FindingAppeared || got.Finding.Object.Name != "api" {
+		t.Errorf("want finding of api appeared, got %+v", got)
+	}
+
+	if err := client.NetworkingV1().Ingresses("shop").Delete(ctx, "web", metav1.DeleteOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	got = <-changes
+	if got.Status != inspector.FindingResolved || got.Finding.Object.Name != "web" {
+		t.Errorf("want finding of web resolved, got %+v", got)
+	}
+
+	cancel()
+	if err := <-watched; err != nil {
+		t.Errorf("want watch stopped without error, got %v", err)
+	}
+}
+
+func TestWatcherRejectsProfilesWithoutWatchedCollectors(t *testing.T) {
+	t.Parallel()
+
+	w := &inspector.Watcher{
+		Inspector: &inspector.Inspector{K8sClient: newTestClientset(), Collectors: []string{"nodes"}},
+		Namespace: "shop",
+	}
+	err := w.Watch(context.Background(), func(inspector.FindingChange) error { return nil })
+	if inspector.ExitCode(err) != inspector.ExitConfig {
+		t.Errorf("want configuration error, got %v", err)
+	}
+}
+
+func TestWatcherReportsVolumesClaimedFromTheNamespaceOnly(t *testing.T) {
+	t.Parallel()
+
+	other := releasedVolume.DeepCopy()
+	other.Name = "pv-released-web"
+	other.Spec.ClaimRef.Namespace = "web"
+	client := newTestClientset(releasedVolume, other)
+	reports := make(chan inspector.Report, 10)
+	w := &inspector.Watcher{
+		Inspector: &inspector.Inspector{K8sClient: client, Collectors: []string{"persistent_volumes"}},
+		Namespace: "db",
+		Debounce:  10 * time.Millisecond,
+		OnUpdate: func(rep inspector.Report) error {
+			reports <- rep
+			return nil
+		},
+	}
+	changes := make(chan inspector.FindingChange, 10)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go w.Watch(ctx, func(c inspector.FindingChange) error {
+		changes <- c
+		return nil
+	})
+
+	got := <-changes
+	if got.Finding.Check != inspector.CheckPVReleased || got.Finding.Object.Name != "pv-released" {
+		t.Errorf("want released volume of the db namespace reported, got %+v", got)
+	}
+	rep := <-reports
+	var names []string
+	for _, pv := range rep.PersistentVolumes.Items {
+		names = append(names, pv.Name)
+	}
+	want := []string{"pv-released"}
+	if !cmp.Equal(want, names) {
+		t.Error(cmp.Diff(want, names))
+	}
+}
+
+func TestDiffFindingsReportsAppearedAndResolvedFindings(t *testing.T) {
+	t.Parallel()
+
+	now := time.Date(2026, 6, 1, 9, 0, 0, 0, time.UTC)
+	expiring := inspector.Finding{Check: inspector.CheckCertificateExpiring, Severity: inspector.SeverityWarning, Object: inspector.ObjectRef{Kind: "Secret", Namespace: "shop", Name: "web-tls"}}
+	missing := inspector.Finding{Check: inspector.CheckTLSSecretMissing, Severity: inspector.SeverityCritical, Object: inspector.ObjectRef{Kind: "Ingress", Namespace: "shop", Name: "api"}}
+	failed := inspector.Finding{Check: inspector.CheckJobFailed, Severity: inspector.SeverityWarning, Object: inspector.ObjectRef{Kind: "Job", Namespace: "shop", Name: "backup"}}
+
+	got := inspector.DiffFindings([]inspector.Finding{expiring, missing}, []inspector.Finding{missing, failed}, now)
+	want := []inspector.FindingChange{
+		{Time: now, Status: inspector.FindingAppeared, Finding: failed},
+		{Time: now, Status: inspector.FindingResolved, Finding: expiring},
+	}
+	if !cmp.Equal(want, got) {
+		t.Error(cmp.Diff(want, got))
+	}
+}
+
+// watchedIngress returns an ingress of the shop namespace
+// referencing a missing TLS secret.
+func watchedIngress(name string) *netv1.Ingress {
+	return &netv1.Ingress{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "shop"},
+		Spec: netv1.IngressSpec{
+			TLS: []netv1.IngressTLS{{Hosts: []string{name + ".example.com"}, SecretName: name + "-tls"}},
+		},
+	}
+}
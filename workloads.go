@@ -0,0 +1,129 @@
+package inspector
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	appsv1 "k8s.io/api/apps/v1"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+)
+
+// Workload checks.
+const (
+	CheckJobFailed             = "JobFailed"
+	CheckCronJobNotSucceeded   = "CronJobNotSucceeded"
+	CheckDaemonSetUnavailable  = "DaemonSetUnavailable"
+	CheckDaemonSetMisscheduled = "DaemonSetMisscheduled"
+	CheckDaemonSetNotScheduled = "DaemonSetNotScheduled"
+)
+
+// Jobs returns a list of [jobs] in a given namespace.
+//
+// [jobs]: https://kubernetes.io/docs/concepts/workloads/controllers/job/
+func (i *Inspector) Jobs(ctx context.Context, namespace string) (*batchv1.JobList, error) {
+	jobs, err := listPages(ctx, i, "jobs", i.K8sClient.BatchV1().Jobs(namespace).List)
+	if err != nil {
+		return nil, err
+	}
+	return jobs, nil
+}
+
+// CronJobs returns a list of [cron jobs] in a given namespace.
+//
+// [cron jobs]: https://kubernetes.io/docs/concepts/workloads/controllers/cron-jobs/
+func (i *Inspector) CronJobs(ctx context.Context, namespace string) (*batchv1.CronJobList, error) {
+	cronJobs, err := listPages(ctx, i, "cron_jobs", i.K8sClient.BatchV1().CronJobs(namespace).List)
+	if err != nil {
+		return nil, err
+	}
+	return cronJobs, nil
+}
+
+// DaemonSets returns a list of [daemon sets] in a given namespace.
+//
+// [daemon sets]: https://kubernetes.io/docs/concepts/workloads/controllers/daemonset/
+func (i *Inspector) DaemonSets(ctx context.Context, namespace string) (*appsv1.DaemonSetList, error) {
+	daemonSets, err := listPages(ctx, i, "daemon_sets", i.K8sClient.AppsV1().DaemonSets(namespace).List)
+	if err != nil {
+		return nil, err
+	}
+	return daemonSets, nil
+}
+
+// AnalyzeWorkloads returns findings for failed jobs, cron jobs whose
+// last scheduled run has not succeeded, and daemon sets with pods
+// unavailable, not scheduled or running on nodes they should not run on.
+func AnalyzeWorkloads(jobs *batchv1.JobList, cronJobs *batchv1.CronJobList, daemonSets *appsv1.DaemonSetList) []Finding {
+	findings := []Finding{}
+	if jobs != nil {
+		for _, job := range jobs.Items {
+			for _, c := range job.Status.Conditions {
+				if c.Type != batchv1.JobFailed || c.Status != corev1.ConditionTrue {
+					continue
+				}
+				findings = append(findings, Finding{
+					Check:    CheckJobFailed,
+					Severity: SeverityCritical,
+					Object:   ObjectRef{Kind: "Job", Namespace: job.Namespace, Name: job.Name},
+					Message:  fmt.Sprintf("%s: %s (%d failed pods)", c.Reason, c.Message, job.Status.Failed),
+				})
+			}
+		}
+	}
+	if cronJobs != nil {
+		for _, cj := range cronJobs.Items {
+			last := cj.Status.LastScheduleTime
+			if last == nil || len(cj.Status.Active) > 0 {
+				continue
+			}
+			success := cj.Status.LastSuccessfulTime
+			if success != nil && !success.Before(last) {
+				continue
+			}
+			msg := fmt.Sprintf("run scheduled at %s with schedule %q has not succeeded, no successful run recorded", last.UTC().Format(time.RFC3339), cj.Spec.Schedule)
+			if success != nil {
+				msg = fmt.Sprintf("run scheduled at %s with schedule %q has not succeeded, last success at %s", last.UTC().Format(time.RFC3339), cj.Spec.Schedule, success.UTC().Format(time.RFC3339))
+			}
+			findings = append(findings, Finding{
+				Check:    CheckCronJobNotSucceeded,
+				Severity: SeverityWarning,
+				Object:   ObjectRef{Kind: "CronJob", Namespace: cj.Namespace, Name: cj.Name},
+				Message:  msg,
+			})
+		}
+	}
+	if daemonSets != nil {
+		for _, ds := range daemonSets.Items {
+			ref := ObjectRef{Kind: "DaemonSet", Namespace: ds.Namespace, Name: ds.Name}
+			status := ds.Status
+			if status.NumberUnavailable > 0 {
+				findings = append(findings, Finding{
+					Check:    CheckDaemonSetUnavailable,
+					Severity: SeverityCritical,
+					Object:   ref,
+					Message:  fmt.Sprintf("%d of %d pods unavailable", status.NumberUnavailable, status.DesiredNumberScheduled),
+				})
+			}
+			if status.DesiredNumberScheduled > status.CurrentNumberScheduled {
+				findings = append(findings, Finding{
+					Check:    CheckDaemonSetNotScheduled,
+					Severity: SeverityWarning,
+					Object:   ref,
+					Message:  fmt.Sprintf("%d of %d nodes run no pod", status.DesiredNumberScheduled-status.CurrentNumberScheduled, status.DesiredNumberScheduled),
+				})
+			}
+			if status.NumberMisscheduled > 0 {
+				findings = append(findings, Finding{
+					Check:    CheckDaemonSetMisscheduled,
+					Severity: SeverityWarning,
+					Object:   ref,
+					Message:  fmt.Sprintf("%d pods run on nodes they should not run on", status.NumberMisscheduled),
+				})
+			}
+		}
+	}
+	sortFindings(findings)
+	return findings
+}
@@ -0,0 +1,148 @@
+package inspector_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/qba73/inspector"
+
+	appsv1 "k8s.io/api/apps/v1"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestInspectorReportCollectsJobsCronJobsAndDaemonSets(t *testing.T) {
+	t.Parallel()
+
+	i := &inspector.Inspector{
+		K8sClient:  newTestClientset(failedJob, failingCronJob, unavailableDaemonSet),
+		Collectors: []string{"jobs", "cron_jobs", "daemon_sets"},
+	}
+	report, err := i.Report(context.Background(), "default")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if report.Jobs == nil || len(report.Jobs.Items) != 1 {
+		t.Errorf("want 1 job, got %+v", report.Jobs)
+	}
+	if report.CronJobs == nil || len(report.CronJobs.Items) != 1 {
+		t.Errorf("want 1 cron job, got %+v", report.CronJobs)
+	}
+	if report.DaemonSets == nil || len(report.DaemonSets.Items) != 1 {
+		t.Errorf("want 1 daemon set, got %+v", report.DaemonSets)
+	}
+	if got := checks(report.Findings); len(got) != 5 {
+		t.Errorf("want workload findings in the report, got %v", got)
+	}
+}
+
+func TestAnalyzeWorkloadsReportsFailedJobsCronJobsAndDaemonSets(t *testing.T) {
+	t.Parallel()
+
+	got := inspector.AnalyzeWorkloads(
+		&batchv1.JobList{Items: []batchv1.Job{*failedJob, *runningJob}},
+		&batchv1.CronJobList{Items: []batchv1.CronJob{*failingCronJob, *healthyCronJob}},
+		&appsv1.DaemonSetList{Items: []appsv1.DaemonSet{*unavailableDaemonSet}},
+	)
+	want := []inspector.Finding{
+		{
+			Check:    inspector.CheckCronJobNotSucceeded,
+			Severity: inspector.SeverityWarning,
+			Object:   inspector.ObjectRef{Kind: "CronJob", Namespace: "default", Name: "backup"},
+			Message:  `run scheduled at 2026-06-01T02:00:00Z with schedule "0 2 * * *" has not succeeded, last success at 2026-05-30T02:05:00Z`,
+		},
+		{
+			Check:    inspector.CheckDaemonSetMisscheduled,
+			Severity: inspector.SeverityWarning,
+			Object:   inspector.ObjectRef{Kind: "DaemonSet", Namespace: "default", Name: "nginx-ingress"},
+			Message:  "1 pods run on nodes they should not run on",
+		},
+		{
+			Check:    inspector.CheckDaemonSetNotScheduled,
+			Severity: inspector.SeverityWarning,
+			Object:   inspector.ObjectRef{Kind: "DaemonSet", Namespace: "default", Name: "nginx-ingress"},
+			Message:  "1 of 3 nodes run no pod",
+		},
+		{
+			Check:    inspector.CheckDaemonSetUnavailable,
+			Severity: inspector.SeverityCritical,
+			Object:   inspector.ObjectRef{Kind: "DaemonSet", Namespace: "default", Name: "nginx-ingress"},
+			Message:  "2 of 3 pods unavailable",
+		},
+		{
+			Check:    inspector.CheckJobFailed,
+			Severity: inspector.SeverityCritical,
+			Object:   inspector.ObjectRef{Kind: "Job", Namespace: "default", Name: "backup-29100000"},
+			Message:  "BackoffLimitExceeded: Job has reached the specified backoff limit (7 failed pods)",
+		},
+	}
+	if !cmp.Equal(want, got) {
+		t.Error(cmp.Diff(want, got))
+	}
+}
+
+func TestAnalyzeWorkloadsReportsCronJobsWithoutSuccessfulRuns(t *testing.T) {
+	t.Parallel()
+
+	cj := failingCronJob.DeepCopy()
+	cj.Status.LastSuccessfulTime = nil
+	got := inspector.AnalyzeWorkloads(nil, &batchv1.CronJobList{Items: []batchv1.CronJob{*cj}}, nil)
+	if len(got) != 1 || got[0].Message != `run scheduled at 2026-06-01T02:00:00Z with schedule "0 2 * * *" has not succeeded, no successful run recorded` {
+		t.Errorf("want cron job finding, got %+v", got)
+	}
+
+	cj.Status.Active = []corev1.ObjectReference{{Kind: "Job", Name: "backup-29100120"}}
+	if got := inspector.AnalyzeWorkloads(nil, &batchv1.CronJobList{Items: []batchv1.CronJob{*cj}}, nil); len(got) != 0 {
+		t.Errorf("want no findings while the scheduled run is active, got %+v", got)
+	}
+}
+
+var (
+	lastBackupSchedule = metav1.NewTime(time.Date(2026, 6, 1, 2, 0, 0, 0, time.UTC))
+	lastBackupSuccess  = metav1.NewTime(time.Date(2026, 5, 30, 2, 5, 0, 0, time.UTC))
+
+	failedJob = &batchv1.Job{
+		ObjectMeta: metav1.ObjectMeta{Name: "backup-29100000", Namespace: "default"},
+		Status: batchv1.JobStatus{
+			Failed: 7,
+			Conditions: []batchv1.JobCondition{{
+				Type:    batchv1.JobFailed,
+				Status:  corev1.ConditionTrue,
+				Reason:  "BackoffLimitExceeded",
+				Message: "Job has reached the specified backoff limit",
+			}},
+		},
+	}
+	runningJob = &batchv1.Job{
+		ObjectMeta: metav1.ObjectMeta{Name: "migrate", Namespace: "default"},
+		Status:     batchv1.JobStatus{Active: 1, Failed: 1},
+	}
+	failingCronJob = &batchv1.CronJob{
+		ObjectMeta: metav1.ObjectMeta{Name: "backup", Namespace: "default"},
+		Spec:       batchv1.CronJobSpec{Schedule: "0 2 * * *"},
+		Status: batchv1.CronJobStatus{
+			LastScheduleTime:   &lastBackupSchedule,
+			LastSuccessfulTime: &lastBackupSuccess,
+		},
+	}
+	healthyCronJob = &batchv1.CronJob{
+		ObjectMeta: metav1.ObjectMeta{Name: "report", Namespace: "default"},
+		Spec:       batchv1.CronJobSpec{Schedule: "0 * * * *"},
+		Status: batchv1.CronJobStatus{
+			LastScheduleTime:   &lastBackupSchedule,
+			LastSuccessfulTime: &lastBackupSchedule,
+		},
+	}
+	unavailableDaemonSet = &appsv1.DaemonSet{
+		ObjectMeta: metav1.ObjectMeta{Name: "nginx-ingress", Namespace: "default"},
+		Status: appsv1.DaemonSetStatus{
+			DesiredNumberScheduled: 3,
+			CurrentNumberScheduled: 2,
+			NumberMisscheduled:     1,
+			NumberUnavailable:      2,
+		},
+	}
+)